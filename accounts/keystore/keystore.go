@@ -37,7 +37,6 @@ import (
 	"github.com/MetisProtocol/l2geth/accounts"
 	"github.com/MetisProtocol/l2geth/common"
 	"github.com/MetisProtocol/l2geth/core/types"
-	"github.com/MetisProtocol/l2geth/core/vm"
 	"github.com/MetisProtocol/l2geth/crypto"
 	"github.com/MetisProtocol/l2geth/event"
 	"github.com/MetisProtocol/l2geth/log"
@@ -78,12 +77,13 @@ type unlocked struct {
 	abort chan struct{}
 }
 
-// NewKeyStore creates a keystore for the given directory.
-func NewKeyStore(keydir string, scryptN, scryptP int) *KeyStore {
+// NewKeyStore creates a keystore for the given directory. A keystore of an
+// OVM node also holds the deterministic clique signer key.
+func NewKeyStore(keydir string, scryptN, scryptP int, usingOVM bool) *KeyStore {
 	keydir, _ = filepath.Abs(keydir)
 	ks := &KeyStore{storage: &keyStorePassphrase{keydir, scryptN, scryptP, false}}
 	ks.init(keydir)
-	if vm.UsingOVM {
+	if usingOVM {
 		// Add a deterministic key to the key store so that
 		// all clique blocks are signed with the same key.
 		// This change will result in deterministic blocks across
@@ -18,6 +18,7 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -36,6 +37,7 @@ import (
 	"github.com/MetisProtocol/l2geth/eth/downloader"
 	"github.com/MetisProtocol/l2geth/event"
 	"github.com/MetisProtocol/l2geth/log"
+	statedump "github.com/MetisProtocol/l2geth/rollup/dump"
 	"github.com/MetisProtocol/l2geth/trie"
 	"gopkg.in/urfave/cli.v1"
 )
@@ -48,6 +50,7 @@ var (
 		ArgsUsage: "<genesisPath>",
 		Flags: []cli.Flag{
 			utils.DataDirFlag,
+			utils.RollupUsingOVMFlag,
 		},
 		Category: "BLOCKCHAIN COMMANDS",
 		Description: `
@@ -188,6 +191,22 @@ Use "ethereum dump 0" to dump the genesis block.`,
 		},
 		Category: "BLOCKCHAIN COMMANDS",
 	}
+	dumpCheckCommand = cli.Command{
+		Action:    utils.MigrateFlags(checkStateDump),
+		Name:      "dumpcheck",
+		Usage:     "Validate an OVM state dump",
+		ArgsUsage: "[<stateDumpPath>]",
+		Flags: []cli.Flag{
+			utils.RollupStateDumpPathFlag,
+			utils.RollupStateDumpHashFlag,
+		},
+		Category: "BLOCKCHAIN COMMANDS",
+		Description: `
+The dumpcheck command reads the state dump at the given path, or at the state
+dump path if no path is given, and checks that it can be used to create the
+genesis state and the OVM execution context. Every problem that is found is
+printed and the command fails if there are any.`,
+	}
 )
 
 // initGenesis will initialise the given JSON format genesis file and writes it as
@@ -217,7 +236,7 @@ func initGenesis(ctx *cli.Context) error {
 		if err != nil {
 			utils.Fatalf("Failed to open database: %v", err)
 		}
-		_, hash, err := core.SetupGenesisBlock(chaindb, genesis)
+		_, hash, err := core.SetupGenesisBlockWithOverride(chaindb, genesis, nil, nil, utils.MakeOVMBlock(ctx))
 		if err != nil {
 			utils.Fatalf("Failed to write genesis block: %v", err)
 		}
@@ -552,6 +571,36 @@ func inspect(ctx *cli.Context) error {
 	return rawdb.InspectDatabase(chainDb)
 }
 
+// checkStateDump reads and validates a state dump
+func checkStateDump(ctx *cli.Context) error {
+	path := ctx.Args().First()
+	if path == "" {
+		path = ctx.GlobalString(utils.RollupStateDumpPathFlag.Name)
+	}
+	var hash common.Hash
+	if ctx.GlobalIsSet(utils.RollupStateDumpHashFlag.Name) {
+		if err := hash.UnmarshalText([]byte(ctx.GlobalString(utils.RollupStateDumpHashFlag.Name))); err != nil {
+			utils.Fatalf("Invalid state dump hash: %v", err)
+		}
+	}
+	stateDump, err := statedump.Load(path, hash)
+	if err != nil {
+		utils.Fatalf("Failed to load state dump: %v", err)
+	}
+	if err := statedump.Validate(stateDump); err != nil {
+		var verr *statedump.ValidationError
+		if !errors.As(err, &verr) {
+			utils.Fatalf("Invalid state dump: %v", err)
+		}
+		for _, problem := range verr.Problems {
+			fmt.Println(problem)
+		}
+		utils.Fatalf("State dump %s has %d problems", path, len(verr.Problems))
+	}
+	fmt.Printf("State dump %s is valid, %d accounts\n", path, len(stateDump.Accounts))
+	return nil
+}
+
 // hashish returns true for strings that look like hashes.
 func hashish(x string) bool {
 	_, err := strconv.Atoi(x)
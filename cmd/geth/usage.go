@@ -73,17 +73,43 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.Eth1StandardBridgeAddressFlag,
 			utils.Eth1ChainIdFlag,
 			utils.RollupClientHttpFlag,
+			utils.RollupClientQuorumFlag,
+			utils.RollupClientTypeFlag,
+			utils.RollupClientWsFlag,
+			utils.RollupClientReplayPathFlag,
 			utils.RollupAddressManagerOwnerAddressFlag,
 			utils.RollupEnableVerifierFlag,
+			utils.RollupUsingOVMFlag,
+			utils.RollupEnableArbitraryContractDeploymentFlag,
+			utils.RollupHaltOnStateRootMismatchFlag,
 			utils.RollupTimstampRefreshFlag,
 			utils.RollupPollIntervalFlag,
+			utils.RollupTxCommitTimeoutFlag,
+			utils.RollupMaxTxsPerBlockFlag,
+			utils.RollupBlockWindowFlag,
+			utils.RollupFutureTxLifetimeFlag,
+			utils.RollupMaxFutureTxsPerSenderFlag,
+			utils.RollupMaxFutureTxsFlag,
+			utils.RollupOrderingPolicyFlag,
+			utils.RollupEnqueueMaxDelayFlag,
+			utils.RollupForceInclusionPeriodFlag,
+			utils.RollupForceInclusionMarginFlag,
+			utils.RollupContextPolicyFlag,
+			utils.RollupPrefetchWorkersFlag,
+			utils.RollupPrefetchDepthFlag,
 			utils.RollupStateDumpPathFlag,
+			utils.RollupStateDumpHashFlag,
 			utils.RollupMaxCalldataSizeFlag,
 			utils.RollupBackendFlag,
 			utils.RollupEnforceFeesFlag,
 			utils.RollupMinL2GasLimitFlag,
 			utils.RollupFeeThresholdDownFlag,
 			utils.RollupFeeThresholdUpFlag,
+			utils.RollupL1GasPriceEMAWeightFlag,
+			utils.RollupL1GasPriceMaxChangeFlag,
+			utils.RollupL1GasPriceFloorFlag,
+			utils.RollupL1GasPriceCeilingFlag,
+			utils.RollupDiffDbCacheFlag,
 			utils.GasPriceOracleOwnerAddress,
 		},
 	},
@@ -842,16 +842,113 @@ var (
 	}
 	RollupClientHttpFlag = cli.StringFlag{
 		Name:   "rollup.clienthttp",
-		Usage:  "HTTP endpoint for the rollup client",
+		Usage:  "Comma separated HTTP endpoints for the rollup client, requests fail over between them",
 		Value:  "http://localhost:7878",
 		EnvVar: "ROLLUP_CLIENT_HTTP",
 	}
+	RollupClientQuorumFlag = cli.IntFlag{
+		Name:   "rollup.clientquorum",
+		Usage:  "Number of rollup client HTTP endpoints that must agree on transaction batches and enqueues",
+		Value:  1,
+		EnvVar: "ROLLUP_CLIENT_QUORUM",
+	}
+	RollupClientTypeFlag = cli.StringFlag{
+		Name:   "rollup.clienttype",
+		Usage:  "Transport of the rollup client (\"http\", \"ws\" or \"replay\"), defaults to http",
+		Value:  "http",
+		EnvVar: "ROLLUP_CLIENT_TYPE",
+	}
+	RollupClientWsFlag = cli.StringFlag{
+		Name:   "rollup.clientws",
+		Usage:  "Websocket endpoint for the rollup client, used with the ws client type",
+		EnvVar: "ROLLUP_CLIENT_WS",
+	}
+	RollupClientReplayPathFlag = cli.StringFlag{
+		Name:   "rollup.clientreplaypath",
+		Usage:  "Path to the JSONL or RLP archive that is replayed by the replay client type",
+		EnvVar: "ROLLUP_CLIENT_REPLAY_PATH",
+	}
 	RollupPollIntervalFlag = cli.DurationFlag{
 		Name:   "rollup.pollinterval",
 		Usage:  "Interval for polling with the rollup http client",
 		Value:  time.Second * 10,
 		EnvVar: "ROLLUP_POLL_INTERVAL_FLAG",
 	}
+	RollupTxCommitTimeoutFlag = cli.DurationFlag{
+		Name:   "rollup.txcommittimeout",
+		Usage:  "Maximum time to wait for a transaction to be added to the chain",
+		Value:  time.Minute,
+		EnvVar: "ROLLUP_TX_COMMIT_TIMEOUT",
+	}
+	RollupMaxTxsPerBlockFlag = cli.IntFlag{
+		Name:   "rollup.maxtxsperblock",
+		Usage:  "Maximum number of sequencer transactions in a block, more than 1 enables multi transaction blocks",
+		Value:  1,
+		EnvVar: "ROLLUP_MAX_TXS_PER_BLOCK",
+	}
+	RollupBlockWindowFlag = cli.DurationFlag{
+		Name:   "rollup.blockwindow",
+		Usage:  "Maximum time to wait for more transactions before sealing a multi transaction block",
+		Value:  time.Second,
+		EnvVar: "ROLLUP_BLOCK_WINDOW",
+	}
+	RollupFutureTxLifetimeFlag = cli.DurationFlag{
+		Name:   "rollup.futuretxlifetime",
+		Usage:  "Maximum time to hold a sequencer transaction whose nonce is ahead of its sender, 0 rejects them",
+		Value:  time.Minute,
+		EnvVar: "ROLLUP_FUTURE_TX_LIFETIME",
+	}
+	RollupMaxFutureTxsPerSenderFlag = cli.IntFlag{
+		Name:   "rollup.maxfuturetxspersender",
+		Usage:  "Maximum number of future nonce sequencer transactions to hold per sender",
+		Value:  16,
+		EnvVar: "ROLLUP_MAX_FUTURE_TXS_PER_SENDER",
+	}
+	RollupMaxFutureTxsFlag = cli.IntFlag{
+		Name:   "rollup.maxfuturetxs",
+		Usage:  "Maximum number of future nonce sequencer transactions to hold for all senders",
+		Value:  1024,
+		EnvVar: "ROLLUP_MAX_FUTURE_TXS",
+	}
+	RollupOrderingPolicyFlag = cli.StringFlag{
+		Name:   "rollup.orderingpolicy",
+		Usage:  "Order of the sequencer transactions: fifo, fee or roundrobin",
+		Value:  "fifo",
+		EnvVar: "ROLLUP_ORDERING_POLICY",
+	}
+	RollupEnqueueMaxDelayFlag = cli.DurationFlag{
+		Name:   "rollup.enqueuemaxdelay",
+		Usage:  "Maximum time that an enqueue transaction waits behind sequencer transactions that arrived after it, 0 keeps the order of arrival",
+		EnvVar: "ROLLUP_ENQUEUE_MAX_DELAY",
+	}
+	RollupForceInclusionPeriodFlag = cli.DurationFlag{
+		Name:   "rollup.forceinclusionperiod",
+		Usage:  "Force inclusion period of the canonical transaction chain, 0 disables tracking the deadlines of enqueue transactions",
+		EnvVar: "ROLLUP_FORCE_INCLUSION_PERIOD",
+	}
+	RollupForceInclusionMarginFlag = cli.DurationFlag{
+		Name:   "rollup.forceinclusionmargin",
+		Usage:  "Time before the force inclusion deadline from which an enqueue transaction is applied ahead of sequencer transactions",
+		EnvVar: "ROLLUP_FORCE_INCLUSION_MARGIN",
+	}
+	RollupContextPolicyFlag = cli.StringFlag{
+		Name:   "rollup.contextpolicy",
+		Usage:  "Policy for transactions whose L1 context goes back in time (clamp, reject)",
+		Value:  "clamp",
+		EnvVar: "ROLLUP_CONTEXT_POLICY",
+	}
+	RollupPrefetchWorkersFlag = cli.IntFlag{
+		Name:   "rollup.prefetchworkers",
+		Usage:  "Number of concurrent workers that fetch transactions, enqueues and batches while syncing",
+		Value:  eth.DefaultConfig.Rollup.PrefetchWorkers,
+		EnvVar: "ROLLUP_PREFETCH_WORKERS",
+	}
+	RollupPrefetchDepthFlag = cli.IntFlag{
+		Name:   "rollup.prefetchdepth",
+		Usage:  "Number of transactions, enqueues or batches that are fetched ahead of the one being applied",
+		Value:  eth.DefaultConfig.Rollup.PrefetchDepth,
+		EnvVar: "ROLLUP_PREFETCH_DEPTH",
+	}
 	RollupTimstampRefreshFlag = cli.DurationFlag{
 		Name:   "rollup.timestamprefresh",
 		Usage:  "Interval for refreshing the timestamp",
@@ -869,6 +966,16 @@ var (
 		Usage:  "Enable the verifier",
 		EnvVar: "ROLLUP_VERIFIER_ENABLE",
 	}
+	RollupUsingOVMFlag = cli.BoolFlag{
+		Name:   "rollup.usingovm",
+		Usage:  "Apply the OVM rules from the genesis block on",
+		EnvVar: "USING_OVM",
+	}
+	RollupEnableArbitraryContractDeploymentFlag = cli.StringFlag{
+		Name:   "rollup.enablearbitrarycontractdeployment",
+		Usage:  "Override the deployer whitelist (true or false)",
+		EnvVar: "ROLLUP_ENABLE_ARBITRARY_CONTRACT_DEPLOYMENT",
+	}
 	RollupAddressManagerOwnerAddressFlag = cli.StringFlag{
 		Name:   "rollup.addressmanagerowneraddress",
 		Usage:  "Owner address of the address manager",
@@ -881,12 +988,22 @@ var (
 		Value:  eth.DefaultConfig.Rollup.StateDumpPath,
 		EnvVar: "ROLLUP_STATE_DUMP_PATH",
 	}
+	RollupStateDumpHashFlag = cli.StringFlag{
+		Name:   "rollup.statedumphash",
+		Usage:  "Expected keccak256 hash of the decompressed state dump",
+		EnvVar: "ROLLUP_STATE_DUMP_HASH",
+	}
 	RollupMaxCalldataSizeFlag = cli.IntFlag{
 		Name:   "rollup.maxcalldatasize",
 		Usage:  "Maximum allowed calldata size for Queue Origin Sequencer Txs",
 		Value:  eth.DefaultConfig.Rollup.MaxCallDataSize,
 		EnvVar: "ROLLUP_MAX_CALLDATA_SIZE",
 	}
+	RollupHaltOnStateRootMismatchFlag = cli.BoolFlag{
+		Name:   "rollup.haltonstaterootmismatch",
+		Usage:  "Stop syncing when a state root submitted to L1 does not match the local state root",
+		EnvVar: "ROLLUP_HALT_ON_STATE_ROOT_MISMATCH",
+	}
 	RollupEnforceFeesFlag = cli.BoolFlag{
 		Name:   "rollup.enforcefeesflag",
 		Usage:  "Disable transactions with 0 gas price",
@@ -907,6 +1024,31 @@ var (
 		Usage:  "Allow txs with fees above the current fee up to this amount, must be > 1",
 		EnvVar: "ROLLUP_FEE_THRESHOLD_UP",
 	}
+	RollupL1GasPriceEMAWeightFlag = cli.Float64Flag{
+		Name:   "rollup.l1gaspriceemaweight",
+		Usage:  "Weight of a new L1 gas price in its moving average, 0 disables the moving average",
+		EnvVar: "ROLLUP_L1_GAS_PRICE_EMA_WEIGHT",
+	}
+	RollupL1GasPriceMaxChangeFlag = cli.Float64Flag{
+		Name:   "rollup.l1gaspricemaxchange",
+		Usage:  "Largest change of the L1 gas price per update in percent, 0 disables the limit",
+		EnvVar: "ROLLUP_L1_GAS_PRICE_MAX_CHANGE",
+	}
+	RollupL1GasPriceFloorFlag = cli.Uint64Flag{
+		Name:   "rollup.l1gaspricefloor",
+		Usage:  "Lowest L1 gas price used for fees",
+		EnvVar: "ROLLUP_L1_GAS_PRICE_FLOOR",
+	}
+	RollupL1GasPriceCeilingFlag = cli.Uint64Flag{
+		Name:   "rollup.l1gaspriceceiling",
+		Usage:  "Highest L1 gas price used for fees",
+		EnvVar: "ROLLUP_L1_GAS_PRICE_CEILING",
+	}
+	RollupDiffDbCacheFlag = cli.Uint64Flag{
+		Name:   "rollup.diffdbcache",
+		Usage:  "Number of state diff insertions committed together, enables recording of state diffs",
+		EnvVar: "ROLLUP_DIFFDB_CACHE",
+	}
 	GasPriceOracleOwnerAddress = cli.StringFlag{
 		Name:   "rollup.gaspriceoracleowneraddress",
 		Usage:  "Owner of the OVM_GasPriceOracle",
@@ -1175,20 +1317,96 @@ func setRollup(ctx *cli.Context, cfg *rollup.Config) {
 	if ctx.GlobalIsSet(RollupEnableVerifierFlag.Name) {
 		cfg.IsVerifier = true
 	}
+	if ctx.GlobalIsSet(RollupUsingOVMFlag.Name) {
+		cfg.UsingOVM = ctx.GlobalBool(RollupUsingOVMFlag.Name)
+	}
+	if ctx.GlobalIsSet(RollupEnableArbitraryContractDeploymentFlag.Name) {
+		val := ctx.GlobalString(RollupEnableArbitraryContractDeploymentFlag.Name)
+		enable, err := strconv.ParseBool(val)
+		if err != nil {
+			Fatalf("Option %q: unknown value %q", RollupEnableArbitraryContractDeploymentFlag.Name, val)
+		}
+		cfg.EnableArbitraryContractDeployment = &enable
+	}
+	if ctx.GlobalIsSet(RollupHaltOnStateRootMismatchFlag.Name) {
+		cfg.HaltOnStateRootMismatch = true
+	}
 	if ctx.GlobalIsSet(RollupStateDumpPathFlag.Name) {
 		cfg.StateDumpPath = ctx.GlobalString(RollupStateDumpPathFlag.Name)
 	} else {
 		cfg.StateDumpPath = eth.DefaultConfig.Rollup.StateDumpPath
 	}
+	if ctx.GlobalIsSet(RollupStateDumpHashFlag.Name) {
+		val := ctx.GlobalString(RollupStateDumpHashFlag.Name)
+		if err := cfg.StateDumpHash.UnmarshalText([]byte(val)); err != nil {
+			Fatalf("Option %q: %v", RollupStateDumpHashFlag.Name, err)
+		}
+	}
 	if ctx.GlobalIsSet(RollupMaxCalldataSizeFlag.Name) {
 		cfg.MaxCallDataSize = ctx.GlobalInt(RollupMaxCalldataSizeFlag.Name)
 	}
 	if ctx.GlobalIsSet(RollupClientHttpFlag.Name) {
 		cfg.RollupClientHttp = ctx.GlobalString(RollupClientHttpFlag.Name)
 	}
+	if ctx.GlobalIsSet(RollupClientQuorumFlag.Name) {
+		cfg.ClientQuorum = ctx.GlobalInt(RollupClientQuorumFlag.Name)
+	}
+	if ctx.GlobalIsSet(RollupClientTypeFlag.Name) {
+		val := ctx.GlobalString(RollupClientTypeFlag.Name)
+		clientType, err := rollup.NewClientType(val)
+		if err != nil {
+			Fatalf("Option %q: %v", RollupClientTypeFlag.Name, err)
+		}
+		cfg.ClientType = clientType
+	}
+	if ctx.GlobalIsSet(RollupClientWsFlag.Name) {
+		cfg.RollupClientWs = ctx.GlobalString(RollupClientWsFlag.Name)
+	}
+	if ctx.GlobalIsSet(RollupClientReplayPathFlag.Name) {
+		cfg.RollupClientReplayPath = ctx.GlobalString(RollupClientReplayPathFlag.Name)
+	}
 	if ctx.GlobalIsSet(RollupPollIntervalFlag.Name) {
 		cfg.PollInterval = ctx.GlobalDuration(RollupPollIntervalFlag.Name)
 	}
+	if ctx.GlobalIsSet(RollupTxCommitTimeoutFlag.Name) {
+		cfg.TxCommitTimeout = ctx.GlobalDuration(RollupTxCommitTimeoutFlag.Name)
+	}
+	if ctx.GlobalIsSet(RollupMaxTxsPerBlockFlag.Name) {
+		cfg.MaxTxsPerBlock = ctx.GlobalInt(RollupMaxTxsPerBlockFlag.Name)
+	}
+	if ctx.GlobalIsSet(RollupBlockWindowFlag.Name) {
+		cfg.BlockWindow = ctx.GlobalDuration(RollupBlockWindowFlag.Name)
+	}
+	if ctx.GlobalIsSet(RollupFutureTxLifetimeFlag.Name) {
+		cfg.FutureTxLifetime = ctx.GlobalDuration(RollupFutureTxLifetimeFlag.Name)
+	}
+	if ctx.GlobalIsSet(RollupMaxFutureTxsPerSenderFlag.Name) {
+		cfg.MaxFutureTxsPerSender = ctx.GlobalInt(RollupMaxFutureTxsPerSenderFlag.Name)
+	}
+	if ctx.GlobalIsSet(RollupMaxFutureTxsFlag.Name) {
+		cfg.MaxFutureTxs = ctx.GlobalInt(RollupMaxFutureTxsFlag.Name)
+	}
+	if ctx.GlobalIsSet(RollupOrderingPolicyFlag.Name) {
+		cfg.OrderingPolicy = ctx.GlobalString(RollupOrderingPolicyFlag.Name)
+	}
+	if ctx.GlobalIsSet(RollupEnqueueMaxDelayFlag.Name) {
+		cfg.EnqueueMaxDelay = ctx.GlobalDuration(RollupEnqueueMaxDelayFlag.Name)
+	}
+	if ctx.GlobalIsSet(RollupForceInclusionPeriodFlag.Name) {
+		cfg.ForceInclusionPeriod = ctx.GlobalDuration(RollupForceInclusionPeriodFlag.Name)
+	}
+	if ctx.GlobalIsSet(RollupForceInclusionMarginFlag.Name) {
+		cfg.ForceInclusionMargin = ctx.GlobalDuration(RollupForceInclusionMarginFlag.Name)
+	}
+	if ctx.GlobalIsSet(RollupContextPolicyFlag.Name) {
+		cfg.ContextPolicy = ctx.GlobalString(RollupContextPolicyFlag.Name)
+	}
+	if ctx.GlobalIsSet(RollupPrefetchWorkersFlag.Name) {
+		cfg.PrefetchWorkers = ctx.GlobalInt(RollupPrefetchWorkersFlag.Name)
+	}
+	if ctx.GlobalIsSet(RollupPrefetchDepthFlag.Name) {
+		cfg.PrefetchDepth = ctx.GlobalInt(RollupPrefetchDepthFlag.Name)
+	}
 	if ctx.GlobalIsSet(RollupTimstampRefreshFlag.Name) {
 		cfg.TimestampRefreshThreshold = ctx.GlobalDuration(RollupTimstampRefreshFlag.Name)
 	}
@@ -1220,6 +1438,23 @@ func setRollup(ctx *cli.Context, cfg *rollup.Config) {
 		val := ctx.GlobalFloat64(RollupFeeThresholdUpFlag.Name)
 		cfg.FeeThresholdUp = new(big.Float).SetFloat64(val)
 	}
+	if ctx.GlobalIsSet(RollupL1GasPriceEMAWeightFlag.Name) {
+		cfg.L1GasPriceEMAWeight = ctx.GlobalFloat64(RollupL1GasPriceEMAWeightFlag.Name)
+	}
+	if ctx.GlobalIsSet(RollupL1GasPriceMaxChangeFlag.Name) {
+		cfg.L1GasPriceMaxChange = ctx.GlobalFloat64(RollupL1GasPriceMaxChangeFlag.Name)
+	}
+	if ctx.GlobalIsSet(RollupL1GasPriceFloorFlag.Name) {
+		val := ctx.GlobalUint64(RollupL1GasPriceFloorFlag.Name)
+		cfg.L1GasPriceFloor = new(big.Int).SetUint64(val)
+	}
+	if ctx.GlobalIsSet(RollupL1GasPriceCeilingFlag.Name) {
+		val := ctx.GlobalUint64(RollupL1GasPriceCeilingFlag.Name)
+		cfg.L1GasPriceCeiling = new(big.Int).SetUint64(val)
+	}
+	if ctx.GlobalIsSet(RollupDiffDbCacheFlag.Name) {
+		cfg.DiffDbCache = ctx.GlobalUint64(RollupDiffDbCacheFlag.Name)
+	}
 }
 
 // setLes configures the les server and ultra light client settings from the command line flags.
@@ -1441,6 +1676,9 @@ func SetNodeConfig(ctx *cli.Context, cfg *node.Config) {
 	if ctx.GlobalIsSet(InsecureUnlockAllowedFlag.Name) {
 		cfg.InsecureUnlockAllowed = ctx.GlobalBool(InsecureUnlockAllowedFlag.Name)
 	}
+	if ctx.GlobalIsSet(RollupUsingOVMFlag.Name) {
+		cfg.UsingOVM = ctx.GlobalBool(RollupUsingOVMFlag.Name)
+	}
 }
 
 func setSmartCard(ctx *cli.Context, cfg *node.Config) {
@@ -1790,7 +2028,12 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *eth.Config) {
 		l1StandardBridgeAddress := cfg.Rollup.L1StandardBridgeAddress
 		gpoOwnerAddress := cfg.Rollup.GasPriceOracleOwnerAddress
 		stateDumpPath := cfg.Rollup.StateDumpPath
-		cfg.Genesis = core.DeveloperGenesisBlock(uint64(ctx.GlobalInt(DeveloperPeriodFlag.Name)), developer.Address, xdomainAddress, l1StandardBridgeAddress, addrManagerOwnerAddress, gpoOwnerAddress, l1FeeWalletAddress, stateDumpPath, chainID, gasLimit)
+		stateDumpHash := cfg.Rollup.StateDumpHash
+		genesis, err := core.DeveloperGenesisBlock(uint64(ctx.GlobalInt(DeveloperPeriodFlag.Name)), developer.Address, xdomainAddress, l1StandardBridgeAddress, addrManagerOwnerAddress, gpoOwnerAddress, l1FeeWalletAddress, cfg.Rollup.UsingOVM, stateDumpPath, stateDumpHash, chainID, gasLimit)
+		if err != nil {
+			Fatalf("Failed to create developer genesis block: %v", err)
+		}
+		cfg.Genesis = genesis
 		if !ctx.GlobalIsSet(MinerGasPriceFlag.Name) && !ctx.GlobalIsSet(MinerLegacyGasPriceFlag.Name) {
 			cfg.Miner.GasPrice = big.NewInt(1)
 		}
@@ -1936,11 +2179,20 @@ func MakeGenesis(ctx *cli.Context) *core.Genesis {
 	return genesis
 }
 
+// MakeOVMBlock returns the OVM switch block that the command line flags
+// enforce, nil when the chain configuration decides.
+func MakeOVMBlock(ctx *cli.Context) *big.Int {
+	if !ctx.GlobalBool(RollupUsingOVMFlag.Name) {
+		return nil
+	}
+	return new(big.Int)
+}
+
 // MakeChain creates a chain manager from set command line flags.
 func MakeChain(ctx *cli.Context, stack *node.Node) (chain *core.BlockChain, chainDb ethdb.Database) {
 	var err error
 	chainDb = MakeChainDatabase(ctx, stack)
-	config, _, err := core.SetupGenesisBlock(chainDb, MakeGenesis(ctx))
+	config, _, err := core.SetupGenesisBlockWithOverride(chainDb, MakeGenesis(ctx), nil, nil, MakeOVMBlock(ctx))
 	if err != nil {
 		Fatalf("%v", err)
 	}
@@ -34,7 +34,6 @@ import (
 	"github.com/MetisProtocol/l2geth/consensus/misc"
 	"github.com/MetisProtocol/l2geth/core/state"
 	"github.com/MetisProtocol/l2geth/core/types"
-	"github.com/MetisProtocol/l2geth/core/vm"
 	"github.com/MetisProtocol/l2geth/crypto"
 	"github.com/MetisProtocol/l2geth/ethdb"
 	"github.com/MetisProtocol/l2geth/log"
@@ -252,7 +251,7 @@ func (c *Clique) verifyHeader(chain consensus.ChainReader, header *types.Header,
 	}
 	number := header.Number.Uint64()
 
-	if vm.UsingOVM {
+	if chain.Config().IsOVM(header.Number) {
 		// Don't waste time checking blocks from the future
 		// NOTE 20210724
 		fmt.Println("verifyHeader in clique, [headerTime, time.Now, allowedFutureBlockTime, expect]", header.Time, time.Now(), allowedFutureBlockTime, uint64(time.Now().Add(allowedFutureBlockTime).Unix()))
@@ -333,7 +332,7 @@ func (c *Clique) verifyCascadingFields(chain consensus.ChainReader, header *type
 	// Do not account for timestamps in consensus when running the OVM
 	// changes. The timestamp must be montonic, meaning that it can be the same
 	// or increase. L1 dictates the timestamp.
-	if !vm.UsingOVM {
+	if !chain.Config().IsOVM(header.Number) {
 		if parent.Time+c.config.Period > header.Time {
 			return ErrInvalidTimestamp
 		}
@@ -560,7 +559,7 @@ func (c *Clique) Prepare(chain consensus.ChainReader, header *types.Header) erro
 	}
 
 	// Do not manipulate the timestamps when running with the OVM
-	if !vm.UsingOVM {
+	if !chain.Config().IsOVM(header.Number) {
 		header.Time = parent.Time + c.config.Period
 		if header.Time < uint64(time.Now().Unix()) {
 			header.Time = uint64(time.Now().Unix())
@@ -645,7 +644,7 @@ func (c *Clique) Seal(chain consensus.ChainReader, block *types.Block, results c
 
 		log.Trace("Out-of-turn signing requested", "wiggle", common.PrettyDuration(wiggle))
 	}
-	if vm.UsingOVM {
+	if chain.Config().IsOVM(block.Number()) {
 		delay = 0
 	}
 	// Sign all the things!
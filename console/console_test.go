@@ -97,8 +97,12 @@ func newTester(t *testing.T, confOverride func(*eth.Config)) *tester {
 	if err != nil {
 		t.Fatalf("failed to create node: %v", err)
 	}
+	genesis, err := core.DeveloperGenesisBlock(15, common.Address{}, common.Address{}, common.Address{}, common.Address{}, common.Address{}, common.Address{}, false, "", common.Hash{}, nil, 12000000)
+	if err != nil {
+		t.Fatalf("failed to create genesis: %v", err)
+	}
 	ethConf := &eth.Config{
-		Genesis: core.DeveloperGenesisBlock(15, common.Address{}, common.Address{}, common.Address{}, common.Address{}, common.Address{}, common.Address{}, "", nil, 12000000),
+		Genesis: genesis,
 		Miner: miner.Config{
 			Etherbase: common.HexToAddress(testAddress),
 		},
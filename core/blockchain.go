@@ -36,6 +36,7 @@ import (
 	"github.com/MetisProtocol/l2geth/core/state"
 	"github.com/MetisProtocol/l2geth/core/types"
 	"github.com/MetisProtocol/l2geth/core/vm"
+	"github.com/MetisProtocol/l2geth/diffdb"
 	"github.com/MetisProtocol/l2geth/ethdb"
 	"github.com/MetisProtocol/l2geth/event"
 	"github.com/MetisProtocol/l2geth/log"
@@ -156,6 +157,7 @@ type BlockChain struct {
 	currentFastBlock atomic.Value // Current head of the fast-sync chain (may be above the block chain!)
 
 	stateCache    state.Database // State database to reuse between imports (contains state cache)
+	diffdb        *diffdb.DiffDb // Per block record of the touched state, nil if disabled
 	bodyCache     *lru.Cache     // Cache for the most recent block bodies
 	bodyRLPCache  *lru.Cache     // Cache for the most recent block bodies in RLP encoded format
 	receiptsCache *lru.Cache     // Cache for the most recent receipts per block
@@ -180,6 +182,17 @@ type BlockChain struct {
 	terminateInsert func(common.Hash, uint64) bool // Testing hook used to terminate ancient receipt chain insertion.
 }
 
+// NewBlockChainWithDiffDb returns a block chain that records the accounts and
+// storage slots touched by every processed block in diffdb.
+func NewBlockChainWithDiffDb(db ethdb.Database, diffdb *diffdb.DiffDb, cacheConfig *CacheConfig, chainConfig *params.ChainConfig, engine consensus.Engine, vmConfig vm.Config, shouldPreserve func(block *types.Block) bool) (*BlockChain, error) {
+	bc, err := NewBlockChain(db, cacheConfig, chainConfig, engine, vmConfig, shouldPreserve)
+	if err != nil {
+		return nil, err
+	}
+	bc.diffdb = diffdb
+	return bc, nil
+}
+
 // NewBlockChain returns a fully initialised block chain using information
 // available in the database. It initialises the default Ethereum Validator and
 // Processor.
@@ -437,6 +450,11 @@ func (bc *BlockChain) SetHead(head uint64) error {
 
 	// Rewind the header chain, deleting all block bodies until then
 	delFn := func(db ethdb.KeyValueWriter, hash common.Hash, num uint64) {
+		// Drop the rollup lookups of the transactions in the block before
+		// the block itself is removed
+		if block := rawdb.ReadBlock(bc.db, hash, num); block != nil {
+			rawdb.DeleteRollupLookupEntries(db, block)
+		}
 		// Ignore the error here since light client won't hit this path
 		frozen, _ := bc.db.Ancients()
 		if num+1 <= frozen {
@@ -459,6 +477,14 @@ func (bc *BlockChain) SetHead(head uint64) error {
 	}
 	bc.hc.SetHead(head, updateFn, delFn)
 
+	// Drop the diffs of the rewound blocks so that they are not served for the
+	// blocks that replace them
+	if bc.diffdb != nil {
+		if err := bc.diffdb.DeleteDiffsAfter(new(big.Int).SetUint64(head)); err != nil {
+			log.Error("Failed to delete state diffs", "head", head, "err", err)
+		}
+	}
+
 	// Clear out any stale content from the caches
 	bc.bodyCache.Purge()
 	bc.bodyRLPCache.Purge()
@@ -533,6 +559,21 @@ func (bc *BlockChain) StateAt(root common.Hash) (*state.StateDB, error) {
 	return state.New(root, bc.stateCache)
 }
 
+// StateAtWithDiffDb returns a new mutable state based on a particular point in
+// time that records the state touched during execution. The diffs are only
+// written to the diff db when the state is written along with its block.
+func (bc *BlockChain) StateAtWithDiffDb(root common.Hash) (*state.StateDB, error) {
+	return state.NewWithDiffDb(root, bc.stateCache, bc.diffdb)
+}
+
+// GetDiff returns the accounts and storage slots touched by the block.
+func (bc *BlockChain) GetDiff(block *big.Int) (diffdb.Diff, error) {
+	if bc.diffdb == nil {
+		return nil, ErrNoDiffDb
+	}
+	return bc.diffdb.GetDiff(block)
+}
+
 // StateCache returns the caching database underpinning the blockchain instance.
 func (bc *BlockChain) StateCache() state.Database {
 	return bc.stateCache
@@ -870,6 +911,11 @@ func (bc *BlockChain) Stop() {
 			log.Error("Dangling trie nodes after full cleanup")
 		}
 	}
+	if bc.diffdb != nil {
+		if err := bc.diffdb.Close(); err != nil {
+			log.Error("Failed to close diff db", "err", err)
+		}
+	}
 
 	log.Info("Blockchain manager stopped")
 }
@@ -1206,9 +1252,10 @@ func (bc *BlockChain) InsertReceiptChain(blockChain types.Blocks, receiptChain [
 			rawdb.WriteBody(batch, block.Hash(), block.NumberU64(), block.Body())
 			rawdb.WriteReceipts(batch, block.Hash(), block.NumberU64(), receiptChain[i])
 			rawdb.WriteTxLookupEntries(batch, block)
-			for _, tx := range block.Transactions() {
-				rawdb.WriteTransactionMeta(batch, block.NumberU64(), tx.GetMeta())
+			for i, tx := range block.Transactions() {
+				rawdb.WriteTransactionMetaAt(batch, block.NumberU64(), uint64(i), tx.GetMeta())
 			}
+			rawdb.WriteRollupLookupEntries(batch, block)
 
 			// Write everything belongs to the blocks into the database. So that
 			// we can ensure all components of body is completed(body, receipts,
@@ -1331,14 +1378,19 @@ func (bc *BlockChain) writeBlockWithState(block *types.Block, receipts []*types.
 	blockBatch := bc.db.NewBatch()
 	rawdb.WriteTd(blockBatch, block.Hash(), block.NumberU64(), externTd)
 	rawdb.WriteBlock(blockBatch, block)
-	for _, tx := range block.Transactions() {
-		rawdb.WriteTransactionMeta(blockBatch, block.NumberU64(), tx.GetMeta())
+	for i, tx := range block.Transactions() {
+		rawdb.WriteTransactionMetaAt(blockBatch, block.NumberU64(), uint64(i), tx.GetMeta())
 	}
+	rawdb.WriteRollupLookupEntries(blockBatch, block)
 	rawdb.WriteReceipts(blockBatch, block.Hash(), block.NumberU64(), receipts)
 	rawdb.WritePreimages(blockBatch, state.Preimages())
 	if err := blockBatch.Write(); err != nil {
 		log.Crit("Failed to write block into disk", "err", err)
 	}
+	// Flush the diffs recorded while executing the block so they can be served
+	if err := state.CommitDiffs(); err != nil {
+		log.Error("Failed to commit state diffs", "number", block.Number(), "err", err)
+	}
 	// Commit all cached state changes into underlying memory database.
 	root, err := state.Commit(bc.chainConfig.IsEIP158(block.Number()))
 	if err != nil {
@@ -1701,9 +1753,7 @@ func (bc *BlockChain) insertChain(chain types.Blocks, verifySeals bool) (int, er
 			parent = bc.GetHeader(block.ParentHash(), block.NumberU64()-1)
 		}
 
-		statedb, err := state.New(parent.Root, bc.stateCache)
-		// NOTE 20210724
-		log.Debug("Test: NewWithDiffDb", err)
+		statedb, err := state.NewWithDiffDb(parent.Root, bc.stateCache, bc.diffdb)
 		if err != nil {
 			return it.index, err
 		}
@@ -35,4 +35,8 @@ var (
 
 	// ErrNoGenesis is returned when there is no Genesis Block.
 	ErrNoGenesis = errors.New("genesis not found in chain")
+
+	// ErrNoDiffDb is returned when state diffs are requested from a chain that
+	// does not record them.
+	ErrNoDiffDb = errors.New("state diffs are not recorded")
 )
@@ -22,9 +22,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io/ioutil"
 	"math/big"
-	"net/http"
 	"os"
 	"sort"
 	"strings"
@@ -35,7 +33,6 @@ import (
 	"github.com/MetisProtocol/l2geth/core/rawdb"
 	"github.com/MetisProtocol/l2geth/core/state"
 	"github.com/MetisProtocol/l2geth/core/types"
-	"github.com/MetisProtocol/l2geth/core/vm"
 	"github.com/MetisProtocol/l2geth/crypto"
 	"github.com/MetisProtocol/l2geth/ethdb"
 	"github.com/MetisProtocol/l2geth/log"
@@ -76,6 +73,11 @@ type Genesis struct {
 	GasPriceOracleOwnerAddress    common.Address `json:"-"`
 	L1StandardBridgeAddress       common.Address `json:"-"`
 	ChainID                       *big.Int       `json:"-"`
+
+	// OVM Specific, the JSON encoded state dump that the genesis state is
+	// created from. It is embedded so that `geth init` can create the
+	// genesis block without fetching the state dump.
+	StateDump json.RawMessage `json:"stateDump,omitempty"`
 }
 
 // GenesisAlloc specifies the initial state that is part of the genesis block.
@@ -167,13 +169,31 @@ func (e *GenesisMismatchError) Error() string {
 //
 // The returned chain configuration is never nil.
 func SetupGenesisBlock(db ethdb.Database, genesis *Genesis) (*params.ChainConfig, common.Hash, error) {
-	return SetupGenesisBlockWithOverride(db, genesis, nil, nil)
+	return SetupGenesisBlockWithOverride(db, genesis, nil, nil, nil)
 }
 
-func SetupGenesisBlockWithOverride(db ethdb.Database, genesis *Genesis, overrideIstanbul, overrideMuirGlacier *big.Int) (*params.ChainConfig, common.Hash, error) {
+// SetupGenesisBlockWithOverride is SetupGenesisBlock with fork blocks that
+// override the ones of the chain configuration. Chains that were created
+// before the OVM switch block existed enabled the OVM for every block through
+// the USING_OVM environment variable, an OVM override upgrades the stored
+// configuration of such a chain without rewinding it.
+func SetupGenesisBlockWithOverride(db ethdb.Database, genesis *Genesis, overrideIstanbul, overrideMuirGlacier, overrideOVM *big.Int) (*params.ChainConfig, common.Hash, error) {
 	if genesis != nil && genesis.Config == nil {
 		return params.AllEthashProtocolChanges, common.Hash{}, errGenesisNoConfig
 	}
+	if genesis != nil && overrideOVM != nil {
+		// The OVM state of the genesis block depends on the switch block
+		config := *genesis.Config
+		config.OVMBlock = overrideOVM
+		genesis.Config = &config
+	}
+	if genesis != nil && genesis.Config.StateDump == nil && len(genesis.StateDump) > 0 {
+		stateDump, err := dump.Decode(genesis.StateDump)
+		if err != nil {
+			return genesis.Config, common.Hash{}, err
+		}
+		genesis.Config.StateDump = stateDump
+	}
 	// Just commit the new block if there is no stored genesis block.
 	stored := rawdb.ReadCanonicalHash(db, 0)
 	if (stored == common.Hash{}) {
@@ -225,6 +245,9 @@ func SetupGenesisBlockWithOverride(db ethdb.Database, genesis *Genesis, override
 	if overrideMuirGlacier != nil {
 		newcfg.MuirGlacierBlock = overrideMuirGlacier
 	}
+	if overrideOVM != nil {
+		newcfg.OVMBlock = overrideOVM
+	}
 	if err := newcfg.CheckConfigForkOrder(); err != nil {
 		return newcfg, common.Hash{}, err
 	}
@@ -238,8 +261,21 @@ func SetupGenesisBlockWithOverride(db ethdb.Database, genesis *Genesis, override
 	// config is supplied. These chains would get AllProtocolChanges (and a compat error)
 	// if we just continued here.
 	if genesis == nil && stored != params.MainnetGenesisHash {
+		if upgradeLegacyOVMConfig(storedcfg, overrideOVM) {
+			rawdb.WriteChainConfig(db, stored, storedcfg)
+		}
+		// The state dump is not part of the chain config, use the one
+		// that the genesis block was created from
+		if data := rawdb.ReadStateDump(db, stored); len(data) > 0 {
+			stateDump, err := dump.Decode(data)
+			if err != nil {
+				return storedcfg, stored, err
+			}
+			storedcfg.StateDump = stateDump
+		}
 		return storedcfg, stored, nil
 	}
+	upgradeLegacyOVMConfig(storedcfg, overrideOVM)
 
 	// Check config compatibility and write the config. Compatibility errors
 	// are returned to the caller unless we're already at block zero.
@@ -255,6 +291,19 @@ func SetupGenesisBlockWithOverride(db ethdb.Database, genesis *Genesis, override
 	return newcfg, stored, nil
 }
 
+// upgradeLegacyOVMConfig sets the OVM switch block of a stored configuration
+// that predates it, returning whether the configuration was changed. The
+// override is only trusted for such configurations, the switch block of a
+// newer configuration is checked for compatibility like any other fork block.
+func upgradeLegacyOVMConfig(storedcfg *params.ChainConfig, overrideOVM *big.Int) bool {
+	if overrideOVM == nil || storedcfg.OVMBlock != nil {
+		return false
+	}
+	log.Warn("Upgrading chain configuration without OVM switch block", "ovm", overrideOVM)
+	storedcfg.OVMBlock = overrideOVM
+	return true
+}
+
 func (g *Genesis) configOrDefault(ghash common.Hash) *params.ChainConfig {
 	switch {
 	case g != nil:
@@ -385,7 +434,7 @@ func (g *Genesis) ToBlock(db ethdb.Database) *types.Block {
 	}
 	statedb, _ := state.New(common.Hash{}, state.NewDatabase(db))
 
-	if vm.UsingOVM {
+	if g.Config != nil && g.Config.IsOVM(new(big.Int).SetUint64(g.Number)) {
 		// OVM_ENABLED
 		ApplyOvmStateToState(statedb, g.Config.StateDump, g.L1CrossDomainMessengerAddress, g.L1StandardBridgeAddress, g.AddressManagerOwnerAddress, g.GasPriceOracleOwnerAddress, g.L1FeeWalletAddress, g.ChainID, g.GasLimit)
 	}
@@ -446,6 +495,9 @@ func (g *Genesis) Commit(db ethdb.Database) (*types.Block, error) {
 	rawdb.WriteHeadFastBlockHash(db, block.Hash())
 	rawdb.WriteHeadHeaderHash(db, block.Hash())
 	rawdb.WriteChainConfig(db, block.Hash(), config)
+	if len(g.StateDump) > 0 {
+		rawdb.WriteStateDump(db, block.Hash(), g.StateDump)
+	}
 	return block, nil
 }
 
@@ -518,7 +570,7 @@ func DefaultGoerliGenesisBlock() *Genesis {
 // Additional runtime parameters are passed through that impact
 // the genesis state. An "incompatible genesis block" error means that
 // these params were altered since the initial creation of the datadir.
-func DeveloperGenesisBlock(period uint64, faucet, l1XDomainMessengerAddress common.Address, l1StandardBridgeAddress common.Address, addrManagerOwnerAddress, gpoOwnerAddress, l1FeeWalletAddress common.Address, stateDumpPath string, chainID *big.Int, gasLimit uint64) *Genesis {
+func DeveloperGenesisBlock(period uint64, faucet, l1XDomainMessengerAddress common.Address, l1StandardBridgeAddress common.Address, addrManagerOwnerAddress, gpoOwnerAddress, l1FeeWalletAddress common.Address, usingOVM bool, stateDumpPath string, stateDumpHash common.Hash, chainID *big.Int, gasLimit uint64) (*Genesis, error) {
 	// Override the default period to the user requested one
 	config := *params.AllCliqueProtocolChanges
 	config.Clique.Period = period
@@ -527,39 +579,32 @@ func DeveloperGenesisBlock(period uint64, faucet, l1XDomainMessengerAddress comm
 		config.ChainID = chainID
 	}
 
-	stateDump := dump.OvmDump{}
-	if vm.UsingOVM {
-		// Fetch the state dump from the state dump path
+	stateDump := &dump.OvmDump{}
+	var stateDumpData json.RawMessage
+	if usingOVM {
+		// The OVM rules apply from the genesis block on
+		config.OVMBlock = big.NewInt(0)
+		// Read the state dump from the state dump path
 		// The system cannot start without a state dump as it depends on
-		// the ABIs that are included in the state dump. Check that all
-		// required state dump entries are present to prevent a faulty
-		// state dump from being used
+		// the ABIs that are included in the state dump. Validate the
+		// state dump to prevent a faulty state dump from being used
 		if stateDumpPath == "" {
-			panic("Must pass state dump path")
+			return nil, errors.New("Must pass state dump path")
 		}
-		log.Info("Fetching state dump", "path", stateDumpPath)
-		err := fetchStateDump(stateDumpPath, &stateDump)
+		log.Info("Reading state dump", "path", stateDumpPath, "hash", stateDumpHash)
+		data, err := dump.Read(stateDumpPath, stateDumpHash)
 		if err != nil {
-			panic(fmt.Sprintf("Cannot fetch state dump: %s", err))
-		}
-		_, ok := stateDump.Accounts["Lib_AddressManager"]
-		if !ok {
-			panic("Lib_AddressManager not in state dump")
+			return nil, fmt.Errorf("Cannot read state dump: %w", err)
 		}
-		_, ok = stateDump.Accounts["OVM_StateManager"]
-		if !ok {
-			panic("OVM_StateManager not in state dump")
+		if stateDump, err = dump.Decode(data); err != nil {
+			return nil, err
 		}
-		_, ok = stateDump.Accounts["OVM_ExecutionManager"]
-		if !ok {
-			panic("OVM_ExecutionManager not in state dump")
-		}
-		_, ok = stateDump.Accounts["OVM_SequencerEntrypoint"]
-		if !ok {
-			panic("OVM_SequencerEntrypoint not in state dump")
+		if err := dump.Validate(stateDump); err != nil {
+			return nil, err
 		}
+		stateDumpData = data
 	}
-	config.StateDump = &stateDump
+	config.StateDump = stateDump
 
 	// Assemble and return the genesis with the precompiles and faucet pre-funded
 	return &Genesis{
@@ -586,7 +631,8 @@ func DeveloperGenesisBlock(period uint64, faucet, l1XDomainMessengerAddress comm
 		GasPriceOracleOwnerAddress:    gpoOwnerAddress,
 		L1StandardBridgeAddress:       l1StandardBridgeAddress,
 		ChainID:                       config.ChainID,
-	}
+		StateDump:                     stateDumpData,
+	}, nil
 }
 
 func decodePrealloc(data string) GenesisAlloc {
@@ -600,30 +646,3 @@ func decodePrealloc(data string) GenesisAlloc {
 	}
 	return ga
 }
-
-// UsingOVM
-// fetchStateDump will fetch a state dump from a remote HTTP endpoint.
-// This state dump includes the OVM system contracts as well as previous
-// user state if the network has previously had a regenesis.
-func fetchStateDump(path string, stateDump *dump.OvmDump) error {
-	if stateDump == nil {
-		return errors.New("Unable to fetch state dump")
-	}
-	resp, err := http.Get(path)
-	if err != nil {
-		return fmt.Errorf("Unable to GET state dump: %w", err)
-	}
-	if resp.StatusCode >= 400 {
-		return errors.New("State dump not found")
-	}
-	defer resp.Body.Close()
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return fmt.Errorf("Unable to read response body: %w", err)
-	}
-	err = json.Unmarshal(body, stateDump)
-	if err != nil {
-		return fmt.Errorf("Unable to unmarshal response body: %w", err)
-	}
-	return nil
-}
@@ -166,3 +166,56 @@ func TestSetupGenesis(t *testing.T) {
 		}
 	}
 }
+
+func TestSetupGenesisStateDump(t *testing.T) {
+	config := *params.TestChainConfig
+	config.OVMBlock = big.NewInt(0)
+	genesis := &Genesis{
+		Config:    &config,
+		Alloc:     GenesisAlloc{},
+		StateDump: []byte(`{"accounts":{}}`),
+	}
+	db := rawdb.NewMemoryDatabase()
+	if _, _, err := SetupGenesisBlock(db, genesis); err != nil {
+		t.Fatal(err)
+	}
+	// The embedded state dump is used when the node is started without a
+	// genesis
+	stored, _, err := SetupGenesisBlock(db, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.StateDump == nil || stored.StateDump.Accounts == nil {
+		t.Fatal("state dump not restored")
+	}
+	// An invalid embedded state dump is rejected
+	genesis = &Genesis{Config: &params.ChainConfig{}, StateDump: []byte("{")}
+	if _, _, err := SetupGenesisBlock(rawdb.NewMemoryDatabase(), genesis); err == nil {
+		t.Fatal("expected error for invalid state dump")
+	}
+}
+
+// Chains that predate the OVM switch block are upgraded by the OVM override
+// instead of being rewound
+func TestSetupGenesisLegacyOVM(t *testing.T) {
+	config := *params.TestChainConfig
+	genesis := &Genesis{Config: &config, Alloc: GenesisAlloc{}}
+	db := rawdb.NewMemoryDatabase()
+	_, hash, err := SetupGenesisBlock(db, genesis)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored := rawdb.ReadChainConfig(db, hash); stored.OVMBlock != nil {
+		t.Fatalf("unexpected OVM switch block %v", stored.OVMBlock)
+	}
+	upgraded, _, err := SetupGenesisBlockWithOverride(db, nil, nil, nil, big.NewInt(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !upgraded.IsOVM(big.NewInt(0)) {
+		t.Fatal("OVM not enabled by the override")
+	}
+	if stored := rawdb.ReadChainConfig(db, hash); !stored.IsOVM(big.NewInt(0)) {
+		t.Fatal("upgraded chain config not stored")
+	}
+}
@@ -342,14 +342,21 @@ func DeleteBody(db ethdb.KeyValueWriter, hash common.Hash, number uint64) {
 // ReadTransactionMeta returns the transaction metadata associated with a
 // transaction hash.
 func ReadTransactionMeta(db ethdb.Reader, number uint64) *types.TransactionMeta {
-	data := ReadTransactionMetaRaw(db, number)
+	return ReadTransactionMetaAt(db, number, 0)
+}
+
+// UsingOVM
+// ReadTransactionMetaAt returns the transaction metadata of the transaction
+// at position txIndex in the block.
+func ReadTransactionMetaAt(db ethdb.Reader, number uint64, txIndex uint64) *types.TransactionMeta {
+	data := ReadTransactionMetaRawAt(db, number, txIndex)
 	if len(data) == 0 {
 		return nil
 	}
 
 	meta, err := types.TxMetaDecode(data)
 	if err != nil {
-		log.Error("Invalid raw tx meta ", "number", number, "err", err)
+		log.Error("Invalid raw tx meta ", "number", number, "tx-index", txIndex, "err", err)
 		return nil
 	}
 	// NOTE 20210724
@@ -362,7 +369,14 @@ func ReadTransactionMeta(db ethdb.Reader, number uint64) *types.TransactionMeta
 // ReadTransactionMetaRaw returns the raw transaction metadata associated with a
 // transaction hash.
 func ReadTransactionMetaRaw(db ethdb.Reader, number uint64) []byte {
-	data, _ := db.Get(txMetaKey(number))
+	return ReadTransactionMetaRawAt(db, number, 0)
+}
+
+// UsingOVM
+// ReadTransactionMetaRawAt returns the raw transaction metadata of the
+// transaction at position txIndex in the block.
+func ReadTransactionMetaRawAt(db ethdb.Reader, number uint64, txIndex uint64) []byte {
+	data, _ := db.Get(txMetaIndexKey(number, txIndex))
 	if len(data) > 0 {
 		return data
 	}
@@ -372,14 +386,28 @@ func ReadTransactionMetaRaw(db ethdb.Reader, number uint64) []byte {
 // UsingOVM
 // WriteTransactionMeta writes the TransactionMeta to disk by hash.
 func WriteTransactionMeta(db ethdb.KeyValueWriter, number uint64, meta *types.TransactionMeta) {
+	WriteTransactionMetaAt(db, number, 0, meta)
+}
+
+// UsingOVM
+// WriteTransactionMetaAt writes the TransactionMeta of the transaction at
+// position txIndex in the block to disk.
+func WriteTransactionMetaAt(db ethdb.KeyValueWriter, number uint64, txIndex uint64, meta *types.TransactionMeta) {
 	data := types.TxMetaEncode(meta)
-	WriteTransactionMetaRaw(db, number, data)
+	WriteTransactionMetaRawAt(db, number, txIndex, data)
 }
 
 // UsingOVM
 // WriteTransactionMetaRaw writes the raw transaction metadata bytes to disk.
 func WriteTransactionMetaRaw(db ethdb.KeyValueWriter, number uint64, data []byte) {
-	if err := db.Put(txMetaKey(number), data); err != nil {
+	WriteTransactionMetaRawAt(db, number, 0, data)
+}
+
+// UsingOVM
+// WriteTransactionMetaRawAt writes the raw transaction metadata bytes of the
+// transaction at position txIndex in the block to disk.
+func WriteTransactionMetaRawAt(db ethdb.KeyValueWriter, number uint64, txIndex uint64, data []byte) {
+	if err := db.Put(txMetaIndexKey(number, txIndex), data); err != nil {
 		log.Crit("Failed to store transaction meta", "err", err)
 	}
 }
@@ -387,7 +415,14 @@ func WriteTransactionMetaRaw(db ethdb.KeyValueWriter, number uint64, data []byte
 // UsingOVM
 // DeleteTransactionMeta removes the transaction metadata associated with a hash
 func DeleteTransactionMeta(db ethdb.KeyValueWriter, number uint64) {
-	if err := db.Delete(txMetaKey(number)); err != nil {
+	DeleteTransactionMetaAt(db, number, 0)
+}
+
+// UsingOVM
+// DeleteTransactionMetaAt removes the transaction metadata of the transaction
+// at position txIndex in the block
+func DeleteTransactionMetaAt(db ethdb.KeyValueWriter, number uint64, txIndex uint64) {
+	if err := db.Delete(txMetaIndexKey(number, txIndex)); err != nil {
 		log.Crit("Failed to delete transaction meta", "err", err)
 	}
 }
@@ -590,7 +625,7 @@ func ReadBlock(db ethdb.Reader, hash common.Hash, number uint64) *types.Block {
 	// is not included as part of the RLP encoding of a transaction to be
 	// backwards compatible with layer one
 	for i := 0; i < len(body.Transactions); i++ {
-		meta := ReadTransactionMeta(db, header.Number.Uint64())
+		meta := ReadTransactionMetaAt(db, header.Number.Uint64(), uint64(i))
 		body.Transactions[i].SetTransactionMeta(meta)
 	}
 	return types.NewBlockWithHeader(header).WithBody(body.Transactions, body.Uncles)
@@ -88,9 +88,9 @@ func ReadTransaction(db ethdb.Reader, hash common.Hash) (*types.Transaction, com
 		if tx.Hash() == hash {
 			// UsingOVM
 			// Read the transaction meta from the database and attach it
-			// to the transaction. Since there is 1 transaction per block, the
-			// blocknumber is used as the key.
-			txMeta := ReadTransactionMeta(db, *blockNumber)
+			// to the transaction. The metadata is keyed by the blocknumber
+			// and the position of the transaction in the block.
+			txMeta := ReadTransactionMetaAt(db, *blockNumber, uint64(txIndex))
 			if txMeta != nil {
 				tx.SetTransactionMeta(txMeta)
 			}
@@ -0,0 +1,142 @@
+package rawdb
+
+import (
+	"github.com/MetisProtocol/l2geth/common"
+	"github.com/MetisProtocol/l2geth/ethdb"
+	"github.com/MetisProtocol/l2geth/log"
+	"github.com/MetisProtocol/l2geth/rlp"
+)
+
+// TransactionBatch is a batch of transactions that was appended to the
+// Canonical Transaction Chain on layer one. The transactions of the batch are
+// the Canonical Transaction Chain indices from PrevTotalElements up to but
+// not including PrevTotalElements+Size.
+type TransactionBatch struct {
+	Index             uint64
+	Root              common.Hash
+	Size              uint64
+	PrevTotalElements uint64
+	BlockNumber       uint64
+	Timestamp         uint64
+	Submitter         common.Address
+}
+
+// ReadTransactionBatch retrieves the transaction batch with the batch index
+func ReadTransactionBatch(db ethdb.KeyValueReader, index uint64) *TransactionBatch {
+	data, _ := db.Get(transactionBatchKey(index))
+	if len(data) == 0 {
+		return nil
+	}
+	batch := new(TransactionBatch)
+	if err := rlp.DecodeBytes(data, batch); err != nil {
+		log.Error("Invalid transaction batch RLP", "index", index, "err", err)
+		return nil
+	}
+	return batch
+}
+
+// WriteTransactionBatch stores a transaction batch by its batch index
+func WriteTransactionBatch(db ethdb.KeyValueWriter, batch *TransactionBatch) {
+	data, err := rlp.EncodeToBytes(batch)
+	if err != nil {
+		log.Crit("Failed to RLP encode transaction batch", "err", err)
+	}
+	if err := db.Put(transactionBatchKey(batch.Index), data); err != nil {
+		log.Crit("Failed to store transaction batch", "err", err)
+	}
+}
+
+// DeleteTransactionBatch removes the transaction batch with the batch index
+func DeleteTransactionBatch(db ethdb.KeyValueWriter, index uint64) {
+	if err := db.Delete(transactionBatchKey(index)); err != nil {
+		log.Crit("Failed to delete transaction batch", "err", err)
+	}
+}
+
+// TxBatchEntry records the batch that the transaction with a Canonical
+// Transaction Chain index was submitted to layer one in
+type TxBatchEntry struct {
+	BatchIndex    uint64
+	L1BlockNumber uint64
+	Submitter     common.Address
+}
+
+// ReadTxBatchEntry retrieves the batch membership of the transaction with the
+// Canonical Transaction Chain index
+func ReadTxBatchEntry(db ethdb.KeyValueReader, index uint64) *TxBatchEntry {
+	data, _ := db.Get(txBatchKey(index))
+	if len(data) == 0 {
+		return nil
+	}
+	entry := new(TxBatchEntry)
+	if err := rlp.DecodeBytes(data, entry); err != nil {
+		log.Error("Invalid tx batch entry RLP", "index", index, "err", err)
+		return nil
+	}
+	return entry
+}
+
+// WriteTxBatchEntry stores the batch membership of the transaction with the
+// Canonical Transaction Chain index
+func WriteTxBatchEntry(db ethdb.KeyValueWriter, index uint64, entry *TxBatchEntry) {
+	data, err := rlp.EncodeToBytes(entry)
+	if err != nil {
+		log.Crit("Failed to RLP encode tx batch entry", "err", err)
+	}
+	if err := db.Put(txBatchKey(index), data); err != nil {
+		log.Crit("Failed to store tx batch entry", "err", err)
+	}
+}
+
+// DeleteTxBatchEntry removes the batch membership of the transaction with the
+// Canonical Transaction Chain index
+func DeleteTxBatchEntry(db ethdb.KeyValueWriter, index uint64) {
+	if err := db.Delete(txBatchKey(index)); err != nil {
+		log.Crit("Failed to delete tx batch entry", "err", err)
+	}
+}
+
+// StateRootBatch is a batch of state roots that was appended to the State
+// Commitment Chain on layer one and verified against the local chain. The
+// state roots of the batch are those of the Canonical Transaction Chain
+// indices from PrevTotalElements up to but not including
+// PrevTotalElements+Size.
+type StateRootBatch struct {
+	Index             uint64
+	Root              common.Hash
+	Size              uint64
+	PrevTotalElements uint64
+	BlockNumber       uint64
+}
+
+// ReadStateRootBatch retrieves the state root batch with the batch index
+func ReadStateRootBatch(db ethdb.KeyValueReader, index uint64) *StateRootBatch {
+	data, _ := db.Get(stateRootBatchKey(index))
+	if len(data) == 0 {
+		return nil
+	}
+	batch := new(StateRootBatch)
+	if err := rlp.DecodeBytes(data, batch); err != nil {
+		log.Error("Invalid state root batch RLP", "index", index, "err", err)
+		return nil
+	}
+	return batch
+}
+
+// WriteStateRootBatch stores a state root batch by its batch index
+func WriteStateRootBatch(db ethdb.KeyValueWriter, batch *StateRootBatch) {
+	data, err := rlp.EncodeToBytes(batch)
+	if err != nil {
+		log.Crit("Failed to RLP encode state root batch", "err", err)
+	}
+	if err := db.Put(stateRootBatchKey(batch.Index), data); err != nil {
+		log.Crit("Failed to store state root batch", "err", err)
+	}
+}
+
+// DeleteStateRootBatch removes the state root batch with the batch index
+func DeleteStateRootBatch(db ethdb.KeyValueWriter, index uint64) {
+	if err := db.Delete(stateRootBatchKey(index)); err != nil {
+		log.Crit("Failed to delete state root batch", "err", err)
+	}
+}
@@ -0,0 +1,78 @@
+package rawdb
+
+import (
+	"testing"
+
+	"github.com/MetisProtocol/l2geth/common"
+)
+
+func TestTransactionBatchStorage(t *testing.T) {
+	db := NewMemoryDatabase()
+	if ReadTransactionBatch(db, 1) != nil {
+		t.Fatal("unexpected batch")
+	}
+	WriteTransactionBatch(db, &TransactionBatch{
+		Index:             1,
+		Root:              common.Hash{0x01},
+		Size:              2,
+		PrevTotalElements: 3,
+		BlockNumber:       10,
+		Submitter:         common.HexToAddress("0x02"),
+	})
+	batch := ReadTransactionBatch(db, 1)
+	if batch == nil || batch.Size != 2 || batch.PrevTotalElements != 3 || batch.Root != (common.Hash{0x01}) {
+		t.Fatalf("unexpected batch: %v", batch)
+	}
+	DeleteTransactionBatch(db, 1)
+	if ReadTransactionBatch(db, 1) != nil {
+		t.Fatal("batch not deleted")
+	}
+}
+
+func TestTxBatchEntryStorage(t *testing.T) {
+	db := NewMemoryDatabase()
+	if ReadTxBatchEntry(db, 5) != nil {
+		t.Fatal("unexpected entry")
+	}
+	WriteTxBatchEntry(db, 5, &TxBatchEntry{
+		BatchIndex:    1,
+		L1BlockNumber: 10,
+		Submitter:     common.HexToAddress("0x02"),
+	})
+	entry := ReadTxBatchEntry(db, 5)
+	if entry == nil || entry.BatchIndex != 1 || entry.L1BlockNumber != 10 || entry.Submitter != common.HexToAddress("0x02") {
+		t.Fatalf("unexpected entry: %v", entry)
+	}
+	if ReadTxBatchEntry(db, 6) != nil {
+		t.Fatal("unexpected entry for index 6")
+	}
+	DeleteTxBatchEntry(db, 5)
+	if ReadTxBatchEntry(db, 5) != nil {
+		t.Fatal("entry not deleted")
+	}
+}
+
+func TestStateRootBatchStorage(t *testing.T) {
+	db := NewMemoryDatabase()
+	if ReadStateRootBatch(db, 1) != nil {
+		t.Fatal("unexpected batch")
+	}
+	WriteStateRootBatch(db, &StateRootBatch{
+		Index:             1,
+		Root:              common.Hash{0x01},
+		Size:              2,
+		PrevTotalElements: 3,
+		BlockNumber:       10,
+	})
+	batch := ReadStateRootBatch(db, 1)
+	if batch == nil || batch.Size != 2 || batch.PrevTotalElements != 3 || batch.Root != (common.Hash{0x01}) {
+		t.Fatalf("unexpected batch: %v", batch)
+	}
+	if ReadTransactionBatch(db, 1) != nil {
+		t.Fatal("state root batch read as transaction batch")
+	}
+	DeleteStateRootBatch(db, 1)
+	if ReadStateRootBatch(db, 1) != nil {
+		t.Fatal("batch not deleted")
+	}
+}
@@ -0,0 +1,70 @@
+package rawdb
+
+import (
+	"math/big"
+
+	"github.com/MetisProtocol/l2geth/ethdb"
+	"github.com/MetisProtocol/l2geth/log"
+	"github.com/MetisProtocol/l2geth/rlp"
+)
+
+// GasPriceSample is the L1 and L2 gas price known by the node at a point in
+// time. Samples are numbered in the order in which they were taken.
+type GasPriceSample struct {
+	Timestamp  uint64
+	L1GasPrice *big.Int
+	L2GasPrice *big.Int
+}
+
+// ReadGasPriceSample retrieves the gas price sample with the number
+func ReadGasPriceSample(db ethdb.KeyValueReader, number uint64) *GasPriceSample {
+	data, _ := db.Get(gasPriceSampleKey(number))
+	if len(data) == 0 {
+		return nil
+	}
+	sample := new(GasPriceSample)
+	if err := rlp.DecodeBytes(data, sample); err != nil {
+		log.Error("Invalid gas price sample RLP", "number", number, "err", err)
+		return nil
+	}
+	return sample
+}
+
+// WriteGasPriceSample stores the gas price sample with the number
+func WriteGasPriceSample(db ethdb.KeyValueWriter, number uint64, sample *GasPriceSample) {
+	data, err := rlp.EncodeToBytes(sample)
+	if err != nil {
+		log.Crit("Failed to RLP encode gas price sample", "err", err)
+	}
+	if err := db.Put(gasPriceSampleKey(number), data); err != nil {
+		log.Crit("Failed to store gas price sample", "err", err)
+	}
+}
+
+// DeleteGasPriceSample removes the gas price sample with the number
+func DeleteGasPriceSample(db ethdb.KeyValueWriter, number uint64) {
+	if err := db.Delete(gasPriceSampleKey(number)); err != nil {
+		log.Crit("Failed to delete gas price sample", "err", err)
+	}
+}
+
+// ReadHeadGasPriceSample will read the number of the latest gas price sample
+func ReadHeadGasPriceSample(db ethdb.KeyValueReader) *uint64 {
+	data, _ := db.Get(headGasPriceSampleKey)
+	if len(data) == 0 {
+		return nil
+	}
+	ret := new(big.Int).SetBytes(data).Uint64()
+	return &ret
+}
+
+// WriteHeadGasPriceSample will write the number of the latest gas price sample
+func WriteHeadGasPriceSample(db ethdb.KeyValueWriter, number uint64) {
+	value := new(big.Int).SetUint64(number).Bytes()
+	if number == 0 {
+		value = []byte{0}
+	}
+	if err := db.Put(headGasPriceSampleKey, value); err != nil {
+		log.Crit("Failed to store gas price sample number", "err", err)
+	}
+}
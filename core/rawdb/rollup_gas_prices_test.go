@@ -0,0 +1,33 @@
+package rawdb
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestGasPriceSampleStorage(t *testing.T) {
+	db := NewMemoryDatabase()
+	if ReadHeadGasPriceSample(db) != nil {
+		t.Fatal("unexpected head sample")
+	}
+	if ReadGasPriceSample(db, 0) != nil {
+		t.Fatal("unexpected sample")
+	}
+	WriteGasPriceSample(db, 0, &GasPriceSample{
+		Timestamp:  100,
+		L1GasPrice: big.NewInt(10),
+		L2GasPrice: big.NewInt(1),
+	})
+	WriteHeadGasPriceSample(db, 0)
+	if head := ReadHeadGasPriceSample(db); head == nil || *head != 0 {
+		t.Fatalf("unexpected head sample: %v", head)
+	}
+	sample := ReadGasPriceSample(db, 0)
+	if sample == nil || sample.Timestamp != 100 || sample.L1GasPrice.Cmp(big.NewInt(10)) != 0 || sample.L2GasPrice.Cmp(big.NewInt(1)) != 0 {
+		t.Fatalf("unexpected sample: %v", sample)
+	}
+	DeleteGasPriceSample(db, 0)
+	if ReadGasPriceSample(db, 0) != nil {
+		t.Fatal("sample not deleted")
+	}
+}
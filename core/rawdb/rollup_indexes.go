@@ -90,3 +90,63 @@ func WriteHeadBatchIndex(db ethdb.KeyValueWriter, index uint64) {
 		log.Crit("Failed to store head batch index", "err", err)
 	}
 }
+
+// DeleteHeadIndex will remove the known tip of the CTC
+func DeleteHeadIndex(db ethdb.KeyValueWriter) {
+	if err := db.Delete(headIndexKey); err != nil {
+		log.Crit("Failed to delete index", "err", err)
+	}
+}
+
+// DeleteHeadQueueIndex will remove the known tip of the queue
+func DeleteHeadQueueIndex(db ethdb.KeyValueWriter) {
+	if err := db.Delete(headQueueIndexKey); err != nil {
+		log.Crit("Failed to delete queue index", "err", err)
+	}
+}
+
+// DeleteHeadVerifiedIndex will remove the known tip of the batched
+// transactions
+func DeleteHeadVerifiedIndex(db ethdb.KeyValueWriter) {
+	if err := db.Delete(headVerifiedIndexKey); err != nil {
+		log.Crit("Failed to delete verifier index", "err", err)
+	}
+}
+
+// DeleteHeadBatchIndex will remove the known tip of the processed batches
+func DeleteHeadBatchIndex(db ethdb.KeyValueWriter) {
+	if err := db.Delete(headBatchKey); err != nil {
+		log.Crit("Failed to delete head batch index", "err", err)
+	}
+}
+
+// ReadHeadStateRootBatchIndex will read the known tip of the verified state
+// root batches
+func ReadHeadStateRootBatchIndex(db ethdb.KeyValueReader) *uint64 {
+	data, _ := db.Get(headStateRootBatchKey)
+	if len(data) == 0 {
+		return nil
+	}
+	ret := new(big.Int).SetBytes(data).Uint64()
+	return &ret
+}
+
+// WriteHeadStateRootBatchIndex will write the known tip of the verified state
+// root batches
+func WriteHeadStateRootBatchIndex(db ethdb.KeyValueWriter, index uint64) {
+	value := new(big.Int).SetUint64(index).Bytes()
+	if index == 0 {
+		value = []byte{0}
+	}
+	if err := db.Put(headStateRootBatchKey, value); err != nil {
+		log.Crit("Failed to store head state root batch index", "err", err)
+	}
+}
+
+// DeleteHeadStateRootBatchIndex will remove the known tip of the verified
+// state root batches
+func DeleteHeadStateRootBatchIndex(db ethdb.KeyValueWriter) {
+	if err := db.Delete(headStateRootBatchKey); err != nil {
+		log.Crit("Failed to delete head state root batch index", "err", err)
+	}
+}
@@ -39,3 +39,34 @@ func TestReadWriteHeadQueueIndex(t *testing.T) {
 		}
 	}
 }
+
+func TestDeleteHeadIndices(t *testing.T) {
+	db := NewMemoryDatabase()
+	WriteHeadIndex(db, 1)
+	WriteHeadQueueIndex(db, 2)
+	WriteHeadVerifiedIndex(db, 3)
+	WriteHeadBatchIndex(db, 4)
+	WriteHeadStateRootBatchIndex(db, 5)
+
+	DeleteHeadIndex(db)
+	DeleteHeadQueueIndex(db)
+	DeleteHeadVerifiedIndex(db)
+	DeleteHeadBatchIndex(db)
+	DeleteHeadStateRootBatchIndex(db)
+
+	if ReadHeadIndex(db) != nil {
+		t.Fatal("Head index not deleted")
+	}
+	if ReadHeadQueueIndex(db) != nil {
+		t.Fatal("Head queue index not deleted")
+	}
+	if ReadHeadVerifiedIndex(db) != nil {
+		t.Fatal("Head verified index not deleted")
+	}
+	if ReadHeadBatchIndex(db) != nil {
+		t.Fatal("Head batch index not deleted")
+	}
+	if ReadHeadStateRootBatchIndex(db) != nil {
+		t.Fatal("Head state root batch index not deleted")
+	}
+}
@@ -0,0 +1,122 @@
+package rawdb
+
+import (
+	"github.com/MetisProtocol/l2geth/common"
+	"github.com/MetisProtocol/l2geth/core/types"
+	"github.com/MetisProtocol/l2geth/ethdb"
+	"github.com/MetisProtocol/l2geth/log"
+	"github.com/MetisProtocol/l2geth/rlp"
+)
+
+// RollupLookupEntry is the position of a rollup transaction in the chain.
+// Blocks may hold more than a single transaction, so the block number alone
+// is not enough to find it.
+type RollupLookupEntry struct {
+	BlockNumber uint64
+	BlockHash   common.Hash
+	TxIndex     uint64
+	TxHash      common.Hash
+}
+
+// ReadCTCLookupEntry retrieves the position of the transaction with the
+// Canonical Transaction Chain index
+func ReadCTCLookupEntry(db ethdb.KeyValueReader, index uint64) *RollupLookupEntry {
+	return readRollupLookupEntry(db, ctcLookupKey(index))
+}
+
+// WriteCTCLookupEntry stores the position of the transaction with the
+// Canonical Transaction Chain index
+func WriteCTCLookupEntry(db ethdb.KeyValueWriter, index uint64, entry *RollupLookupEntry) {
+	writeRollupLookupEntry(db, ctcLookupKey(index), entry)
+}
+
+// DeleteCTCLookupEntry removes the position of the transaction with the
+// Canonical Transaction Chain index
+func DeleteCTCLookupEntry(db ethdb.KeyValueWriter, index uint64) {
+	if err := db.Delete(ctcLookupKey(index)); err != nil {
+		log.Crit("Failed to delete CTC lookup entry", "err", err)
+	}
+}
+
+// ReadQueueLookupEntry retrieves the position of the L1 to L2 transaction
+// with the queue index
+func ReadQueueLookupEntry(db ethdb.KeyValueReader, queueIndex uint64) *RollupLookupEntry {
+	return readRollupLookupEntry(db, queueLookupKey(queueIndex))
+}
+
+// WriteQueueLookupEntry stores the position of the L1 to L2 transaction with
+// the queue index
+func WriteQueueLookupEntry(db ethdb.KeyValueWriter, queueIndex uint64, entry *RollupLookupEntry) {
+	writeRollupLookupEntry(db, queueLookupKey(queueIndex), entry)
+}
+
+// DeleteQueueLookupEntry removes the position of the L1 to L2 transaction
+// with the queue index
+func DeleteQueueLookupEntry(db ethdb.KeyValueWriter, queueIndex uint64) {
+	if err := db.Delete(queueLookupKey(queueIndex)); err != nil {
+		log.Crit("Failed to delete queue lookup entry", "err", err)
+	}
+}
+
+// WriteRollupLookupEntries stores the positions of all of the transactions in
+// the block by their Canonical Transaction Chain index and queue index
+func WriteRollupLookupEntries(db ethdb.KeyValueWriter, block *types.Block) {
+	for i, tx := range block.Transactions() {
+		meta := tx.GetMeta()
+		if meta == nil {
+			continue
+		}
+		entry := &RollupLookupEntry{
+			BlockNumber: block.NumberU64(),
+			BlockHash:   block.Hash(),
+			TxIndex:     uint64(i),
+			TxHash:      tx.Hash(),
+		}
+		if meta.Index != nil {
+			WriteCTCLookupEntry(db, *meta.Index, entry)
+		}
+		if meta.QueueIndex != nil {
+			WriteQueueLookupEntry(db, *meta.QueueIndex, entry)
+		}
+	}
+}
+
+// DeleteRollupLookupEntries removes the positions of all of the transactions
+// in the block. It is used when the block is removed from the chain.
+func DeleteRollupLookupEntries(db ethdb.KeyValueWriter, block *types.Block) {
+	for _, tx := range block.Transactions() {
+		meta := tx.GetMeta()
+		if meta == nil {
+			continue
+		}
+		if meta.Index != nil {
+			DeleteCTCLookupEntry(db, *meta.Index)
+		}
+		if meta.QueueIndex != nil {
+			DeleteQueueLookupEntry(db, *meta.QueueIndex)
+		}
+	}
+}
+
+func readRollupLookupEntry(db ethdb.KeyValueReader, key []byte) *RollupLookupEntry {
+	data, _ := db.Get(key)
+	if len(data) == 0 {
+		return nil
+	}
+	entry := new(RollupLookupEntry)
+	if err := rlp.DecodeBytes(data, entry); err != nil {
+		log.Error("Invalid rollup lookup entry RLP", "key", key, "err", err)
+		return nil
+	}
+	return entry
+}
+
+func writeRollupLookupEntry(db ethdb.KeyValueWriter, key []byte, entry *RollupLookupEntry) {
+	data, err := rlp.EncodeToBytes(entry)
+	if err != nil {
+		log.Crit("Failed to RLP encode rollup lookup entry", "err", err)
+	}
+	if err := db.Put(key, data); err != nil {
+		log.Crit("Failed to store rollup lookup entry", "err", err)
+	}
+}
@@ -0,0 +1,61 @@
+package rawdb
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/MetisProtocol/l2geth/common"
+	"github.com/MetisProtocol/l2geth/core/types"
+)
+
+func TestRollupLookupStorage(t *testing.T) {
+	db := NewMemoryDatabase()
+
+	var txs types.Transactions
+	for i := uint64(0); i < 3; i++ {
+		tx := types.NewTransaction(i, common.HexToAddress("0x01"), big.NewInt(1), 21000, big.NewInt(0), nil)
+		index := 10 + i
+		queueOrigin, queueIndex := types.QueueOriginSequencer, (*uint64)(nil)
+		if i == 1 {
+			queueOrigin, queueIndex = types.QueueOriginL1ToL2, new(uint64)
+		}
+		tx.SetTransactionMeta(types.NewTransactionMeta(big.NewInt(1), i, nil, queueOrigin, &index, queueIndex, nil))
+		txs = append(txs, tx)
+	}
+	block := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(5)}).WithBody(txs, nil)
+	WriteBlock(db, block)
+	for i, tx := range block.Transactions() {
+		WriteTransactionMetaAt(db, block.NumberU64(), uint64(i), tx.GetMeta())
+	}
+	WriteRollupLookupEntries(db, block)
+
+	for i := uint64(0); i < 3; i++ {
+		entry := ReadCTCLookupEntry(db, 10+i)
+		if entry == nil || entry.BlockNumber != 5 || entry.BlockHash != block.Hash() || entry.TxIndex != i || entry.TxHash != txs[i].Hash() {
+			t.Fatalf("unexpected entry for index %d: %v", 10+i, entry)
+		}
+	}
+	if ReadCTCLookupEntry(db, 13) != nil {
+		t.Fatal("unexpected entry for index 13")
+	}
+	entry := ReadQueueLookupEntry(db, 0)
+	if entry == nil || entry.TxIndex != 1 || entry.TxHash != txs[1].Hash() {
+		t.Fatalf("unexpected entry for queue index 0: %v", entry)
+	}
+	// Each transaction of the block gets its own metadata back
+	stored := ReadBlock(db, block.Hash(), block.NumberU64())
+	for i, tx := range stored.Transactions() {
+		if index := tx.GetMeta().Index; index == nil || *index != 10+uint64(i) {
+			t.Fatalf("unexpected meta index for tx %d", i)
+		}
+	}
+
+	DeleteCTCLookupEntry(db, 11)
+	if ReadCTCLookupEntry(db, 11) != nil {
+		t.Fatal("entry not deleted")
+	}
+	DeleteRollupLookupEntries(db, block)
+	if ReadCTCLookupEntry(db, 10) != nil || ReadQueueLookupEntry(db, 0) != nil {
+		t.Fatal("entries of the block not deleted")
+	}
+}
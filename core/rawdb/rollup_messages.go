@@ -0,0 +1,192 @@
+package rawdb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math/big"
+
+	"github.com/MetisProtocol/l2geth/common"
+	"github.com/MetisProtocol/l2geth/ethdb"
+	"github.com/MetisProtocol/l2geth/log"
+	"github.com/MetisProtocol/l2geth/rlp"
+)
+
+// MessageDirection is the direction that a cross domain message is sent in
+type MessageDirection uint8
+
+const (
+	// MessageL1ToL2 is a deposit that is relayed by a L1 to L2 transaction
+	MessageL1ToL2 MessageDirection = iota
+	// MessageL2ToL1 is a withdrawal that is relayed on L1
+	MessageL2ToL1
+)
+
+func (d MessageDirection) String() string {
+	switch d {
+	case MessageL1ToL2:
+		return "l1ToL2"
+	case MessageL2ToL1:
+		return "l2ToL1"
+	default:
+		return ""
+	}
+}
+
+// MessageStatus is the status of a cross domain message as far as it is
+// known on L2
+type MessageStatus uint8
+
+const (
+	// MessageSent is a L2 to L1 message that was sent on L2, relaying it on
+	// L1 is not tracked by L2
+	MessageSent MessageStatus = iota
+	// MessageRelayed is a L1 to L2 message that was relayed successfully
+	MessageRelayed
+	// MessageFailed is a L1 to L2 message whose relay failed. It may still
+	// be replayed by a later L1 to L2 transaction.
+	MessageFailed
+)
+
+func (s MessageStatus) String() string {
+	switch s {
+	case MessageSent:
+		return "sent"
+	case MessageRelayed:
+		return "relayed"
+	case MessageFailed:
+		return "failed"
+	default:
+		return ""
+	}
+}
+
+// CrossDomainMessage is a message of the L2 cross domain messenger along with
+// the position of the transaction that it was last indexed from. A replayed
+// L1 to L2 message keeps the earlier relay that it replaced, so that the
+// earlier relay can be restored when the replay is removed by a reorg.
+type CrossDomainMessage struct {
+	Direction   MessageDirection
+	Status      MessageStatus
+	Sender      common.Address
+	Target      common.Address
+	Message     []byte
+	Nonce       *big.Int
+	BlockNumber uint64
+	TxHash      common.Hash
+	Replaced    *CrossDomainMessage `rlp:"nil"`
+}
+
+// ReadCrossDomainMessage retrieves the cross domain message with the hash
+func ReadCrossDomainMessage(db ethdb.KeyValueReader, hash common.Hash) *CrossDomainMessage {
+	data, _ := db.Get(crossDomainMessageKey(hash))
+	if len(data) == 0 {
+		return nil
+	}
+	message := new(CrossDomainMessage)
+	if err := rlp.DecodeBytes(data, message); err != nil {
+		log.Error("Invalid cross domain message RLP", "hash", hash, "err", err)
+		return nil
+	}
+	return message
+}
+
+// WriteCrossDomainMessage stores the cross domain message with the hash and
+// indexes it by its sender and target
+func WriteCrossDomainMessage(db ethdb.KeyValueWriter, hash common.Hash, message *CrossDomainMessage) {
+	data, err := rlp.EncodeToBytes(message)
+	if err != nil {
+		log.Crit("Failed to RLP encode cross domain message", "err", err)
+	}
+	if err := db.Put(crossDomainMessageKey(hash), data); err != nil {
+		log.Crit("Failed to store cross domain message", "err", err)
+	}
+	for _, address := range []common.Address{message.Sender, message.Target} {
+		if err := db.Put(addressMessageKey(address, message.BlockNumber, hash), nil); err != nil {
+			log.Crit("Failed to store cross domain message address index", "err", err)
+		}
+	}
+}
+
+// DeleteCrossDomainMessage removes the cross domain message with the hash and
+// its address index entries
+func DeleteCrossDomainMessage(db ethdb.KeyValueWriter, hash common.Hash, message *CrossDomainMessage) {
+	if err := db.Delete(crossDomainMessageKey(hash)); err != nil {
+		log.Crit("Failed to delete cross domain message", "err", err)
+	}
+	for _, address := range []common.Address{message.Sender, message.Target} {
+		if err := db.Delete(addressMessageKey(address, message.BlockNumber, hash)); err != nil {
+			log.Crit("Failed to delete cross domain message address index", "err", err)
+		}
+	}
+}
+
+// ReadAddressMessageHashes retrieves the hashes of the cross domain messages
+// that the address is the sender or the target of in block order
+func ReadAddressMessageHashes(db ethdb.Iteratee, address common.Address) []common.Hash {
+	prefix := append(common.CopyBytes(addressMessagePrefix), address.Bytes()...)
+
+	var hashes []common.Hash
+	it := db.NewIteratorWithPrefix(prefix)
+	defer it.Release()
+
+	for it.Next() {
+		if key := it.Key(); len(key) == len(prefix)+8+common.HashLength {
+			hashes = append(hashes, common.BytesToHash(key[len(key)-common.HashLength:]))
+		}
+	}
+	return hashes
+}
+
+// ReadBlockMessageHashes retrieves the hashes of the cross domain messages
+// that were indexed from the block with the number
+func ReadBlockMessageHashes(db ethdb.KeyValueReader, number uint64) []common.Hash {
+	data, _ := db.Get(blockMessagesKey(number))
+	if len(data) == 0 {
+		return nil
+	}
+	var hashes []common.Hash
+	if err := rlp.DecodeBytes(data, &hashes); err != nil {
+		log.Error("Invalid block message hashes RLP", "number", number, "err", err)
+		return nil
+	}
+	return hashes
+}
+
+// WriteBlockMessageHashes stores the hashes of the cross domain messages that
+// were indexed from the block with the number
+func WriteBlockMessageHashes(db ethdb.KeyValueWriter, number uint64, hashes []common.Hash) {
+	data, err := rlp.EncodeToBytes(hashes)
+	if err != nil {
+		log.Crit("Failed to RLP encode block message hashes", "err", err)
+	}
+	if err := db.Put(blockMessagesKey(number), data); err != nil {
+		log.Crit("Failed to store block message hashes", "err", err)
+	}
+}
+
+// ReadMessageBlockNumbers retrieves the numbers of the blocks from the number
+// on that cross domain messages were indexed from in ascending order
+func ReadMessageBlockNumbers(db ethdb.Iteratee, from uint64) []uint64 {
+	it := db.NewIteratorWithStart(blockMessagesKey(from))
+	defer it.Release()
+
+	var numbers []uint64
+	for it.Next() {
+		key := it.Key()
+		if !bytes.HasPrefix(key, blockMessagesPrefix) {
+			break
+		}
+		if len(key) == len(blockMessagesPrefix)+8 {
+			numbers = append(numbers, binary.BigEndian.Uint64(key[len(blockMessagesPrefix):]))
+		}
+	}
+	return numbers
+}
+
+// DeleteBlockMessageHashes removes the hashes of the cross domain messages
+// that were indexed from the block with the number
+func DeleteBlockMessageHashes(db ethdb.KeyValueWriter, number uint64) {
+	if err := db.Delete(blockMessagesKey(number)); err != nil {
+		log.Crit("Failed to delete block message hashes", "err", err)
+	}
+}
@@ -0,0 +1,92 @@
+package rawdb
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/MetisProtocol/l2geth/common"
+)
+
+func TestCrossDomainMessageStorage(t *testing.T) {
+	db := NewMemoryDatabase()
+	var (
+		sender = common.HexToAddress("0x01")
+		target = common.HexToAddress("0x02")
+		first  = common.HexToHash("0x0a")
+		second = common.HexToHash("0x0b")
+	)
+	if ReadCrossDomainMessage(db, first) != nil {
+		t.Fatal("unexpected message")
+	}
+	deposit := &CrossDomainMessage{
+		Direction:   MessageL1ToL2,
+		Status:      MessageRelayed,
+		Sender:      sender,
+		Target:      target,
+		Message:     []byte{0x01, 0x02},
+		Nonce:       big.NewInt(7),
+		BlockNumber: 2,
+		TxHash:      common.HexToHash("0x1234"),
+	}
+	withdrawal := &CrossDomainMessage{
+		Direction:   MessageL2ToL1,
+		Status:      MessageSent,
+		Sender:      target,
+		Target:      target,
+		Nonce:       big.NewInt(0),
+		BlockNumber: 1,
+	}
+	WriteCrossDomainMessage(db, first, deposit)
+	WriteCrossDomainMessage(db, second, withdrawal)
+
+	if have := ReadCrossDomainMessage(db, first); !reflect.DeepEqual(have, deposit) {
+		t.Fatalf("message mismatch: have %+v, want %+v", have, deposit)
+	}
+	if have, want := ReadAddressMessageHashes(db, sender), []common.Hash{first}; !reflect.DeepEqual(have, want) {
+		t.Fatalf("sender messages mismatch: have %v, want %v", have, want)
+	}
+	// Messages are listed in block order
+	if have, want := ReadAddressMessageHashes(db, target), []common.Hash{second, first}; !reflect.DeepEqual(have, want) {
+		t.Fatalf("target messages mismatch: have %v, want %v", have, want)
+	}
+	// A replay keeps the relay that it replaced
+	replay := *deposit
+	replay.BlockNumber, replay.TxHash, replay.Replaced = 3, common.HexToHash("0x5678"), deposit
+	WriteCrossDomainMessage(db, first, &replay)
+	if have := ReadCrossDomainMessage(db, first); !reflect.DeepEqual(have, &replay) {
+		t.Fatalf("replay mismatch: have %+v, want %+v", have, &replay)
+	}
+	DeleteCrossDomainMessage(db, first, &replay)
+	DeleteCrossDomainMessage(db, first, deposit)
+	if ReadCrossDomainMessage(db, first) != nil {
+		t.Fatal("message not deleted")
+	}
+	if have := ReadAddressMessageHashes(db, sender); len(have) != 0 {
+		t.Fatalf("sender messages not deleted: %v", have)
+	}
+}
+
+func TestBlockMessageHashesStorage(t *testing.T) {
+	db := NewMemoryDatabase()
+	if ReadBlockMessageHashes(db, 1) != nil {
+		t.Fatal("unexpected hashes")
+	}
+	hashes := []common.Hash{common.HexToHash("0x01"), common.HexToHash("0x02")}
+	WriteBlockMessageHashes(db, 1, hashes)
+	if have := ReadBlockMessageHashes(db, 1); !reflect.DeepEqual(have, hashes) {
+		t.Fatalf("hashes mismatch: have %v, want %v", have, hashes)
+	}
+	WriteBlockMessageHashes(db, 3, hashes)
+	WriteBlockMessageHashes(db, 7, hashes)
+	if have := ReadMessageBlockNumbers(db, 2); !reflect.DeepEqual(have, []uint64{3, 7}) {
+		t.Fatalf("block numbers mismatch: have %v, want [3 7]", have)
+	}
+	DeleteBlockMessageHashes(db, 1)
+	if ReadBlockMessageHashes(db, 1) != nil {
+		t.Fatal("hashes not deleted")
+	}
+	if have := ReadMessageBlockNumbers(db, 0); !reflect.DeepEqual(have, []uint64{3, 7}) {
+		t.Fatalf("block numbers mismatch: have %v, want [3 7]", have)
+	}
+}
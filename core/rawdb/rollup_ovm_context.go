@@ -0,0 +1,91 @@
+package rawdb
+
+import (
+	"github.com/MetisProtocol/l2geth/common"
+	"github.com/MetisProtocol/l2geth/ethdb"
+	"github.com/MetisProtocol/l2geth/log"
+	"github.com/MetisProtocol/l2geth/rlp"
+)
+
+// OVMContext is the L1 block number and timestamp that the sync service
+// executes L2 transactions with
+type OVMContext struct {
+	BlockNumber uint64
+	Timestamp   uint64
+}
+
+// BlockOVMContext is the L1 context that a L2 block was executed with, along
+// with the hash of the L1 block that anchors it. The hash is nil until it is
+// known.
+type BlockOVMContext struct {
+	BlockNumber uint64
+	Timestamp   uint64
+	BlockHash   *common.Hash `rlp:"nil"`
+}
+
+// ReadHeadOVMContext retrieves the latest L1 context of the sync service
+func ReadHeadOVMContext(db ethdb.KeyValueReader) *OVMContext {
+	data, _ := db.Get(headOVMContextKey)
+	if len(data) == 0 {
+		return nil
+	}
+	context := new(OVMContext)
+	if err := rlp.DecodeBytes(data, context); err != nil {
+		log.Error("Invalid OVM context RLP", "err", err)
+		return nil
+	}
+	return context
+}
+
+// WriteHeadOVMContext stores the latest L1 context of the sync service
+func WriteHeadOVMContext(db ethdb.KeyValueWriter, context *OVMContext) {
+	data, err := rlp.EncodeToBytes(context)
+	if err != nil {
+		log.Crit("Failed to RLP encode OVM context", "err", err)
+	}
+	if err := db.Put(headOVMContextKey, data); err != nil {
+		log.Crit("Failed to store OVM context", "err", err)
+	}
+}
+
+// DeleteHeadOVMContext removes the latest L1 context of the sync service
+func DeleteHeadOVMContext(db ethdb.KeyValueWriter) {
+	if err := db.Delete(headOVMContextKey); err != nil {
+		log.Crit("Failed to delete OVM context", "err", err)
+	}
+}
+
+// ReadBlockOVMContext retrieves the L1 context of the canonical L2 block with
+// the number
+func ReadBlockOVMContext(db ethdb.KeyValueReader, number uint64) *BlockOVMContext {
+	data, _ := db.Get(blockOVMContextKey(number))
+	if len(data) == 0 {
+		return nil
+	}
+	context := new(BlockOVMContext)
+	if err := rlp.DecodeBytes(data, context); err != nil {
+		log.Error("Invalid block OVM context RLP", "number", number, "err", err)
+		return nil
+	}
+	return context
+}
+
+// WriteBlockOVMContext stores the L1 context of the canonical L2 block with
+// the number
+func WriteBlockOVMContext(db ethdb.KeyValueWriter, number uint64, context *BlockOVMContext) {
+	data, err := rlp.EncodeToBytes(context)
+	if err != nil {
+		log.Crit("Failed to RLP encode block OVM context", "err", err)
+	}
+	if err := db.Put(blockOVMContextKey(number), data); err != nil {
+		log.Crit("Failed to store block OVM context", "err", err)
+	}
+}
+
+// DeleteBlockOVMContext removes the L1 context of the canonical L2 block with
+// the number
+func DeleteBlockOVMContext(db ethdb.KeyValueWriter, number uint64) {
+	if err := db.Delete(blockOVMContextKey(number)); err != nil {
+		log.Crit("Failed to delete block OVM context", "err", err)
+	}
+}
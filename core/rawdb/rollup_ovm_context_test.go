@@ -0,0 +1,46 @@
+package rawdb
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/MetisProtocol/l2geth/common"
+)
+
+func TestHeadOVMContextStorage(t *testing.T) {
+	db := NewMemoryDatabase()
+	if ReadHeadOVMContext(db) != nil {
+		t.Fatal("unexpected OVM context")
+	}
+	for _, context := range []*OVMContext{{}, {BlockNumber: 1, Timestamp: 2}, {BlockNumber: 1 << 40, Timestamp: 1 << 33}} {
+		WriteHeadOVMContext(db, context)
+		if have := ReadHeadOVMContext(db); !reflect.DeepEqual(have, context) {
+			t.Fatalf("OVM context mismatch: have %+v, want %+v", have, context)
+		}
+	}
+	DeleteHeadOVMContext(db)
+	if ReadHeadOVMContext(db) != nil {
+		t.Fatal("OVM context not deleted")
+	}
+}
+
+func TestBlockOVMContextStorage(t *testing.T) {
+	db := NewMemoryDatabase()
+	if ReadBlockOVMContext(db, 1) != nil {
+		t.Fatal("unexpected block OVM context")
+	}
+	hash := common.HexToHash("0xff")
+	for _, context := range []*BlockOVMContext{{BlockNumber: 10, Timestamp: 100}, {BlockNumber: 10, Timestamp: 100, BlockHash: &hash}} {
+		WriteBlockOVMContext(db, 1, context)
+		if have := ReadBlockOVMContext(db, 1); !reflect.DeepEqual(have, context) {
+			t.Fatalf("block OVM context mismatch: have %+v, want %+v", have, context)
+		}
+	}
+	if ReadBlockOVMContext(db, 2) != nil {
+		t.Fatal("block OVM context returned for another block")
+	}
+	DeleteBlockOVMContext(db, 1)
+	if ReadBlockOVMContext(db, 1) != nil {
+		t.Fatal("block OVM context not deleted")
+	}
+}
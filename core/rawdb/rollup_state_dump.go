@@ -0,0 +1,22 @@
+package rawdb
+
+import (
+	"github.com/MetisProtocol/l2geth/common"
+	"github.com/MetisProtocol/l2geth/ethdb"
+	"github.com/MetisProtocol/l2geth/log"
+)
+
+// ReadStateDump retrieves the JSON encoded OVM state dump that the genesis
+// block with the hash was created from
+func ReadStateDump(db ethdb.KeyValueReader, hash common.Hash) []byte {
+	data, _ := db.Get(stateDumpKey(hash))
+	return data
+}
+
+// WriteStateDump stores the JSON encoded OVM state dump that the genesis block
+// with the hash was created from
+func WriteStateDump(db ethdb.KeyValueWriter, hash common.Hash, data []byte) {
+	if err := db.Put(stateDumpKey(hash), data); err != nil {
+		log.Crit("Failed to store state dump", "err", err)
+	}
+}
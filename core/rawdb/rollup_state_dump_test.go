@@ -0,0 +1,21 @@
+package rawdb
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/MetisProtocol/l2geth/common"
+)
+
+func TestStateDumpStorage(t *testing.T) {
+	db := NewMemoryDatabase()
+	hash := common.HexToHash("0x01")
+	if data := ReadStateDump(db, hash); len(data) != 0 {
+		t.Fatalf("unexpected state dump: %s", data)
+	}
+	stateDump := []byte(`{"accounts":{}}`)
+	WriteStateDump(db, hash, stateDump)
+	if data := ReadStateDump(db, hash); !bytes.Equal(data, stateDump) {
+		t.Fatalf("state dump mismatch: have %s, want %s", data, stateDump)
+	}
+}
@@ -0,0 +1,77 @@
+package rawdb
+
+import (
+	"bytes"
+
+	"github.com/MetisProtocol/l2geth/common"
+	"github.com/MetisProtocol/l2geth/ethdb"
+	"github.com/MetisProtocol/l2geth/log"
+	"github.com/MetisProtocol/l2geth/rlp"
+)
+
+// StateRootMismatch is a state root that was submitted to layer one which
+// does not match the state root of the local block at the same index
+type StateRootMismatch struct {
+	Index       uint64
+	BatchIndex  uint64
+	BlockNumber uint64
+	BlockHash   common.Hash
+	LocalRoot   common.Hash
+	RemoteRoot  common.Hash
+}
+
+// ReadStateRootMismatch retrieves the state root mismatch at the Canonical
+// Transaction Chain index
+func ReadStateRootMismatch(db ethdb.KeyValueReader, index uint64) *StateRootMismatch {
+	data, _ := db.Get(stateRootMismatchKey(index))
+	if len(data) == 0 {
+		return nil
+	}
+	mismatch := new(StateRootMismatch)
+	if err := rlp.DecodeBytes(data, mismatch); err != nil {
+		log.Error("Invalid state root mismatch RLP", "index", index, "err", err)
+		return nil
+	}
+	return mismatch
+}
+
+// ReadStateRootMismatches retrieves up to limit state root mismatches starting
+// at the Canonical Transaction Chain index in ascending order
+func ReadStateRootMismatches(db ethdb.Iteratee, start uint64, limit int) []*StateRootMismatch {
+	it := db.NewIteratorWithStart(stateRootMismatchKey(start))
+	defer it.Release()
+
+	var mismatches []*StateRootMismatch
+	for len(mismatches) < limit && it.Next() {
+		if !bytes.HasPrefix(it.Key(), stateRootMismatchPrefix) {
+			break
+		}
+		mismatch := new(StateRootMismatch)
+		if err := rlp.DecodeBytes(it.Value(), mismatch); err != nil {
+			log.Error("Invalid state root mismatch RLP", "key", it.Key(), "err", err)
+			continue
+		}
+		mismatches = append(mismatches, mismatch)
+	}
+	return mismatches
+}
+
+// WriteStateRootMismatch stores a state root mismatch by its Canonical
+// Transaction Chain index
+func WriteStateRootMismatch(db ethdb.KeyValueWriter, mismatch *StateRootMismatch) {
+	data, err := rlp.EncodeToBytes(mismatch)
+	if err != nil {
+		log.Crit("Failed to RLP encode state root mismatch", "err", err)
+	}
+	if err := db.Put(stateRootMismatchKey(mismatch.Index), data); err != nil {
+		log.Crit("Failed to store state root mismatch", "err", err)
+	}
+}
+
+// DeleteStateRootMismatch removes the state root mismatch at the Canonical
+// Transaction Chain index
+func DeleteStateRootMismatch(db ethdb.KeyValueWriter, index uint64) {
+	if err := db.Delete(stateRootMismatchKey(index)); err != nil {
+		log.Crit("Failed to delete state root mismatch", "err", err)
+	}
+}
@@ -0,0 +1,46 @@
+package rawdb
+
+import (
+	"testing"
+
+	"github.com/MetisProtocol/l2geth/common"
+)
+
+func TestReadWriteStateRootMismatches(t *testing.T) {
+	db := NewMemoryDatabase()
+	for _, index := range []uint64{1, 5, 1 << 8, 1 << 32} {
+		WriteStateRootMismatch(db, &StateRootMismatch{
+			Index:       index,
+			BatchIndex:  index / 2,
+			BlockNumber: index + 1,
+			LocalRoot:   common.Hash{0x01},
+			RemoteRoot:  common.Hash{0x02},
+		})
+	}
+	// Unrelated keys that sort after the mismatches must not be returned
+	WriteHeadStateRootBatchIndex(db, 7)
+
+	mismatch := ReadStateRootMismatch(db, 5)
+	if mismatch == nil || mismatch.BlockNumber != 6 || mismatch.RemoteRoot != (common.Hash{0x02}) {
+		t.Fatalf("unexpected mismatch: %v", mismatch)
+	}
+	if ReadStateRootMismatch(db, 2) != nil {
+		t.Fatal("unexpected mismatch at index 2")
+	}
+
+	mismatches := ReadStateRootMismatches(db, 2, 2)
+	if len(mismatches) != 2 || mismatches[0].Index != 5 || mismatches[1].Index != 1<<8 {
+		t.Fatalf("unexpected mismatches: %v", mismatches)
+	}
+	if mismatches = ReadStateRootMismatches(db, 0, 10); len(mismatches) != 4 {
+		t.Fatalf("expected 4 mismatches, got %d", len(mismatches))
+	}
+
+	DeleteStateRootMismatch(db, 5)
+	if ReadStateRootMismatch(db, 5) != nil {
+		t.Fatal("state root mismatch not deleted")
+	}
+	if index := ReadHeadStateRootBatchIndex(db); index == nil || *index != 7 {
+		t.Fatal("unexpected head state root batch index")
+	}
+}
@@ -0,0 +1,56 @@
+package rawdb
+
+import (
+	"math/big"
+
+	"github.com/MetisProtocol/l2geth/common"
+	"github.com/MetisProtocol/l2geth/ethdb"
+	"github.com/MetisProtocol/l2geth/log"
+	"github.com/MetisProtocol/l2geth/rlp"
+)
+
+// TransactionFee is the L1 data fee and the L2 execution fee that were
+// charged to a transaction, computed with the gas prices that were in effect
+// when the transaction was executed
+type TransactionFee struct {
+	L1GasUsed  uint64
+	L1GasPrice *big.Int
+	L1Fee      *big.Int
+	L2GasPrice *big.Int
+	L2Fee      *big.Int
+}
+
+// ReadTransactionFee retrieves the fee components of the transaction with the
+// hash
+func ReadTransactionFee(db ethdb.KeyValueReader, hash common.Hash) *TransactionFee {
+	data, _ := db.Get(txFeeKey(hash))
+	if len(data) == 0 {
+		return nil
+	}
+	fee := new(TransactionFee)
+	if err := rlp.DecodeBytes(data, fee); err != nil {
+		log.Error("Invalid transaction fee RLP", "hash", hash, "err", err)
+		return nil
+	}
+	return fee
+}
+
+// WriteTransactionFee stores the fee components of the transaction with the
+// hash
+func WriteTransactionFee(db ethdb.KeyValueWriter, hash common.Hash, fee *TransactionFee) {
+	data, err := rlp.EncodeToBytes(fee)
+	if err != nil {
+		log.Crit("Failed to RLP encode transaction fee", "err", err)
+	}
+	if err := db.Put(txFeeKey(hash), data); err != nil {
+		log.Crit("Failed to store transaction fee", "err", err)
+	}
+}
+
+// DeleteTransactionFee removes the fee components of the transaction with the
+// hash
+func DeleteTransactionFee(db ethdb.KeyValueWriter, hash common.Hash) {
+	if err := db.Delete(txFeeKey(hash)); err != nil {
+		log.Crit("Failed to delete transaction fee", "err", err)
+	}
+}
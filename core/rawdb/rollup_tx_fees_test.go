@@ -0,0 +1,31 @@
+package rawdb
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/MetisProtocol/l2geth/common"
+)
+
+func TestTransactionFeeStorage(t *testing.T) {
+	db := NewMemoryDatabase()
+	hash := common.HexToHash("0x01")
+	if ReadTransactionFee(db, hash) != nil {
+		t.Fatal("unexpected fee")
+	}
+	WriteTransactionFee(db, hash, &TransactionFee{
+		L1GasUsed:  3000,
+		L1GasPrice: big.NewInt(10),
+		L1Fee:      big.NewInt(30000),
+		L2GasPrice: big.NewInt(1),
+		L2Fee:      big.NewInt(21000),
+	})
+	fee := ReadTransactionFee(db, hash)
+	if fee == nil || fee.L1GasUsed != 3000 || fee.L1Fee.Cmp(big.NewInt(30000)) != 0 || fee.L2Fee.Cmp(big.NewInt(21000)) != 0 {
+		t.Fatalf("unexpected fee: %v", fee)
+	}
+	DeleteTransactionFee(db, hash)
+	if ReadTransactionFee(db, hash) != nil {
+		t.Fatal("fee not deleted")
+	}
+}
@@ -64,12 +64,45 @@ var (
 	headVerifiedIndexKey = []byte("LastVerifiedIndex")
 	// headBatchKey tracks the latest processed batch
 	headBatchKey = []byte("LastBatch")
+	// headStateRootBatchKey tracks the latest verified state root batch
+	headStateRootBatchKey = []byte("LastStateRootBatch")
+	// stateRootMismatchPrefix + index (uint64 big endian) -> state root mismatch
+	stateRootMismatchPrefix = []byte("rollup-state-root-mismatch-")
+	// ctcLookupPrefix + index (uint64 big endian) -> position of the transaction
+	ctcLookupPrefix = []byte("rollup-ctc-lookup-")
+	// queueLookupPrefix + queue index (uint64 big endian) -> position of the transaction
+	queueLookupPrefix = []byte("rollup-queue-lookup-")
+	// transactionBatchPrefix + batch index (uint64 big endian) -> transaction batch
+	transactionBatchPrefix = []byte("rollup-batch-")
+	// txBatchPrefix + index (uint64 big endian) -> batch membership of the transaction
+	txBatchPrefix = []byte("rollup-tx-batch-")
+	// stateRootBatchPrefix + batch index (uint64 big endian) -> verified state root batch
+	stateRootBatchPrefix = []byte("rollup-state-root-batch-")
+	// headOVMContextKey tracks the latest L1 context of the sync service
+	headOVMContextKey = []byte("LastOVMContext")
+	// blockOVMContextPrefix + num (uint64 big endian) -> L1 context that the block was executed with
+	blockOVMContextPrefix = []byte("rollup-block-context-")
+	// headGasPriceSampleKey tracks the number of the latest gas price sample
+	headGasPriceSampleKey = []byte("LastGasPriceSample")
+	// gasPriceSamplePrefix + number (uint64 big endian) -> gas price sample
+	gasPriceSamplePrefix = []byte("rollup-gas-price-sample-")
+	// txFeePrefix + hash -> fee components charged to the transaction
+	txFeePrefix = []byte("rollup-tx-fee-")
+	// stateDumpPrefix + genesis hash -> JSON encoded OVM state dump
+	stateDumpPrefix = []byte("rollup-state-dump-")
+	// crossDomainMessagePrefix + message hash -> cross domain message
+	crossDomainMessagePrefix = []byte("rollup-message-")
+	// addressMessagePrefix + address + num (uint64 big endian) + message hash -> nil
+	addressMessagePrefix = []byte("rollup-address-message-")
+	// blockMessagesPrefix + num (uint64 big endian) -> hashes of the messages indexed in the block
+	blockMessagesPrefix = []byte("rollup-block-messages-")
 
 	preimagePrefix = []byte("secure-key-")      // preimagePrefix + hash -> preimage
 	configPrefix   = []byte("ethereum-config-") // config prefix for the db
 
 	// Chain index prefixes (use `i` + single byte to avoid mixing data types).
 	BloomBitsIndexPrefix = []byte("iB") // BloomBitsIndexPrefix is the data table of a chain indexer to track its progress
+	MessageIndexPrefix   = []byte("iM") // MessageIndexPrefix is the data table of the cross domain message indexer to track its progress
 
 	preimageCounter    = metrics.NewRegisteredCounter("db/preimage/total", nil)
 	preimageHitCounter = metrics.NewRegisteredCounter("db/preimage/hits", nil)
@@ -162,6 +195,82 @@ func txMetaKey(number uint64) []byte {
 	return append(txMetaPrefix, encodeBlockNumber(number)...)
 }
 
+// txMetaIndexKey = txMetaPrefix + num (uint64 big endian) + txIndex (uint64 big endian)
+// The first transaction of a block uses txMetaKey so that the metadata of
+// blocks with a single transaction keeps its key.
+func txMetaIndexKey(number uint64, txIndex uint64) []byte {
+	if txIndex == 0 {
+		return txMetaKey(number)
+	}
+	return append(txMetaKey(number), encodeBlockNumber(txIndex)...)
+}
+
+// stateRootMismatchKey = stateRootMismatchPrefix + index (uint64 big endian)
+func stateRootMismatchKey(index uint64) []byte {
+	return append(stateRootMismatchPrefix, encodeBlockNumber(index)...)
+}
+
+// ctcLookupKey = ctcLookupPrefix + index (uint64 big endian)
+func ctcLookupKey(index uint64) []byte {
+	return append(ctcLookupPrefix, encodeBlockNumber(index)...)
+}
+
+// queueLookupKey = queueLookupPrefix + queue index (uint64 big endian)
+func queueLookupKey(queueIndex uint64) []byte {
+	return append(queueLookupPrefix, encodeBlockNumber(queueIndex)...)
+}
+
+// transactionBatchKey = transactionBatchPrefix + batch index (uint64 big endian)
+func transactionBatchKey(index uint64) []byte {
+	return append(transactionBatchPrefix, encodeBlockNumber(index)...)
+}
+
+// txBatchKey = txBatchPrefix + index (uint64 big endian)
+func txBatchKey(index uint64) []byte {
+	return append(txBatchPrefix, encodeBlockNumber(index)...)
+}
+
+// stateRootBatchKey = stateRootBatchPrefix + batch index (uint64 big endian)
+func stateRootBatchKey(index uint64) []byte {
+	return append(stateRootBatchPrefix, encodeBlockNumber(index)...)
+}
+
+// gasPriceSampleKey = gasPriceSamplePrefix + number (uint64 big endian)
+func gasPriceSampleKey(number uint64) []byte {
+	return append(gasPriceSamplePrefix, encodeBlockNumber(number)...)
+}
+
+// txFeeKey = txFeePrefix + hash
+func txFeeKey(hash common.Hash) []byte {
+	return append(txFeePrefix, hash.Bytes()...)
+}
+
+// blockOVMContextKey = blockOVMContextPrefix + num (uint64 big endian)
+func blockOVMContextKey(number uint64) []byte {
+	return append(blockOVMContextPrefix, encodeBlockNumber(number)...)
+}
+
+// stateDumpKey = stateDumpPrefix + hash
+func stateDumpKey(hash common.Hash) []byte {
+	return append(stateDumpPrefix, hash.Bytes()...)
+}
+
+// crossDomainMessageKey = crossDomainMessagePrefix + hash
+func crossDomainMessageKey(hash common.Hash) []byte {
+	return append(crossDomainMessagePrefix, hash.Bytes()...)
+}
+
+// addressMessageKey = addressMessagePrefix + address + num (uint64 big endian) + hash
+func addressMessageKey(address common.Address, number uint64, hash common.Hash) []byte {
+	key := append(append(addressMessagePrefix, address.Bytes()...), encodeBlockNumber(number)...)
+	return append(key, hash.Bytes()...)
+}
+
+// blockMessagesKey = blockMessagesPrefix + num (uint64 big endian)
+func blockMessagesKey(number uint64) []byte {
+	return append(blockMessagesPrefix, encodeBlockNumber(number)...)
+}
+
 // bloomBitsKey = bloomBitsPrefix + bit (uint16 big endian) + section (uint64 big endian) + hash
 func bloomBitsKey(bit uint, section uint64, hash common.Hash) []byte {
 	key := append(append(bloomBitsPrefix, make([]byte, 10)...), hash.Bytes()...)
@@ -27,6 +27,7 @@ import (
 	"github.com/MetisProtocol/l2geth/common"
 	"github.com/MetisProtocol/l2geth/core/types"
 	"github.com/MetisProtocol/l2geth/crypto"
+	"github.com/MetisProtocol/l2geth/diffdb"
 	"github.com/MetisProtocol/l2geth/log"
 	"github.com/MetisProtocol/l2geth/metrics"
 	"github.com/MetisProtocol/l2geth/rlp"
@@ -95,6 +96,12 @@ type StateDB struct {
 	validRevisions []revision
 	nextRevisionId int
 
+	// Records the accounts and storage slots touched by the OVM_StateManager,
+	// nil when diffs are not being recorded. The diffs are buffered until the
+	// block is written to the chain.
+	diffdb *diffdb.DiffDb
+	diffs  *diffdb.Buffer
+
 	// Measurements gathered during execution for debugging purposes
 	AccountReads   time.Duration
 	AccountHashes  time.Duration
@@ -124,6 +131,21 @@ func New(root common.Hash, db Database) (*StateDB, error) {
 	}, nil
 }
 
+// NewWithDiffDb creates a new state from a given trie that records the
+// accounts and storage slots touched during execution. The diffs are written
+// to the diff db by CommitDiffs.
+func NewWithDiffDb(root common.Hash, db Database, diffDb *diffdb.DiffDb) (*StateDB, error) {
+	statedb, err := New(root, db)
+	if err != nil {
+		return nil, err
+	}
+	if diffDb != nil {
+		statedb.diffdb = diffDb
+		statedb.diffs = diffdb.NewBuffer()
+	}
+	return statedb, nil
+}
+
 // setError remembers the first non-nil error it is called with.
 func (s *StateDB) setError(err error) {
 	if s.dbErr == nil {
@@ -583,6 +605,40 @@ func (db *StateDB) ForEachStorage(addr common.Address, cb func(key, value common
 	return nil
 }
 
+// SetDiffKey records that the storage slot of address was touched in block.
+// It is a noop when the state does not record diffs.
+func (s *StateDB) SetDiffKey(block *big.Int, address common.Address, key common.Hash, mutated bool) error {
+	if s.diffs == nil {
+		return nil
+	}
+	s.diffs.SetDiffKey(block, address, key, mutated)
+	return nil
+}
+
+// SetDiffAccount records that the account at address was modified in block.
+// It is a noop when the state does not record diffs.
+func (s *StateDB) SetDiffAccount(block *big.Int, address common.Address) error {
+	if s.diffs == nil {
+		return nil
+	}
+	s.diffs.SetDiffAccount(block, address)
+	return nil
+}
+
+// CommitDiffs writes the diffs recorded by the state to the diff db. It must
+// only be called for the state of a block that is written to the chain, the
+// diffs of a state that is discarded are dropped along with it.
+func (s *StateDB) CommitDiffs() error {
+	if s.diffs == nil || s.diffs.Len() == 0 {
+		return nil
+	}
+	if err := s.diffdb.Flush(s.diffs); err != nil {
+		return err
+	}
+	s.diffs = diffdb.NewBuffer()
+	return nil
+}
+
 // Copy creates a deep, independent copy of the state.
 // Snapshots of the copied state cannot be applied to the copy.
 func (s *StateDB) Copy() *StateDB {
@@ -641,6 +697,10 @@ func (s *StateDB) Copy() *StateDB {
 	for hash, preimage := range s.preimages {
 		state.preimages[hash] = preimage
 	}
+	if s.diffs != nil {
+		state.diffdb = s.diffdb
+		state.diffs = s.diffs.Copy()
+	}
 	return state
 }
 
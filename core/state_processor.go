@@ -83,7 +83,7 @@ func (p *StateProcessor) Process(block *types.Block, statedb *state.StateDB, cfg
 func ApplyTransaction(config *params.ChainConfig, bc ChainContext, author *common.Address, gp *GasPool, statedb *state.StateDB, header *types.Header, tx *types.Transaction, usedGas *uint64, cfg vm.Config) (*types.Receipt, error) {
 	var msg Message
 	var err error
-	if !vm.UsingOVM {
+	if !config.IsOVM(header.Number) {
 		msg, err = tx.AsMessage(types.MakeSigner(config, header.Number))
 		if err != nil {
 			return nil, err
@@ -101,13 +101,14 @@ func ApplyTransaction(config *params.ChainConfig, bc ChainContext, author *commo
 	}
 	// Create a new context to be used in the EVM environment
 	context := NewEVMContext(msg, header, bc, author)
-	if vm.UsingOVM {
+	if config.IsOVM(header.Number) {
 		// The `NUMBER` opcode returns the L1 blocknumber instead of the L2
 		// blocknumber, so set that here. In the future, this should be
 		// implemented by adding a new property to the EVM struct
 		// `L1BlockNumber` and updating `opNumber` to return that. This
 		// will help with keeping the difference in behavior maintainable over
 		// time
+		context.L2BlockNumber = header.Number
 		context.BlockNumber = msg.L1BlockNumber()
 	}
 	// Create a new environment which holds all relevant information
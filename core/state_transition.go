@@ -135,6 +135,11 @@ func NewStateTransition(evm *vm.EVM, msg Message, gp *GasPool) *StateTransition
 	}
 }
 
+// isOVM returns whether the OVM rules apply to the L2 block of the transition
+func (st *StateTransition) isOVM() bool {
+	return st.evm.ChainRules().IsOVM
+}
+
 // ApplyMessage computes the new state by applying the given message
 // against the old state within the environment.
 //
@@ -169,7 +174,7 @@ func (st *StateTransition) buyGas() error {
 	// Sufficient user balance is checked when the user sends the transaction
 	// via RPC through very similar checks as to when a transaction enters
 	// the layer one mempool. Deposits skip the check
-	if !vm.UsingOVM {
+	if !st.isOVM() {
 		if st.state.GetBalance(st.msg.From()).Cmp(mgval) < 0 {
 			return errInsufficientBalanceForGas
 		}
@@ -182,7 +187,7 @@ func (st *StateTransition) buyGas() error {
 	st.initialGas = st.msg.Gas()
 	// Do not subtract the gas from the user balance when running OVM.
 	// This is handled in the Solidity contracts to enable to fraud proof
-	if !vm.UsingOVM {
+	if !st.isOVM() {
 		st.state.SubBalance(st.msg.From(), mgval)
 	}
 	return nil
@@ -196,7 +201,7 @@ func (st *StateTransition) preCheck() error {
 			// Skip the nonce check for L1 to L2 transactions. They do not
 			// increment a nonce in the state and they also ecrecover to
 			// `address(0)`
-			if vm.UsingOVM {
+			if st.isOVM() {
 				if st.msg.QueueOrigin() == types.QueueOriginL1ToL2 {
 					return st.buyGas()
 				}
@@ -217,7 +222,7 @@ func (st *StateTransition) TransitionDb() (ret []byte, usedGas uint64, failed bo
 		return
 	}
 
-	if vm.UsingOVM {
+	if st.isOVM() {
 		// When the execution is not an `eth_call`, abi encode the user transaction
 		// and place it in the calldata of the msg struct so that the user
 		// transaction can be passed to the system contracts via the calldata
@@ -252,7 +257,7 @@ func (st *StateTransition) TransitionDb() (ret []byte, usedGas uint64, failed bo
 		vmerr error
 	)
 
-	if vm.UsingOVM {
+	if st.isOVM() {
 		to := "<nil>"
 		if msg.To() != nil {
 			to = msg.To().Hex()
@@ -270,7 +275,7 @@ func (st *StateTransition) TransitionDb() (ret []byte, usedGas uint64, failed bo
 		ret, _, st.gas, vmerr = evm.Create(sender, st.data, st.gas, st.value)
 	} else {
 		// Increment the nonce for the next transaction
-		if !vm.UsingOVM {
+		if !st.isOVM() {
 			// Do not set the nonce because that is handled in the Solidity
 			// contracts.
 			st.state.SetNonce(msg.From(), st.state.GetNonce(msg.From())+1)
@@ -286,7 +291,7 @@ func (st *StateTransition) TransitionDb() (ret []byte, usedGas uint64, failed bo
 	}
 	st.refundGas()
 
-	if !vm.UsingOVM {
+	if !st.isOVM() {
 		// Do not pay the gas to the coinbase address when running the OVM
 		st.state.AddBalance(evm.Coinbase, new(big.Int).Mul(new(big.Int).SetUint64(st.gasUsed()), st.gasPrice))
 	}
@@ -295,7 +300,7 @@ func (st *StateTransition) TransitionDb() (ret []byte, usedGas uint64, failed bo
 
 func (st *StateTransition) refundGas() {
 	// Do not refund any gas when running the OVM
-	if vm.UsingOVM {
+	if st.isOVM() {
 		return
 	}
 	// Apply refund counter, capped to half of the used gas.
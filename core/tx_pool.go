@@ -29,7 +29,6 @@ import (
 	"github.com/MetisProtocol/l2geth/common/prque"
 	"github.com/MetisProtocol/l2geth/core/state"
 	"github.com/MetisProtocol/l2geth/core/types"
-	"github.com/MetisProtocol/l2geth/core/vm"
 	"github.com/MetisProtocol/l2geth/event"
 	"github.com/MetisProtocol/l2geth/log"
 	"github.com/MetisProtocol/l2geth/metrics"
@@ -233,6 +232,7 @@ type TxPool struct {
 	mu          sync.RWMutex
 
 	istanbul bool // Fork indicator whether we are in the istanbul stage.
+	ovm      bool // Fork indicator whether the OVM rules apply.
 
 	currentState  *state.StateDB // Current state in the blockchain head
 	pendingNonces *txNoncer      // Pending state tracking virtual nonces
@@ -539,7 +539,7 @@ func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
 	}
 
 	// Ensure the transaction doesn't exceed the current block limit gas.
-	if vm.UsingOVM {
+	if pool.ovm {
 		if pool.currentMaxGas < tx.L2Gas() {
 			return ErrGasLimit
 		}
@@ -560,9 +560,11 @@ func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
 		return ErrUnderpriced
 	}
 	// Ensure the transaction adheres to nonce ordering
-	if vm.UsingOVM {
-		if pool.currentState.GetNonce(from) != tx.Nonce() {
+	if pool.ovm {
+		if nonce := pool.currentState.GetNonce(from); nonce > tx.Nonce() {
 			return ErrNonceTooLow
+		} else if nonce < tx.Nonce() {
+			return ErrNonceTooHigh
 		}
 	} else {
 		if pool.currentState.GetNonce(from) > tx.Nonce() {
@@ -571,7 +573,7 @@ func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
 	}
 	// Transactor should have enough funds to cover the costs
 	// cost == V + GP * GL
-	if vm.UsingOVM {
+	if pool.ovm {
 		if pool.currentState.GetOVMBalance(from).Cmp(tx.Cost()) < 0 {
 			return ErrInsufficientFunds
 		}
@@ -1192,6 +1194,7 @@ func (pool *TxPool) reset(oldHead, newHead *types.Header) {
 	// Update all fork indicator by next pending block number.
 	next := new(big.Int).Add(newHead.Number, big.NewInt(1))
 	pool.istanbul = pool.chainconfig.IsIstanbul(next)
+	pool.ovm = pool.chainconfig.IsOVM(next)
 }
 
 // promoteExecutables moves transactions that have become processable from the
@@ -114,7 +114,7 @@ type (
 
 // run runs the given contract and takes care of running precompiles with a fallback to the byte code interpreter.
 func run(evm *EVM, contract *Contract, input []byte, readOnly bool) ([]byte, error) {
-	if UsingOVM {
+	if evm.chainRules.IsOVM {
 		// OVM_ENABLED
 		// Only log for non `eth_call`s
 		if evm.Context.EthCallSender == nil {
@@ -138,18 +138,15 @@ func run(evm *EVM, contract *Contract, input []byte, readOnly bool) ([]byte, err
 
 		// Only in the case where EnableArbitraryContractDeployment is
 		// set, allows codepath to be skipped when it is not set
-		if EnableArbitraryContractDeployment != nil {
+		if enable := evm.vmConfig.EnableArbitraryContractDeployment; enable != nil {
 			// When the address manager is called
 			if contract.Address() == WhitelistAddress {
 				// If the first four bytes match `isDeployerAllowed(address)`
 				if bytes.Equal(input[0:4], isDeployerAllowedSig) {
-					// Already checked to make sure this value is not nil
-					switch *EnableArbitraryContractDeployment {
-					case EnableArbitraryContractDeploymentTrue:
+					if *enable {
 						return AbiBytesTrue, nil
-					case EnableArbitraryContractDeploymentFalse:
-						return AbiBytesFalse, nil
 					}
+					return AbiBytesFalse, nil
 				}
 			}
 		}
@@ -208,6 +205,7 @@ type Context struct {
 	Difficulty  *big.Int       // Provides information for DIFFICULTY
 
 	// OVM_ADDITION
+	L2BlockNumber             *big.Int // The L2 block number, NUMBER provides the L1 block number
 	EthCallSender             *common.Address
 	IsL1ToL2Message           bool
 	IsSuccessfulL1ToL2Message bool
@@ -217,6 +215,17 @@ type Context struct {
 	OvmL2CrossDomainMessenger dump.OvmDumpAccount
 	OvmETH                    dump.OvmDumpAccount
 	OvmL2StandardBridge       dump.OvmDumpAccount
+	OvmSequencerEntrypoint    dump.OvmDumpAccount
+}
+
+// L2Number returns the L2 block number of the context. The NUMBER opcode
+// returns the L1 block number in the OVM, in which case the L2 block number is
+// carried separately. The chain rules always apply by the L2 block number.
+func (ctx *Context) L2Number() *big.Int {
+	if ctx.L2BlockNumber != nil {
+		return ctx.L2BlockNumber
+	}
+	return ctx.BlockNumber
 }
 
 // EVM is the Ethereum Virtual Machine base object and provides
@@ -268,6 +277,7 @@ func NewEVM(ctx Context, statedb StateDB, chainConfig *params.ChainConfig, vmCon
 		ctx.OvmL2CrossDomainMessenger = chainConfig.StateDump.Accounts["OVM_L2CrossDomainMessenger"]
 		ctx.OvmETH = chainConfig.StateDump.Accounts["OVM_ETH"]
 		ctx.OvmL2StandardBridge = chainConfig.StateDump.Accounts["OVM_L2StandardBridge"]
+		ctx.OvmSequencerEntrypoint = chainConfig.StateDump.Accounts["OVM_SequencerEntrypoint"]
 	}
 
 	evm := &EVM{
@@ -275,11 +285,11 @@ func NewEVM(ctx Context, statedb StateDB, chainConfig *params.ChainConfig, vmCon
 		StateDB:      statedb,
 		vmConfig:     vmConfig,
 		chainConfig:  chainConfig,
-		chainRules:   chainConfig.Rules(ctx.BlockNumber),
+		chainRules:   chainConfig.Rules(ctx.L2Number()),
 		interpreters: make([]Interpreter, 0, 1),
 	}
 
-	if chainConfig.IsEWASM(ctx.BlockNumber) {
+	if chainConfig.IsEWASM(ctx.L2Number()) {
 		// to be implemented by EVM-C and Wagon PRs.
 		// if vmConfig.EWASMInterpreter != "" {
 		//  extIntOpts := strings.Split(vmConfig.EWASMInterpreter, ":")
@@ -333,7 +343,7 @@ func (evm *EVM) Call(caller ContractRef, addr common.Address, input []byte, gas
 		return nil, gas, ErrDepth
 	}
 
-	if !UsingOVM {
+	if !evm.chainRules.IsOVM {
 		// OVM_DISABLED
 		// Fail if we're trying to transfer more than the available balance
 		if !evm.Context.CanTransfer(evm.StateDB, caller.Address(), value) {
@@ -378,7 +388,7 @@ func (evm *EVM) Call(caller ContractRef, addr common.Address, input []byte, gas
 		evm.StateDB.CreateAccount(addr)
 	}
 
-	if !UsingOVM {
+	if !evm.chainRules.IsOVM {
 		// OVM_DISABLED
 		evm.Transfer(evm.StateDB, caller.Address(), to.Address(), value)
 	}
@@ -439,7 +449,7 @@ func (evm *EVM) Call(caller ContractRef, addr common.Address, input []byte, gas
 		}
 	}
 
-	if UsingOVM {
+	if evm.chainRules.IsOVM {
 		// OVM_ENABLED
 		if evm.depth == 0 {
 			// We're back at the root-level message call, so we'll need to modify the return data
@@ -500,7 +510,7 @@ func (evm *EVM) CallCode(caller ContractRef, addr common.Address, input []byte,
 	if evm.depth > int(params.CallCreateDepth) {
 		return nil, gas, ErrDepth
 	}
-	if !UsingOVM {
+	if !evm.chainRules.IsOVM {
 		// OVM_DISABLED
 		// Fail if we're trying to transfer more than the available balance
 		if !evm.CanTransfer(evm.StateDB, caller.Address(), value) {
@@ -620,7 +630,7 @@ func (evm *EVM) create(caller ContractRef, codeAndHash *codeAndHash, gas uint64,
 	if evm.depth > int(params.CallCreateDepth) {
 		return nil, common.Address{}, gas, ErrDepth
 	}
-	if !UsingOVM {
+	if !evm.chainRules.IsOVM {
 		// OVM_DISABLED
 		if !evm.CanTransfer(evm.StateDB, caller.Address(), value) {
 			return nil, common.Address{}, gas, ErrInsufficientBalance
@@ -638,7 +648,7 @@ func (evm *EVM) create(caller ContractRef, codeAndHash *codeAndHash, gas uint64,
 	if evm.chainRules.IsEIP158 {
 		evm.StateDB.SetNonce(address, 1)
 	}
-	if !UsingOVM {
+	if !evm.chainRules.IsOVM {
 		// OVM_DISABLED
 		evm.Transfer(evm.StateDB, caller.Address(), address, value)
 	}
@@ -696,7 +706,7 @@ func (evm *EVM) create(caller ContractRef, codeAndHash *codeAndHash, gas uint64,
 
 // Create creates a new contract using code as deployment code.
 func (evm *EVM) Create(caller ContractRef, code []byte, gas uint64, value *big.Int) (ret []byte, contractAddr common.Address, leftOverGas uint64, err error) {
-	if !UsingOVM {
+	if !evm.chainRules.IsOVM {
 		// OVM_DISABLED
 		contractAddr = crypto.CreateAddress(caller.Address(), evm.StateDB.GetNonce(caller.Address()))
 	} else {
@@ -722,7 +732,7 @@ func (evm *EVM) Create(caller ContractRef, code []byte, gas uint64, value *big.I
 // instead of the usual sender-and-nonce-hash as the address where the contract is initialized at.
 func (evm *EVM) Create2(caller ContractRef, code []byte, gas uint64, endowment *big.Int, salt *big.Int) (ret []byte, contractAddr common.Address, leftOverGas uint64, err error) {
 	codeAndHash := &codeAndHash{code: code}
-	if !UsingOVM {
+	if !evm.chainRules.IsOVM {
 		// OVM_DISABLED
 		contractAddr = crypto.CreateAddress2(caller.Address(), common.BigToHash(salt), codeAndHash.Hash().Bytes())
 	} else {
@@ -745,6 +755,9 @@ func (evm *EVM) Create2(caller ContractRef, code []byte, gas uint64, endowment *
 // ChainConfig returns the environment's chain configuration
 func (evm *EVM) ChainConfig() *params.ChainConfig { return evm.chainConfig }
 
+// ChainRules returns the chain rules of the environment's block
+func (evm *EVM) ChainRules() params.Rules { return evm.chainRules }
+
 // OvmADDRESS will be set by the execution manager to the target address whenever it's
 // about to create a new contract. This value is currently stored at the [15] storage slot.
 // Can pull this specific storage slot to get the address that the execution manager is
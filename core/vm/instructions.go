@@ -898,7 +898,7 @@ func makeLog(size int) executionFunc {
 		}
 
 		contractAddr := contract.Address()
-		if UsingOVM {
+		if interpreter.evm.chainRules.IsOVM {
 			contractAddr = interpreter.evm.OvmADDRESS()
 		}
 
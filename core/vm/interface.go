@@ -64,6 +64,11 @@ type StateDB interface {
 	AddPreimage(common.Hash, []byte)
 
 	ForEachStorage(common.Address, func(common.Hash, common.Hash) bool) error
+
+	// SetDiffKey records that the storage slot was touched in the block,
+	// SetDiffAccount records that the account itself was modified.
+	SetDiffKey(block *big.Int, address common.Address, key common.Hash, mutated bool) error
+	SetDiffAccount(block *big.Int, address common.Address) error
 }
 
 // CallContext provides a basic interface for the EVM calling conventions. The EVM
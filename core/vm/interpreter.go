@@ -39,6 +39,10 @@ type Config struct {
 	EVMInterpreter   string // External EVM interpreter options
 
 	ExtraEips []int // Additional EIPS that are to be enabled
+
+	// EnableArbitraryContractDeployment overrides the deployer whitelist of
+	// the OVM, nil leaves the decision to the whitelist contract
+	EnableArbitraryContractDeployment *bool
 }
 
 // Interpreter is used to run Ethereum based contracts and will utilise the
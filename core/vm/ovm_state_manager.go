@@ -1,193 +1,168 @@
 package vm
 
 import (
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"math/big"
 
 	"github.com/MetisProtocol/l2geth/common"
 	"github.com/MetisProtocol/l2geth/log"
+	"github.com/MetisProtocol/l2geth/metrics"
 )
 
-type stateManagerFunction func(*EVM, *Contract, map[string]interface{}) ([]interface{}, error)
-
-var funcs = map[string]stateManagerFunction{
-	"owner":                                    owner,
-	"setAccountNonce":                          setAccountNonce,
-	"getAccountNonce":                          getAccountNonce,
-	"getAccountEthAddress":                     getAccountEthAddress,
-	"getContractStorage":                       getContractStorage,
-	"putContractStorage":                       putContractStorage,
-	"isAuthenticated":                          nativeFunctionTrue,
-	"hasAccount":                               nativeFunctionTrue,
-	"hasEmptyAccount":                          hasEmptyAccount,
-	"hasContractStorage":                       nativeFunctionTrue,
-	"testAndSetAccountLoaded":                  testAndSetAccount,
-	"testAndSetAccountChanged":                 testAndSetAccount,
-	"testAndSetContractStorageLoaded":          testAndSetContractStorageLoaded,
-	"testAndSetContractStorageChanged":         testAndSetContractStorageChanged,
-	"incrementTotalUncommittedAccounts":        nativeFunctionVoid,
-	"incrementTotalUncommittedContractStorage": nativeFunctionVoid,
-	"initPendingAccount":                       nativeFunctionVoid,
-	"commitPendingAccount":                     nativeFunctionVoid,
-}
-
-func callStateManager(input []byte, evm *EVM, contract *Contract) (ret []byte, err error) {
-	method, err := evm.Context.OvmStateManager.ABI.MethodById(input)
-	if err != nil {
-		return nil, fmt.Errorf("cannot find method id %s: %w", input, err)
-	}
+var (
+	// errUnknownStateManagerMethod represents the error case of a call to a
+	// OVM_StateManager method that is not implemented natively
+	errUnknownStateManagerMethod = errors.New("unknown OVM_StateManager method")
+	// errShortStateManagerInput represents the error case of a call to the
+	// OVM_StateManager with missing arguments
+	errShortStateManagerInput = errors.New("OVM_StateManager input too short")
 
-	var inputArgs = make(map[string]interface{})
-	if err := method.Inputs.UnpackIntoMap(inputArgs, input[4:]); err != nil {
-		return nil, err
-	}
-
-	fn, exist := funcs[method.RawName]
-	if !exist {
-		return nil, fmt.Errorf("Native OVM_StateManager function not found for method '%s'", method.RawName)
-	}
-
-	outputArgs, err := fn(evm, contract, inputArgs)
-	if err != nil {
-		return nil, fmt.Errorf("cannot execute state manager function: %w", err)
-	}
-
-	returndata, err := method.Outputs.PackValues(outputArgs)
-	if err != nil {
-		return nil, fmt.Errorf("cannot pack returndata: %w", err)
-	}
-
-	return returndata, nil
-}
-
-func owner(evm *EVM, contract *Contract, args map[string]interface{}) ([]interface{}, error) {
-	origin := evm.Context.Origin
-	return []interface{}{origin}, nil
-}
-
-func setAccountNonce(evm *EVM, contract *Contract, args map[string]interface{}) ([]interface{}, error) {
-	address, ok := args["_address"].(common.Address)
-	if !ok {
-		return nil, errors.New("Could not parse address arg in setAccountNonce")
-	}
-	nonce, ok := args["_nonce"].(*big.Int)
-	if !ok {
-		return nil, errors.New("Could not parse nonce arg in setAccountNonce")
-	}
-	evm.StateDB.SetNonce(address, nonce.Uint64())
-	return []interface{}{}, nil
-}
-
-func getAccountNonce(evm *EVM, contract *Contract, args map[string]interface{}) ([]interface{}, error) {
-	address, ok := args["_address"].(common.Address)
-	if !ok {
-		return nil, errors.New("Could not parse address arg in getAccountNonce")
-	}
-	nonce := evm.StateDB.GetNonce(address)
-	return []interface{}{new(big.Int).SetUint64(nonce)}, nil
-}
-
-func getAccountEthAddress(evm *EVM, contract *Contract, args map[string]interface{}) ([]interface{}, error) {
-	address, ok := args["_address"].(common.Address)
-	if !ok {
-		return nil, errors.New("Could not parse address arg in getAccountEthAddress")
-	}
-	return []interface{}{address}, nil
-}
+	stateManagerUnknownMeter = metrics.NewRegisteredMeter("vm/ovm/statemanager/unknown", nil)
+)
 
-func getContractStorage(evm *EVM, contract *Contract, args map[string]interface{}) ([]interface{}, error) {
-	address, ok := args["_contract"].(common.Address)
-	if !ok {
-		return nil, errors.New("Could not parse contract arg in getContractStorage")
-	}
-	_key, ok := args["_key"]
-	if !ok {
-		return nil, errors.New("Could not parse key arg in getContractStorage")
-	}
-	key := toHash(_key)
+// callStateManager executes a call to the OVM_StateManager natively. The
+// method is selected by the first four bytes of the input and its arguments
+// are decoded directly from the input, the ABI of the state dump is not used.
+func callStateManager(input []byte, evm *EVM, contract *Contract) (ret []byte, err error) {
+	if len(input) < 4 {
+		return nil, fmt.Errorf("%w: have %d bytes, want 4", errShortStateManagerInput, len(input))
+	}
+	selector := binary.BigEndian.Uint32(input)
+	args := stateManagerArgs(input[4:])
+
+	switch selector {
+	case ownerSelector:
+		return encodeAddress(evm.Context.Origin), nil
+
+	case setAccountNonceSelector:
+		if err := args.expect(2); err != nil {
+			return nil, err
+		}
+		setAccountNonce(evm, args.address(0), args.uint64(1))
+		return nil, nil
+
+	case getAccountNonceSelector:
+		if err := args.expect(1); err != nil {
+			return nil, err
+		}
+		return encodeUint64(evm.StateDB.GetNonce(args.address(0))), nil
+
+	case getAccountEthAddressSelector:
+		if err := args.expect(1); err != nil {
+			return nil, err
+		}
+		return encodeAddress(args.address(0)), nil
+
+	case getContractStorageSelector:
+		if err := args.expect(2); err != nil {
+			return nil, err
+		}
+		val := getContractStorage(evm, args.address(0), args.hash(1))
+		return val.Bytes(), nil
+
+	case putContractStorageSelector:
+		if err := args.expect(3); err != nil {
+			return nil, err
+		}
+		putContractStorage(evm, args.address(0), args.hash(1), args.hash(2))
+		return nil, nil
+
+	case isAuthenticatedSelector, hasAccountSelector, hasContractStorageSelector:
+		return encodeBool(true), nil
+
+	case hasEmptyAccountSelector:
+		if err := args.expect(1); err != nil {
+			return nil, err
+		}
+		return encodeBool(hasEmptyAccount(evm, args.address(0))), nil
+
+	case testAndSetAccountLoadedSelector, testAndSetAccountChangedSelector:
+		if err := args.expect(1); err != nil {
+			return nil, err
+		}
+		setDiffAccount(evm, args.address(0))
+		return encodeBool(true), nil
+
+	case testAndSetContractStorageLoadedSelector, testAndSetContractStorageChangedSelector:
+		if err := args.expect(2); err != nil {
+			return nil, err
+		}
+		changed := selector == testAndSetContractStorageChangedSelector
+		testAndSetContractStorage(evm, args.address(0), args.hash(1), changed)
+		return encodeBool(true), nil
+
+	case incrementTotalUncommittedAccountsSelector, incrementTotalUncommittedContractStorageSelector,
+		initPendingAccountSelector, commitPendingAccountSelector:
+		return nil, nil
+
+	default:
+		stateManagerUnknownMeter.Mark(1)
+		return nil, fmt.Errorf("%w: 0x%08x", errUnknownStateManagerMethod, selector)
+	}
+}
+
+func setAccountNonce(evm *EVM, address common.Address, nonce uint64) {
+	evm.StateDB.SetNonce(address, nonce)
+	setDiffAccount(evm, address)
+}
+
+func getContractStorage(evm *EVM, address common.Address, key common.Hash) common.Hash {
 	val := evm.StateDB.GetState(address, key)
 	if evm.Context.EthCallSender == nil {
 		log.Debug("Got contract storage", "address", address.Hex(), "key", key.Hex(), "val", val.Hex())
 	}
-	return []interface{}{val}, nil
+	setDiffKey(evm, address, key, false)
+	return val
 }
 
-func putContractStorage(evm *EVM, contract *Contract, args map[string]interface{}) ([]interface{}, error) {
-	address, ok := args["_contract"].(common.Address)
-	if !ok {
-		return nil, errors.New("Could not parse address arg in putContractStorage")
-	}
-	_key, ok := args["_key"]
-	if !ok {
-		return nil, errors.New("Could not parse key arg in putContractStorage")
-	}
-	key := toHash(_key)
-	_value, ok := args["_value"]
-	if !ok {
-		return nil, errors.New("Could not parse value arg in putContractStorage")
-	}
-	val := toHash(_value)
+func putContractStorage(evm *EVM, address common.Address, key, val common.Hash) {
 	if evm.Context.EthCallSender == nil {
 		log.Debug("Put contract storage", "address", address.Hex(), "key", key.Hex(), "val", val.Hex())
 	}
+	before := evm.StateDB.GetState(address, key)
 	evm.StateDB.SetState(address, key, val)
-	return []interface{}{}, nil
-}
-
-func testAndSetAccount(evm *EVM, contract *Contract, args map[string]interface{}) ([]interface{}, error) {
-	return []interface{}{true}, nil
-}
-
-func testAndSetContractStorageLoaded(evm *EVM, contract *Contract, args map[string]interface{}) ([]interface{}, error) {
-	return testAndSetContractStorage(evm, contract, args, false)
-}
-
-func testAndSetContractStorageChanged(evm *EVM, contract *Contract, args map[string]interface{}) ([]interface{}, error) {
-	return testAndSetContractStorage(evm, contract, args, true)
+	setDiffKey(evm, address, key, before != val)
 }
 
-func testAndSetContractStorage(evm *EVM, contract *Contract, args map[string]interface{}, changed bool) ([]interface{}, error) {
-	address, ok := args["_contract"].(common.Address)
-	if !ok {
-		return nil, errors.New("Could not parse address arg in putContractStorage")
-	}
-	_key, ok := args["_key"]
-	if !ok {
-		return nil, errors.New("Could not parse key arg in putContractStorage")
-	}
-	key := toHash(_key)
-
+func testAndSetContractStorage(evm *EVM, address common.Address, key common.Hash, changed bool) {
 	if evm.Context.EthCallSender == nil {
 		log.Debug("Test and Set Contract Storage", "address", address.Hex(), "key", key.Hex(), "changed", changed)
 	}
-
-	return []interface{}{true}, nil
+	setDiffKey(evm, address, key, changed)
 }
 
-func hasEmptyAccount(evm *EVM, contract *Contract, args map[string]interface{}) ([]interface{}, error) {
-	address, ok := args["_address"].(common.Address)
-	if !ok {
-		return nil, errors.New("Could not parse address arg in hasEmptyAccount")
-	}
-
+func hasEmptyAccount(evm *EVM, address common.Address) bool {
 	contractHash := evm.StateDB.GetCodeHash(address)
-	if evm.StateDB.GetNonce(address) != 0 || (contractHash != (common.Hash{}) && contractHash != emptyCodeHash) {
-		return []interface{}{false}, nil
-	}
-
-	return []interface{}{true}, nil
+	return evm.StateDB.GetNonce(address) == 0 && (contractHash == (common.Hash{}) || contractHash == emptyCodeHash)
 }
 
-func nativeFunctionTrue(evm *EVM, contract *Contract, args map[string]interface{}) ([]interface{}, error) {
-	return []interface{}{true}, nil
+// diffBlockNumber returns the block number that state diffs are recorded
+// under. The NUMBER opcode returns the L1 block number in the OVM, so the L2
+// block number is carried separately when it is known.
+func diffBlockNumber(evm *EVM) *big.Int {
+	return evm.Context.L2Number()
 }
 
-func nativeFunctionVoid(evm *EVM, contract *Contract, args map[string]interface{}) ([]interface{}, error) {
-	return []interface{}{}, nil
+// setDiffKey records a touched storage slot. Nothing is recorded for eth_calls
+// since they never make it into a block.
+func setDiffKey(evm *EVM, address common.Address, key common.Hash, mutated bool) {
+	if evm.Context.EthCallSender != nil {
+		return
+	}
+	if err := evm.StateDB.SetDiffKey(diffBlockNumber(evm), address, key, mutated); err != nil {
+		log.Error("Cannot set diff key", "address", address.Hex(), "key", key.Hex(), "err", err)
+	}
 }
 
-func toHash(arg interface{}) common.Hash {
-	b := arg.([32]uint8)
-	return common.BytesToHash(b[:])
+// setDiffAccount records a touched account. Nothing is recorded for eth_calls
+// since they never make it into a block.
+func setDiffAccount(evm *EVM, address common.Address) {
+	if evm.Context.EthCallSender != nil {
+		return
+	}
+	if err := evm.StateDB.SetDiffAccount(diffBlockNumber(evm), address); err != nil {
+		log.Error("Cannot set diff account", "address", address.Hex(), "err", err)
+	}
 }
@@ -0,0 +1,100 @@
+package vm
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/MetisProtocol/l2geth/common"
+)
+
+// Selectors of the natively implemented OVM_StateManager methods, the first
+// four bytes of the keccak256 hash of the method signature
+const (
+	ownerSelector                                    uint32 = 0x8da5cb5b
+	setAccountNonceSelector                          uint32 = 0xe90abb86
+	getAccountNonceSelector                          uint32 = 0xd126199f
+	getAccountEthAddressSelector                     uint32 = 0x7c8ee703
+	getContractStorageSelector                       uint32 = 0x1aaf392f
+	putContractStorageSelector                       uint32 = 0x5c17d629
+	isAuthenticatedSelector                          uint32 = 0xd15d4150
+	hasAccountSelector                               uint32 = 0xc8e40fbf
+	hasEmptyAccountSelector                          uint32 = 0x07a12945
+	hasContractStorageSelector                       uint32 = 0x0ad22679
+	testAndSetAccountLoadedSelector                  uint32 = 0xfb37b31c
+	testAndSetAccountChangedSelector                 uint32 = 0x11b1f790
+	testAndSetContractStorageLoadedSelector          uint32 = 0xaf37b864
+	testAndSetContractStorageChangedSelector         uint32 = 0xaf3dc011
+	incrementTotalUncommittedAccountsSelector        uint32 = 0x33f94305
+	incrementTotalUncommittedContractStorageSelector uint32 = 0xc3fd9b25
+	initPendingAccountSelector                       uint32 = 0xfcf149a2
+	commitPendingAccountSelector                     uint32 = 0xd0a215f2
+)
+
+// stateManagerSignatures maps the selectors of the natively implemented
+// OVM_StateManager methods to their signatures
+var stateManagerSignatures = map[uint32]string{
+	ownerSelector:                                    "owner()",
+	setAccountNonceSelector:                          "setAccountNonce(address,uint256)",
+	getAccountNonceSelector:                          "getAccountNonce(address)",
+	getAccountEthAddressSelector:                     "getAccountEthAddress(address)",
+	getContractStorageSelector:                       "getContractStorage(address,bytes32)",
+	putContractStorageSelector:                       "putContractStorage(address,bytes32,bytes32)",
+	isAuthenticatedSelector:                          "isAuthenticated(address)",
+	hasAccountSelector:                               "hasAccount(address)",
+	hasEmptyAccountSelector:                          "hasEmptyAccount(address)",
+	hasContractStorageSelector:                       "hasContractStorage(address,bytes32)",
+	testAndSetAccountLoadedSelector:                  "testAndSetAccountLoaded(address)",
+	testAndSetAccountChangedSelector:                 "testAndSetAccountChanged(address)",
+	testAndSetContractStorageLoadedSelector:          "testAndSetContractStorageLoaded(address,bytes32)",
+	testAndSetContractStorageChangedSelector:         "testAndSetContractStorageChanged(address,bytes32)",
+	incrementTotalUncommittedAccountsSelector:        "incrementTotalUncommittedAccounts()",
+	incrementTotalUncommittedContractStorageSelector: "incrementTotalUncommittedContractStorage()",
+	initPendingAccountSelector:                       "initPendingAccount(address)",
+	commitPendingAccountSelector:                     "commitPendingAccount(address,address,bytes32)",
+}
+
+// stateManagerArgs are the ABI encoded arguments of a OVM_StateManager call.
+// Every argument of the natively implemented methods is a static type that
+// is encoded in a single word.
+type stateManagerArgs []byte
+
+// expect returns an error if there are less than n arguments
+func (args stateManagerArgs) expect(n int) error {
+	if len(args) < n*32 {
+		return fmt.Errorf("%w: have %d bytes, want %d", errShortStateManagerInput, len(args), n*32)
+	}
+	return nil
+}
+
+// address decodes the i-th argument as an address
+func (args stateManagerArgs) address(i int) common.Address {
+	return common.BytesToAddress(args[i*32+12 : i*32+32])
+}
+
+// hash decodes the i-th argument as a bytes32
+func (args stateManagerArgs) hash(i int) common.Hash {
+	return common.BytesToHash(args[i*32 : i*32+32])
+}
+
+// uint64 decodes the i-th argument as a uint256 that is truncated to 64 bits
+func (args stateManagerArgs) uint64(i int) uint64 {
+	return new(big.Int).SetBytes(args[i*32 : i*32+32]).Uint64()
+}
+
+// encodeBool returns the ABI encoding of a bool
+func encodeBool(b bool) []byte {
+	if b {
+		return common.CopyBytes(AbiBytesTrue)
+	}
+	return common.CopyBytes(AbiBytesFalse)
+}
+
+// encodeAddress returns the ABI encoding of an address
+func encodeAddress(address common.Address) []byte {
+	return common.LeftPadBytes(address.Bytes(), 32)
+}
+
+// encodeUint64 returns the ABI encoding of a uint256 holding n
+func encodeUint64(n uint64) []byte {
+	return common.LeftPadBytes(new(big.Int).SetUint64(n).Bytes(), 32)
+}
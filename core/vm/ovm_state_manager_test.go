@@ -1,16 +1,23 @@
 package vm
 
 import (
+	"bytes"
 	"crypto/rand"
+	"encoding/binary"
+	"errors"
 	"math/big"
 	"os"
 	"sort"
+	"strings"
 	"testing"
 
+	"github.com/MetisProtocol/l2geth/accounts/abi"
 	"github.com/MetisProtocol/l2geth/common"
 	"github.com/MetisProtocol/l2geth/core/types"
+	"github.com/MetisProtocol/l2geth/crypto"
 	"github.com/MetisProtocol/l2geth/diffdb"
 	"github.com/MetisProtocol/l2geth/params"
+	"github.com/MetisProtocol/l2geth/rollup/dump"
 )
 
 type TestData map[*big.Int]BlockData
@@ -42,16 +49,11 @@ func makeEnv(dbname string) (*diffdb.DiffDb, *EVM, TestData, *Contract) {
 }
 
 func TestEthCallNoop(t *testing.T) {
-	db, env, _, contract := makeEnv("test1")
+	db, env, _, _ := makeEnv("test1")
 	defer os.Remove("test1")
 	env.Context.EthCallSender = &common.Address{0}
 	env.Context.BlockNumber = big.NewInt(1)
-	args := map[string]interface{}{
-		"_contract": contract1,
-		"_key":      [32]uint8{1},
-		"_value":    [32]uint8{2},
-	}
-	putContractStorage(env, contract, args)
+	putContractStorage(env, contract1, common.Hash{1}, common.Hash{2})
 	diff, err := db.GetDiff(env.Context.BlockNumber)
 	if err != nil {
 		t.Fatal("Db call error", err)
@@ -135,15 +137,7 @@ func putTestData(t *testing.T, env *EVM, contract *Contract, blockNumber *big.In
 	env.Context.BlockNumber = blockNumber
 	for address, data := range blockData {
 		for _, contractData := range data {
-			args := map[string]interface{}{
-				"_contract": address,
-				"_key":      contractData.key,
-				"_value":    contractData.value,
-			}
-			_, err := putContractStorage(env, contract, args)
-			if err != nil {
-				t.Fatalf("Expected nil error, got %s", err)
-			}
+			putContractStorage(env, address, common.Hash(contractData.key), common.Hash(contractData.value))
 		}
 	}
 }
@@ -232,3 +226,144 @@ func (mock *mockDb) AddPreimage(common.Hash, []byte)                           {
 func (mock *mockDb) ForEachStorage(common.Address, func(common.Hash, common.Hash) bool) error {
 	return nil
 }
+
+// The state dump validation must require exactly the methods of the
+// OVM_StateManager that are implemented natively
+func TestStateManagerMethods(t *testing.T) {
+	if len(dump.StateManagerMethods) != len(stateManagerSignatures) {
+		t.Fatalf("method count mismatch: have %d, want %d", len(dump.StateManagerMethods), len(stateManagerSignatures))
+	}
+	names := make(map[string]bool)
+	for selector, signature := range stateManagerSignatures {
+		if have := binary.BigEndian.Uint32(crypto.Keccak256([]byte(signature))); have != selector {
+			t.Fatalf("selector mismatch for %s: have %#x, want %#x", signature, have, selector)
+		}
+		names[signature[:strings.Index(signature, "(")]] = true
+	}
+	for _, method := range dump.StateManagerMethods {
+		if !names[method] {
+			t.Fatalf("no native implementation of %s", method)
+		}
+	}
+}
+
+func TestCallStateManager(t *testing.T) {
+	_, env, _, contract := makeEnv("test3")
+	defer os.Remove("test3")
+	env.Context.EthCallSender = &common.Address{0}
+	env.Context.Origin = common.HexToAddress("0x1234")
+
+	tests := []struct {
+		input  []byte
+		output []byte
+		err    error
+	}{
+		{
+			input:  stateManagerInput(ownerSelector),
+			output: common.LeftPadBytes(env.Context.Origin.Bytes(), 32),
+		},
+		{
+			input:  stateManagerInput(getAccountEthAddressSelector, contract1.Hash()),
+			output: contract1.Hash().Bytes(),
+		},
+		{
+			input:  stateManagerInput(hasEmptyAccountSelector, contract1.Hash()),
+			output: AbiBytesTrue,
+		},
+		{
+			input:  stateManagerInput(getContractStorageSelector, contract1.Hash(), common.Hash{1}),
+			output: common.Hash{}.Bytes(),
+		},
+		{
+			input: stateManagerInput(commitPendingAccountSelector, contract1.Hash(), contract2.Hash(), common.Hash{}),
+		},
+		{
+			input: stateManagerInput(putContractStorageSelector, contract1.Hash(), common.Hash{1}),
+			err:   errShortStateManagerInput,
+		},
+		{
+			input: []byte{0x01, 0x02},
+			err:   errShortStateManagerInput,
+		},
+		{
+			input: stateManagerInput(0xdeadbeef),
+			err:   errUnknownStateManagerMethod,
+		},
+	}
+	for i, tt := range tests {
+		output, err := callStateManager(tt.input, env, contract)
+		if !errors.Is(err, tt.err) {
+			t.Fatalf("test %d: expected error %v, got %v", i, tt.err, err)
+		}
+		if !bytes.Equal(output, tt.output) {
+			t.Fatalf("test %d: expected output %x, got %x", i, tt.output, output)
+		}
+	}
+}
+
+// stateManagerInput returns the input of a call to the OVM_StateManager
+func stateManagerInput(selector uint32, args ...common.Hash) []byte {
+	input := make([]byte, 4, 4+32*len(args))
+	binary.BigEndian.PutUint32(input, selector)
+	for _, arg := range args {
+		input = append(input, arg.Bytes()...)
+	}
+	return input
+}
+
+// stateManagerABI is the part of the OVM_StateManager ABI that is benchmarked
+const stateManagerABI = `[
+	{"type":"function","name":"getContractStorage","inputs":[{"name":"_contract","type":"address"},{"name":"_key","type":"bytes32"}],"outputs":[{"name":"","type":"bytes32"}]},
+	{"type":"function","name":"putContractStorage","inputs":[{"name":"_contract","type":"address"},{"name":"_key","type":"bytes32"},{"name":"_value","type":"bytes32"}],"outputs":[]}
+]`
+
+func BenchmarkGetContractStorage(b *testing.B) {
+	benchmarkCallStateManager(b, stateManagerInput(getContractStorageSelector, contract1.Hash(), common.Hash{1}))
+}
+
+func BenchmarkPutContractStorage(b *testing.B) {
+	benchmarkCallStateManager(b, stateManagerInput(putContractStorageSelector, contract1.Hash(), common.Hash{1}, common.Hash{2}))
+}
+
+// The ABI benchmarks measure the method lookup and argument unpacking that
+// the native dispatch replaces
+func BenchmarkGetContractStorageABI(b *testing.B) {
+	benchmarkUnpackStateManager(b, stateManagerInput(getContractStorageSelector, contract1.Hash(), common.Hash{1}))
+}
+
+func BenchmarkPutContractStorageABI(b *testing.B) {
+	benchmarkUnpackStateManager(b, stateManagerInput(putContractStorageSelector, contract1.Hash(), common.Hash{1}, common.Hash{2}))
+}
+
+func benchmarkCallStateManager(b *testing.B, input []byte) {
+	_, env, _, contract := makeEnv("bench")
+	defer os.Remove("bench")
+	env.Context.EthCallSender = &common.Address{0}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := callStateManager(input, env, contract); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func benchmarkUnpackStateManager(b *testing.B, input []byte) {
+	parsed, err := abi.JSON(strings.NewReader(stateManagerABI))
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		method, err := parsed.MethodById(input)
+		if err != nil {
+			b.Fatal(err)
+		}
+		args := make(map[string]interface{})
+		if err := method.Inputs.UnpackIntoMap(args, input[4:]); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package diffdb
+
+import (
+	"math/big"
+
+	"github.com/MetisProtocol/l2geth/common"
+)
+
+type bufferedKey struct {
+	block   uint64
+	address common.Address
+	key     common.Hash
+}
+
+// A Buffer holds the diffs of a block that is still being built. The diffs
+// are only written to the DiffDb once the block is written to the chain, so
+// that execution that is abandoned never shows up in the diffs of a block.
+type Buffer struct {
+	keys    []bufferedKey // Keys in the order they were first touched
+	mutated map[bufferedKey]bool
+}
+
+// Creates an empty buffer.
+func NewBuffer() *Buffer {
+	return &Buffer{mutated: make(map[bufferedKey]bool)}
+}
+
+// Records the storage key of the address as touched in the block. A key stays
+// mutated once any write mutated it.
+func (b *Buffer) SetDiffKey(block *big.Int, address common.Address, key common.Hash, mutated bool) {
+	k := bufferedKey{block.Uint64(), address, key}
+	prev, ok := b.mutated[k]
+	if !ok {
+		b.keys = append(b.keys, k)
+	}
+	b.mutated[k] = prev || mutated
+}
+
+// Records that the account was modified in the block.
+func (b *Buffer) SetDiffAccount(block *big.Int, address common.Address) {
+	b.SetDiffKey(block, address, accountKey, true)
+}
+
+// Returns the number of buffered keys.
+func (b *Buffer) Len() int {
+	return len(b.keys)
+}
+
+// Returns an independent copy of the buffer.
+func (b *Buffer) Copy() *Buffer {
+	cpy := &Buffer{
+		keys:    make([]bufferedKey, len(b.keys)),
+		mutated: make(map[bufferedKey]bool, len(b.mutated)),
+	}
+	copy(cpy.keys, b.keys)
+	for k, mutated := range b.mutated {
+		cpy.mutated[k] = mutated
+	}
+	return cpy
+}
+
+// Writes the buffered diffs to the database and commits them.
+func (diff *DiffDb) Flush(b *Buffer) error {
+	for _, k := range b.keys {
+		if err := diff.SetDiffKey(new(big.Int).SetUint64(k.block), k.address, k.key, b.mutated[k]); err != nil {
+			return err
+		}
+	}
+	return diff.ForceCommit()
+}
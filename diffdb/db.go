@@ -9,8 +9,8 @@ import (
 )
 
 type Key struct {
-	Key     common.Hash
-	Mutated bool
+	Key     common.Hash `json:"key"`
+	Mutated bool        `json:"mutated"`
 }
 
 type Diff map[common.Address][]Key
@@ -38,7 +38,7 @@ INSERT INTO diffs
     (block, address, key, mutated)
     VALUES
     ($1, $2, $3, $4)
-ON CONFLICT DO NOTHING
+ON CONFLICT (block, address, key) DO UPDATE SET mutated = mutated OR excluded.mutated
 `
 var createStmt = `
 CREATE TABLE IF NOT EXISTS diffs (
@@ -52,8 +52,12 @@ CREATE TABLE IF NOT EXISTS diffs (
 var selectStmt = `
 SELECT * from diffs WHERE block = $1
 `
+var deleteStmt = `
+DELETE FROM diffs WHERE block > $1
+`
 
-/// Inserts a new row to the sqlite with the provided diff data.
+/// Inserts a new row to the sqlite with the provided diff data. A key that was
+/// already recorded for the block stays mutated once any write mutated it.
 func (diff *DiffDb) SetDiffKey(block *big.Int, address common.Address, key common.Hash, mutated bool) error {
 	// add 1 more insertion to the transaction
 	_, err := diff.stmt.Exec(block.Uint64(), address, key, mutated)
@@ -88,6 +92,16 @@ func (diff *DiffDb) ForceCommit() error {
 	return diff.resetTx()
 }
 
+/// Removes every row recorded for blocks above `block` and commits. This is
+/// used when the chain is rewound so that diffs of orphaned blocks are not
+/// served for the blocks that replace them.
+func (diff *DiffDb) DeleteDiffsAfter(block *big.Int) error {
+	if _, err := diff.tx.Exec(deleteStmt, block.Uint64()); err != nil {
+		return err
+	}
+	return diff.ForceCommit()
+}
+
 /// Gets all the rows for the matching block and converts them to a Diff map.
 func (diff *DiffDb) GetDiff(blockNum *big.Int) (Diff, error) {
 	// make the query
@@ -137,7 +151,11 @@ func (diff *DiffDb) resetTx() error {
 	return nil
 }
 
+/// Commits any pending insertions and closes the database.
 func (diff *DiffDb) Close() error {
+	if err := diff.tx.Commit(); err != nil {
+		return err
+	}
 	return diff.db.Close()
 }
 
@@ -49,3 +49,90 @@ func TestDiffDb(t *testing.T) {
 		t.Fatalf("Did not match mutated")
 	}
 }
+
+func TestDiffDbMutatedAndDelete(t *testing.T) {
+	db, err := NewDiffDb("./test_diff_delete.db", 1)
+	defer os.Remove("./test_diff_delete.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	addr := common.Address{0x1}
+	// a read followed by a write marks the key as mutated
+	if err := db.SetDiffKey(big.NewInt(1), addr, common.Hash{0x1}, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SetDiffKey(big.NewInt(1), addr, common.Hash{0x1}, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SetDiffAccount(big.NewInt(2), addr); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SetDiffKey(big.NewInt(3), addr, common.Hash{0x3}, true); err != nil {
+		t.Fatal(err)
+	}
+
+	diff, err := db.GetDiff(big.NewInt(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diff[addr]) != 1 || !diff[addr][0].Mutated {
+		t.Fatalf("Expected a single mutated key, got %v", diff[addr])
+	}
+
+	// rewinding to block 1 removes the diffs of the later blocks
+	if err := db.DeleteDiffsAfter(big.NewInt(1)); err != nil {
+		t.Fatal(err)
+	}
+	for _, block := range []int64{2, 3} {
+		diff, err := db.GetDiff(big.NewInt(block))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(diff) != 0 {
+			t.Fatalf("Expected no diff for block %d, got %v", block, diff)
+		}
+	}
+	diff, _ = db.GetDiff(big.NewInt(1))
+	if len(diff[addr]) != 1 {
+		t.Fatal("Expected the diff of block 1 to remain")
+	}
+}
+
+func TestDiffDbFlushBuffer(t *testing.T) {
+	db, err := NewDiffDb("./test_diff_buffer.db", 16)
+	defer os.Remove("./test_diff_buffer.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	addr := common.Address{0x1}
+	buffer := NewBuffer()
+	buffer.SetDiffKey(big.NewInt(1), addr, common.Hash{0x2}, false)
+	buffer.SetDiffKey(big.NewInt(1), addr, common.Hash{0x1}, true)
+	buffer.SetDiffKey(big.NewInt(1), addr, common.Hash{0x2}, true)
+
+	// the work on a copy that is abandoned is never written
+	abandoned := buffer.Copy()
+	abandoned.SetDiffKey(big.NewInt(1), addr, common.Hash{0x3}, true)
+	if buffer.Len() != 2 {
+		t.Fatalf("Expected the copy to be independent, got %d keys", buffer.Len())
+	}
+
+	if err := db.Flush(buffer); err != nil {
+		t.Fatal(err)
+	}
+	diff, err := db.GetDiff(big.NewInt(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Key{{common.Hash{0x2}, true}, {common.Hash{0x1}, true}}
+	if len(diff[addr]) != len(expected) {
+		t.Fatalf("Expected %d keys, got %v", len(expected), diff[addr])
+	}
+	for i := range expected {
+		if diff[addr][i] != expected[i] {
+			t.Fatal("Did not match", expected[i], "got", diff[addr][i])
+		}
+	}
+}
@@ -31,6 +31,7 @@ import (
 	"github.com/MetisProtocol/l2geth/core/state"
 	"github.com/MetisProtocol/l2geth/core/types"
 	"github.com/MetisProtocol/l2geth/core/vm"
+	"github.com/MetisProtocol/l2geth/diffdb"
 	"github.com/MetisProtocol/l2geth/eth/downloader"
 	"github.com/MetisProtocol/l2geth/eth/gasprice"
 	"github.com/MetisProtocol/l2geth/ethdb"
@@ -48,10 +49,14 @@ type EthAPIBackend struct {
 	rollupGpo       *gasprice.RollupOracle
 	verifier        bool
 	gasLimit        uint64
-	UsingOVM        bool
 	MaxCallDataSize int
 }
 
+// isOVM returns whether the OVM rules apply to the current block
+func (b *EthAPIBackend) isOVM() bool {
+	return b.eth.blockchain.Config().IsOVM(b.eth.blockchain.CurrentBlock().Number())
+}
+
 func (b *EthAPIBackend) IsVerifier() bool {
 	return b.verifier
 }
@@ -101,7 +106,7 @@ func (b *EthAPIBackend) SetHead(number uint64) {
 		log.Info("Cannot reset to genesis")
 		return
 	}
-	if !b.UsingOVM {
+	if !b.isOVM() {
 		b.eth.protocolManager.downloader.Cancel()
 	}
 	b.eth.blockchain.SetHead(number)
@@ -114,15 +119,14 @@ func (b *EthAPIBackend) SetHead(number uint64) {
 		return
 	}
 
-	tx := txs[0]
+	tx := txs[len(txs)-1]
 	blockNumber := tx.L1BlockNumber()
 	if blockNumber == nil {
 		log.Error("No L1BlockNumber found in transaction", "number", number)
 		return
 	}
 
-	b.eth.syncService.SetLatestL1Timestamp(tx.L1Timestamp())
-	b.eth.syncService.SetLatestL1BlockNumber(blockNumber.Uint64())
+	b.eth.syncService.SetLatestL1Context(blockNumber.Uint64(), tx.L1Timestamp())
 }
 
 func (b *EthAPIBackend) IngestTransactions(txs []*types.Transaction) error {
@@ -135,6 +139,10 @@ func (b *EthAPIBackend) IngestTransactions(txs []*types.Transaction) error {
 	return nil
 }
 
+func (b *EthAPIBackend) GetDiff(block *big.Int) (diffdb.Diff, error) {
+	return b.eth.blockchain.GetDiff(block)
+}
+
 func (b *EthAPIBackend) HeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Header, error) {
 	// Pending block is only known by the miner
 	if number == rpc.PendingBlockNumber {
@@ -301,7 +309,7 @@ func (b *EthAPIBackend) SubscribeLogsEvent(ch chan<- []*types.Log) event.Subscri
 // Transactions originating from the RPC endpoints are added to remotes so that
 // a lock can be used around the remotes for when the sequencer is reorganizing.
 func (b *EthAPIBackend) SendTx(ctx context.Context, signedTx *types.Transaction) error {
-	if b.UsingOVM {
+	if b.isOVM() {
 		to := signedTx.To()
 		if to != nil {
 			// Prevent QueueOriginSequencer transactions that are too large to
@@ -346,7 +354,10 @@ func (b *EthAPIBackend) GetPoolNonce(ctx context.Context, addr common.Address) (
 }
 
 func (b *EthAPIBackend) Stats() (pending int, queued int) {
-	return b.eth.txPool.Stats()
+	pending, queued = b.eth.txPool.Stats()
+	// Sequencer transactions that arrive ahead of their nonce are queued by
+	// the sync service rather than by the transaction pool
+	return pending, queued + b.eth.syncService.FutureTransactions()
 }
 
 func (b *EthAPIBackend) TxPoolContent() (map[common.Address]types.Transactions, map[common.Address]types.Transactions) {
@@ -385,6 +396,15 @@ func (b *EthAPIBackend) SetL2GasPrice(ctx context.Context, gasPrice *big.Int) er
 	return b.rollupGpo.SetL2GasPrice(gasPrice)
 }
 
+func (b *EthAPIBackend) FeeThresholds() (*big.Float, *big.Float, error) {
+	down, up := b.eth.syncService.FeeThresholds()
+	return down, up, nil
+}
+
+func (b *EthAPIBackend) GasPriceHistory(ctx context.Context, count int, newest uint64) ([]*rawdb.GasPriceSample, error) {
+	return b.rollupGpo.GasPriceHistory(count, newest), nil
+}
+
 func (b *EthAPIBackend) ChainDb() ethdb.Database {
 	return b.eth.ChainDb()
 }
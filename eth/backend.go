@@ -39,6 +39,7 @@ import (
 	"github.com/MetisProtocol/l2geth/core/rawdb"
 	"github.com/MetisProtocol/l2geth/core/types"
 	"github.com/MetisProtocol/l2geth/core/vm"
+	"github.com/MetisProtocol/l2geth/diffdb"
 	"github.com/MetisProtocol/l2geth/eth/downloader"
 	"github.com/MetisProtocol/l2geth/eth/filters"
 	"github.com/MetisProtocol/l2geth/eth/gasprice"
@@ -89,6 +90,8 @@ type Ethereum struct {
 	bloomRequests chan chan *bloombits.Retrieval // Channel receiving bloom data retrieval requests
 	bloomIndexer  *core.ChainIndexer             // Bloom indexer operating during block imports
 
+	messageIndexer *core.ChainIndexer // Cross domain message indexer of OVM chains, nil otherwise
+
 	APIBackend *EthAPIBackend
 
 	miner     *miner.Miner
@@ -139,7 +142,7 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 	if err != nil {
 		return nil, err
 	}
-	chainConfig, genesisHash, genesisErr := core.SetupGenesisBlockWithOverride(chainDb, config.Genesis, config.OverrideIstanbul, config.OverrideMuirGlacier)
+	chainConfig, genesisHash, genesisErr := core.SetupGenesisBlockWithOverride(chainDb, config.Genesis, config.OverrideIstanbul, config.OverrideMuirGlacier, config.Rollup.OVMBlock())
 	if _, ok := genesisErr.(*params.ConfigCompatError); genesisErr != nil && !ok {
 		return nil, genesisErr
 	}
@@ -179,6 +182,8 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 			EnablePreimageRecording: config.EnablePreimageRecording,
 			EWASMInterpreter:        config.EWASMInterpreter,
 			EVMInterpreter:          config.EVMInterpreter,
+
+			EnableArbitraryContractDeployment: config.Rollup.EnableArbitraryContractDeployment,
 		}
 		cacheConfig = &core.CacheConfig{
 			TrieCleanLimit:      config.TrieCleanCache,
@@ -189,7 +194,15 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 		}
 	)
 
-	eth.blockchain, err = core.NewBlockChain(chainDb, cacheConfig, chainConfig, eth.engine, vmConfig, eth.shouldPreserve)
+	if config.Rollup.DiffDbCache > 0 {
+		var diff *diffdb.DiffDb
+		if diff, err = diffdb.NewDiffDb(ctx.ResolvePath("diffdb"), config.Rollup.DiffDbCache); err != nil {
+			return nil, fmt.Errorf("Cannot open diff db: %w", err)
+		}
+		eth.blockchain, err = core.NewBlockChainWithDiffDb(chainDb, diff, cacheConfig, chainConfig, eth.engine, vmConfig, eth.shouldPreserve)
+	} else {
+		eth.blockchain, err = core.NewBlockChain(chainDb, cacheConfig, chainConfig, eth.engine, vmConfig, eth.shouldPreserve)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -200,6 +213,16 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 		rawdb.WriteChainConfig(chainDb, genesisHash, chainConfig)
 	}
 	eth.bloomIndexer.Start(eth.blockchain)
+	if chainConfig.OVMBlock != nil && chainConfig.StateDump != nil {
+		if messenger, ok := chainConfig.StateDump.Accounts["OVM_L2CrossDomainMessenger"]; ok {
+			if eth.messageIndexer, err = NewMessageIndexer(chainDb, chainConfig, messenger); err != nil {
+				return nil, err
+			}
+			eth.messageIndexer.Start(eth.blockchain)
+		} else {
+			log.Warn("Not indexing cross domain messages, the state dump has no messenger")
+		}
+	}
 
 	if config.TxPool.Journal != "" {
 		config.TxPool.Journal = ctx.ResolvePath(config.TxPool.Journal)
@@ -221,24 +244,27 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 		return nil, err
 	}
 	eth.miner = miner.New(eth, &config.Miner, chainConfig, eth.EventMux(), eth.engine, eth.isLocalBlock)
-	eth.miner.SetExtra(makeExtraData(config.Miner.ExtraData))
+	// The OVM may be switched on after genesis, the chain is an OVM chain as
+	// soon as it has a switch block
+	usingOVM := chainConfig.OVMBlock != nil
+	eth.miner.SetExtra(makeExtraData(config.Miner.ExtraData, usingOVM))
 
-	log.Info("Backend Config", "max-calldata-size", config.Rollup.MaxCallDataSize, "gas-limit", config.Rollup.GasLimit, "is-verifier", config.Rollup.IsVerifier, "using-ovm", vm.UsingOVM)
-	eth.APIBackend = &EthAPIBackend{ctx.ExtRPCEnabled(), eth, nil, nil, config.Rollup.IsVerifier, config.Rollup.GasLimit, vm.UsingOVM, config.Rollup.MaxCallDataSize}
+	log.Info("Backend Config", "max-calldata-size", config.Rollup.MaxCallDataSize, "gas-limit", config.Rollup.GasLimit, "is-verifier", config.Rollup.IsVerifier, "using-ovm", usingOVM, "ovm-block", chainConfig.OVMBlock)
+	eth.APIBackend = &EthAPIBackend{ctx.ExtRPCEnabled(), eth, nil, nil, config.Rollup.IsVerifier, config.Rollup.GasLimit, config.Rollup.MaxCallDataSize}
 	gpoParams := config.GPO
 	if gpoParams.Default == nil {
 		gpoParams.Default = config.Miner.GasPrice
 	}
 	eth.APIBackend.gpo = gasprice.NewOracle(eth.APIBackend, gpoParams)
 	// create the Rollup GPO and allow the API backend and the sync service to access it
-	rollupGpo := gasprice.NewRollupOracle()
+	rollupGpo := gasprice.NewRollupOracle(chainDb)
 	eth.APIBackend.rollupGpo = rollupGpo
 	eth.syncService.RollupGpo = rollupGpo
 	return eth, nil
 }
 
-func makeExtraData(extra []byte) []byte {
-	if vm.UsingOVM {
+func makeExtraData(extra []byte, usingOVM bool) []byte {
+	if usingOVM {
 		// Make the extradata deterministic
 		extra, _ = rlp.EncodeToBytes([]interface{}{
 			uint(params.VersionMajor<<16 | params.VersionMinor<<8 | params.VersionPatch),
@@ -566,6 +592,9 @@ func (s *Ethereum) Stop() error {
 	s.protocolManager.Stop()
 
 	s.bloomIndexer.Close()
+	if s.messageIndexer != nil {
+		s.messageIndexer.Close()
+	}
 	s.blockchain.Stop()
 	s.engine.Close()
 	s.txPool.Stop()
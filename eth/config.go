@@ -80,7 +80,13 @@ var DefaultConfig = Config{
 		// accepted. This option applies to the transaction calldata, so there
 		// is additional overhead that is unaccounted. Round down to 127000 for
 		// safety.
-		MaxCallDataSize: 127000,
+		MaxCallDataSize:       127000,
+		PrefetchWorkers:       4,
+		PrefetchDepth:         64,
+		FutureTxLifetime:      time.Minute,
+		MaxFutureTxsPerSender: 16,
+		MaxFutureTxs:          1024,
+		OrderingPolicy:        rollup.OrderingFIFO,
 	},
 }
 
@@ -4,26 +4,77 @@ import (
 	"context"
 	"math/big"
 	"sync"
+	"time"
 
+	"github.com/MetisProtocol/l2geth/core/rawdb"
+	"github.com/MetisProtocol/l2geth/ethdb"
 	"github.com/MetisProtocol/l2geth/log"
 )
 
-// RollupOracle holds the L1 and L2 gas prices for fee calculation
+// DefaultRollupHistorySize is the number of gas price samples that are kept
+// by the RollupOracle
+const DefaultRollupHistorySize = 1024
+
+// RollupOracle holds the L1 and L2 gas prices for fee calculation along with
+// a history of timestamped samples of both prices
 type RollupOracle struct {
 	l1GasPrice     *big.Int
 	l2GasPrice     *big.Int
 	l1GasPriceLock sync.RWMutex
 	l2GasPriceLock sync.RWMutex
+
+	// history is a ring buffer of the latest samples, the sample with the
+	// number n lives at n % len(history). Samples are persisted to db when
+	// it is set so that the history survives restarts. The historyLock is
+	// held while a price is set, so that every sample holds a pair of prices
+	// that were in effect together.
+	db          ethdb.KeyValueStore
+	history     []*rawdb.GasPriceSample
+	next        uint64
+	historyLock sync.RWMutex
+	now         func() time.Time
+}
+
+// NewRollupOracle returns an initialized RollupOracle. The gas price history
+// is loaded from the database if one is passed in.
+func NewRollupOracle(db ethdb.KeyValueStore) *RollupOracle {
+	return newRollupOracle(db, DefaultRollupHistorySize)
 }
 
-// NewRollupOracle returns an initialized RollupOracle
-func NewRollupOracle() *RollupOracle {
-	return &RollupOracle{
+func newRollupOracle(db ethdb.KeyValueStore, size int) *RollupOracle {
+	gpo := &RollupOracle{
 		l1GasPrice:     new(big.Int),
 		l2GasPrice:     new(big.Int),
 		l1GasPriceLock: sync.RWMutex{},
 		l2GasPriceLock: sync.RWMutex{},
+		db:             db,
+		history:        make([]*rawdb.GasPriceSample, size),
+		now:            time.Now,
+	}
+	gpo.loadHistory()
+	return gpo
+}
+
+// loadHistory reads the latest samples from the database into the ring
+// buffer
+func (gpo *RollupOracle) loadHistory() {
+	if gpo.db == nil {
+		return
+	}
+	head := rawdb.ReadHeadGasPriceSample(gpo.db)
+	if head == nil {
+		return
+	}
+	gpo.next = *head + 1
+	size := uint64(len(gpo.history))
+	start := uint64(0)
+	if gpo.next > size {
+		start = gpo.next - size
 	}
+	for i := start; i < gpo.next; i++ {
+		gpo.history[i%size] = rawdb.ReadGasPriceSample(gpo.db, i)
+	}
+	log.Info("Loaded gas price history", "samples", gpo.next-start)
 }
 
 // SuggestL1GasPrice returns the gas price which should be charged per byte of published
@@ -36,10 +87,14 @@ func (gpo *RollupOracle) SuggestL1GasPrice(ctx context.Context) (*big.Int, error
 
 // SetL1GasPrice returns the current L1 gas price
 func (gpo *RollupOracle) SetL1GasPrice(gasPrice *big.Int) error {
+	gpo.historyLock.Lock()
+	defer gpo.historyLock.Unlock()
+
 	gpo.l1GasPriceLock.Lock()
-	defer gpo.l1GasPriceLock.Unlock()
 	gpo.l1GasPrice = gasPrice
-	log.Info("Set L1 Gas Price", "gasprice", gpo.l1GasPrice)
+	gpo.l1GasPriceLock.Unlock()
+	log.Info("Set L1 Gas Price", "gasprice", gasPrice)
+	gpo.recordSample()
 	return nil
 }
 
@@ -53,9 +108,79 @@ func (gpo *RollupOracle) SuggestL2GasPrice(ctx context.Context) (*big.Int, error
 
 // SetL2GasPrice returns the current L2 gas price
 func (gpo *RollupOracle) SetL2GasPrice(gasPrice *big.Int) error {
+	gpo.historyLock.Lock()
+	defer gpo.historyLock.Unlock()
+
 	gpo.l2GasPriceLock.Lock()
-	defer gpo.l2GasPriceLock.Unlock()
 	gpo.l2GasPrice = gasPrice
-	log.Info("Set L2 Gas Price", "gasprice", gpo.l2GasPrice)
+	gpo.l2GasPriceLock.Unlock()
+	log.Info("Set L2 Gas Price", "gasprice", gasPrice)
+	gpo.recordSample()
 	return nil
 }
+
+// recordSample adds the current L1 and L2 gas prices to the history when
+// either of them changed since the latest sample. The oldest sample is dropped
+// once the ring buffer is full. It must be called with the historyLock held.
+func (gpo *RollupOracle) recordSample() {
+	size := uint64(len(gpo.history))
+	if size == 0 {
+		return
+	}
+	l1GasPrice, _ := gpo.SuggestL1GasPrice(context.Background())
+	l2GasPrice, _ := gpo.SuggestL2GasPrice(context.Background())
+	if gpo.next > 0 {
+		latest := gpo.history[(gpo.next-1)%size]
+		if latest != nil && latest.L1GasPrice.Cmp(l1GasPrice) == 0 && latest.L2GasPrice.Cmp(l2GasPrice) == 0 {
+			return
+		}
+	}
+	sample := &rawdb.GasPriceSample{
+		Timestamp:  uint64(gpo.now().Unix()),
+		L1GasPrice: new(big.Int).Set(l1GasPrice),
+		L2GasPrice: new(big.Int).Set(l2GasPrice),
+	}
+	number := gpo.next
+	gpo.history[number%size] = sample
+	gpo.next++
+	if gpo.db != nil {
+		batch := gpo.db.NewBatch()
+		rawdb.WriteGasPriceSample(batch, number, sample)
+		if number >= size {
+			rawdb.DeleteGasPriceSample(batch, number-size)
+		}
+		rawdb.WriteHeadGasPriceSample(batch, number)
+		if err := batch.Write(); err != nil {
+			log.Error("Cannot write gas price sample", "number", number, "err", err)
+		}
+	}
+}
+
+// GasPriceHistory returns up to count of the latest gas price samples that
+// were taken at or before the newest timestamp, ordered from oldest to
+// newest. A newest timestamp of zero starts from the latest sample.
+func (gpo *RollupOracle) GasPriceHistory(count int, newest uint64) []*rawdb.GasPriceSample {
+	gpo.historyLock.RLock()
+	defer gpo.historyLock.RUnlock()
+
+	var samples []*rawdb.GasPriceSample
+	size := uint64(len(gpo.history))
+	for i := gpo.next; i > 0 && len(samples) < count; i-- {
+		if gpo.next-i >= size {
+			break
+		}
+		sample := gpo.history[(i-1)%size]
+		if sample == nil {
+			break
+		}
+		if newest != 0 && sample.Timestamp > newest {
+			continue
+		}
+		samples = append(samples, sample)
+	}
+	// Reverse the samples so that the oldest one comes first
+	for i, j := 0, len(samples)-1; i < j; i, j = i+1, j-1 {
+		samples[i], samples[j] = samples[j], samples[i]
+	}
+	return samples
+}
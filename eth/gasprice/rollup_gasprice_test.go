@@ -0,0 +1,63 @@
+package gasprice
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/MetisProtocol/l2geth/core/rawdb"
+)
+
+func TestRollupGasPriceHistory(t *testing.T) {
+	db := rawdb.NewMemoryDatabase()
+	gpo := newRollupOracle(db, 4)
+	timestamp := int64(1000)
+	gpo.now = func() time.Time {
+		return time.Unix(timestamp, 0)
+	}
+	if samples := gpo.GasPriceHistory(10, 0); len(samples) != 0 {
+		t.Fatalf("unexpected samples: %d", len(samples))
+	}
+	// Record more samples than fit into the history
+	for i := int64(1); i <= 6; i++ {
+		timestamp = 1000 + i
+		gpo.SetL1GasPrice(big.NewInt(i * 10))
+	}
+	gpo.SetL2GasPrice(big.NewInt(1))
+
+	// Prices that did not change are not recorded again
+	timestamp = 1010
+	gpo.SetL1GasPrice(big.NewInt(60))
+	gpo.SetL2GasPrice(big.NewInt(1))
+
+	samples := gpo.GasPriceHistory(10, 0)
+	if len(samples) != 4 {
+		t.Fatalf("expected 4 samples, got %d", len(samples))
+	}
+	for i, l1 := range []int64{40, 50, 60, 60} {
+		if samples[i].L1GasPrice.Int64() != l1 {
+			t.Fatalf("sample %d: expected L1 gas price %d, got %d", i, l1, samples[i].L1GasPrice)
+		}
+	}
+	if samples[3].L2GasPrice.Int64() != 1 || samples[2].L2GasPrice.Int64() != 0 {
+		t.Fatal("unexpected L2 gas prices")
+	}
+	if samples[3].Timestamp != 1006 {
+		t.Fatalf("unexpected timestamp of the latest sample: %d", samples[3].Timestamp)
+	}
+	// Only samples up to the newest timestamp are returned
+	samples = gpo.GasPriceHistory(2, 1005)
+	if len(samples) != 2 || samples[0].Timestamp != 1004 || samples[1].Timestamp != 1005 {
+		t.Fatalf("unexpected samples: %v", samples)
+	}
+
+	// The history survives a restart and evicted samples are removed
+	gpo = newRollupOracle(db, 4)
+	samples = gpo.GasPriceHistory(10, 0)
+	if len(samples) != 4 || samples[0].L1GasPrice.Int64() != 40 || samples[3].L2GasPrice.Int64() != 1 {
+		t.Fatalf("unexpected samples after restart: %v", samples)
+	}
+	if rawdb.ReadGasPriceSample(db, 2) != nil {
+		t.Fatal("evicted sample not deleted")
+	}
+}
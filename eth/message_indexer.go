@@ -0,0 +1,288 @@
+package eth
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/MetisProtocol/l2geth/accounts/abi"
+	"github.com/MetisProtocol/l2geth/common"
+	"github.com/MetisProtocol/l2geth/core"
+	"github.com/MetisProtocol/l2geth/core/rawdb"
+	"github.com/MetisProtocol/l2geth/core/types"
+	"github.com/MetisProtocol/l2geth/crypto"
+	"github.com/MetisProtocol/l2geth/ethdb"
+	"github.com/MetisProtocol/l2geth/log"
+	"github.com/MetisProtocol/l2geth/params"
+	"github.com/MetisProtocol/l2geth/rollup/dump"
+)
+
+const (
+	// messageIndexSectionSize is the number of blocks in a section of the cross
+	// domain message index. Every block is a section of its own so that
+	// messages are indexed as soon as their block is imported.
+	messageIndexSectionSize = 1
+
+	// messageIndexConfirms is the number of confirmations before a block is
+	// indexed. Reorgs roll the indexed messages of the affected blocks back.
+	messageIndexConfirms = 0
+)
+
+var errNotRelayMessage = errors.New("not a relayMessage call")
+
+// MessageIndexer implements a core.ChainIndexer, indexing the messages of the
+// OVM_L2CrossDomainMessenger by their hash, sender and target. L1 to L2
+// messages are indexed from the L1 to L2 transactions that relay them and L2
+// to L1 messages from the SentMessage events of the messenger. The method and
+// events of the messenger are taken from its ABI in the state dump.
+type MessageIndexer struct {
+	db        ethdb.Database      // database instance to write index data into
+	config    *params.ChainConfig // chain config to skip the blocks before the OVM switch block
+	messenger common.Address      // address of the OVM_L2CrossDomainMessenger
+	batch     ethdb.Batch         // batch of the section that is being processed
+
+	relayMessage abi.Method // method that L1 to L2 transactions call
+	sentMessage  abi.Event  // event of L2 to L1 messages, its data is the relayMessage calldata
+
+	// relayedMessage and failedRelayedMessage are the topics of the events
+	// that are emitted with the hash of a relayed L1 to L2 message, the zero
+	// hash if the messenger has no such event
+	relayedMessage       common.Hash
+	failedRelayedMessage common.Hash
+}
+
+// NewMessageIndexer returns a chain indexer that indexes the cross domain
+// messages of the canonical chain.
+func NewMessageIndexer(db ethdb.Database, config *params.ChainConfig, messenger dump.OvmDumpAccount) (*core.ChainIndexer, error) {
+	backend, err := newMessageIndexer(db, config, messenger)
+	if err != nil {
+		return nil, err
+	}
+	table := rawdb.NewTable(db, string(rawdb.MessageIndexPrefix))
+
+	return core.NewChainIndexer(db, table, backend, messageIndexSectionSize, messageIndexConfirms, 0, "messages"), nil
+}
+
+// newMessageIndexer creates the indexer backend for the messenger account of
+// the state dump
+func newMessageIndexer(db ethdb.Database, config *params.ChainConfig, messenger dump.OvmDumpAccount) (*MessageIndexer, error) {
+	relayMessage, ok := messenger.ABI.Methods["relayMessage"]
+	if !ok {
+		return nil, errors.New("messenger ABI has no relayMessage method")
+	}
+	sentMessage, ok := messenger.ABI.Events["SentMessage"]
+	if !ok {
+		return nil, errors.New("messenger ABI has no SentMessage event")
+	}
+	m := &MessageIndexer{
+		db:           db,
+		config:       config,
+		messenger:    messenger.Address,
+		relayMessage: relayMessage,
+		sentMessage:  sentMessage,
+	}
+	if event, ok := messenger.ABI.Events["RelayedMessage"]; ok {
+		m.relayedMessage = event.ID()
+	}
+	if event, ok := messenger.ABI.Events["FailedRelayedMessage"]; ok {
+		m.failedRelayedMessage = event.ID()
+	}
+	return m, nil
+}
+
+// Reset implements core.ChainIndexerBackend, starting a new section and
+// removing the messages that were indexed from its blocks and from every
+// block after it before a reorg, so that no message of a rewound block is
+// served until its height is indexed again. The relays of replayed messages
+// from those blocks are dropped and the earlier relay is restored.
+func (m *MessageIndexer) Reset(ctx context.Context, section uint64, lastSectionHead common.Hash) error {
+	m.batch = m.db.NewBatch()
+	start := section * messageIndexSectionSize
+	for _, number := range rawdb.ReadMessageBlockNumbers(m.db, start) {
+		for _, hash := range rawdb.ReadBlockMessageHashes(m.db, number) {
+			message := rawdb.ReadCrossDomainMessage(m.db, hash)
+			if message == nil || message.BlockNumber < start {
+				continue
+			}
+			rawdb.DeleteCrossDomainMessage(m.batch, hash, message)
+			if prev := relayBefore(message.Replaced, start); prev != nil {
+				rawdb.WriteCrossDomainMessage(m.batch, hash, prev)
+			}
+		}
+		rawdb.DeleteBlockMessageHashes(m.batch, number)
+	}
+	return nil
+}
+
+// Process implements core.ChainIndexerBackend, indexing the cross domain
+// messages of the block.
+func (m *MessageIndexer) Process(ctx context.Context, header *types.Header) error {
+	if !m.config.IsOVM(header.Number) {
+		return nil
+	}
+	number, hash := header.Number.Uint64(), header.Hash()
+	block := rawdb.ReadBlock(m.db, hash, number)
+	if block == nil {
+		return fmt.Errorf("block #%d [%x…] not found", number, hash[:4])
+	}
+	receipts := rawdb.ReadRawReceipts(m.db, hash, number)
+	if len(receipts) != len(block.Transactions()) {
+		return fmt.Errorf("receipts of block #%d [%x…] not found", number, hash[:4])
+	}
+	var (
+		hashes  []common.Hash
+		indexed = make(map[common.Hash]*rawdb.CrossDomainMessage)
+	)
+	for i, tx := range block.Transactions() {
+		txHashes, messages := m.messages(tx, receipts[i])
+		for _, messageHash := range txHashes {
+			message := messages[messageHash]
+			message.BlockNumber, message.TxHash = number, tx.Hash()
+			// A replayed L1 to L2 message replaces the earlier relay, which
+			// is kept to be restored if the replay is reorged out
+			prev, ok := indexed[messageHash]
+			if !ok {
+				prev = rawdb.ReadCrossDomainMessage(m.db, messageHash)
+				hashes = append(hashes, messageHash)
+			}
+			if prev != nil {
+				rawdb.DeleteCrossDomainMessage(m.batch, messageHash, prev)
+				message.Replaced = relayBefore(prev, number+1)
+			}
+			rawdb.WriteCrossDomainMessage(m.batch, messageHash, message)
+			indexed[messageHash] = message
+		}
+	}
+	if len(hashes) > 0 {
+		rawdb.WriteBlockMessageHashes(m.batch, number, hashes)
+		log.Debug("Indexed cross domain messages", "number", number, "count", len(hashes))
+	}
+	return nil
+}
+
+// relayBefore returns the latest relay of a message that was indexed from a
+// block before the number, skipping the relays from later blocks that were
+// reorged out
+func relayBefore(message *rawdb.CrossDomainMessage, number uint64) *rawdb.CrossDomainMessage {
+	for message != nil && message.BlockNumber >= number {
+		message = message.Replaced
+	}
+	return message
+}
+
+// Commit implements core.ChainIndexerBackend, writing out the messages of the
+// section.
+func (m *MessageIndexer) Commit() error {
+	return m.batch.Write()
+}
+
+// messages returns the hashes of the cross domain messages of a transaction in
+// the order that they were found along with the messages by their hash
+func (m *MessageIndexer) messages(tx *types.Transaction, receipt *types.Receipt) ([]common.Hash, map[common.Hash]*rawdb.CrossDomainMessage) {
+	var (
+		hashes   []common.Hash
+		messages = make(map[common.Hash]*rawdb.CrossDomainMessage)
+	)
+	add := func(hash common.Hash, message *rawdb.CrossDomainMessage) {
+		if _, ok := messages[hash]; !ok {
+			hashes = append(hashes, hash)
+		}
+		messages[hash] = message
+	}
+
+	// L1 to L2 transactions call relayMessage on the messenger
+	if meta := tx.GetMeta(); meta != nil && meta.QueueOrigin == types.QueueOriginL1ToL2 && tx.To() != nil && *tx.To() == m.messenger {
+		if message, err := m.decodeRelayMessage(tx.Data()); err != nil {
+			log.Debug("Skipping undecodable L1 to L2 message", "tx", tx.Hash(), "err", err)
+		} else {
+			message.Direction = rawdb.MessageL1ToL2
+			message.Status = rawdb.MessageFailed
+			add(crypto.Keccak256Hash(tx.Data()), message)
+		}
+	}
+	for _, l := range receipt.Logs {
+		if l.Address != m.messenger || len(l.Topics) == 0 {
+			continue
+		}
+		switch topic := l.Topics[0]; {
+		case topic == m.sentMessage.ID():
+			data, err := m.sentMessage.Inputs.NonIndexed().UnpackValues(l.Data)
+			if err != nil {
+				log.Debug("Skipping undecodable SentMessage event", "tx", tx.Hash(), "err", err)
+				continue
+			}
+			calldata, ok := data[0].([]byte)
+			if !ok {
+				log.Debug("Skipping SentMessage event without calldata", "tx", tx.Hash())
+				continue
+			}
+			message, err := m.decodeRelayMessage(calldata)
+			if err != nil {
+				log.Debug("Skipping undecodable L2 to L1 message", "tx", tx.Hash(), "err", err)
+				continue
+			}
+			message.Direction = rawdb.MessageL2ToL1
+			message.Status = rawdb.MessageSent
+			add(crypto.Keccak256Hash(calldata), message)
+
+		case topic != (common.Hash{}) && (topic == m.relayedMessage || topic == m.failedRelayedMessage):
+			if message := messages[eventMessageHash(l)]; message != nil && message.Direction == rawdb.MessageL1ToL2 {
+				message.Status = rawdb.MessageFailed
+				if topic == m.relayedMessage {
+					message.Status = rawdb.MessageRelayed
+				}
+			}
+		}
+	}
+	// The message can't have been relayed if the transaction failed
+	if receipt.Status == types.ReceiptStatusFailed {
+		for _, message := range messages {
+			if message.Direction == rawdb.MessageL1ToL2 {
+				message.Status = rawdb.MessageFailed
+			}
+		}
+	}
+	return hashes, messages
+}
+
+// eventMessageHash returns the message hash of a RelayedMessage or
+// FailedRelayedMessage event, which is either indexed or the event data
+func eventMessageHash(l *types.Log) common.Hash {
+	if len(l.Topics) > 1 {
+		return l.Topics[1]
+	}
+	if len(l.Data) >= common.HashLength {
+		return common.BytesToHash(l.Data[:common.HashLength])
+	}
+	return common.Hash{}
+}
+
+// decodeRelayMessage decodes the relayMessage calldata of a cross domain
+// message, its leading arguments are the target, sender, message and nonce
+func (m *MessageIndexer) decodeRelayMessage(calldata []byte) (*rawdb.CrossDomainMessage, error) {
+	if len(calldata) < 4 || !bytes.Equal(calldata[:4], m.relayMessage.ID()) {
+		return nil, errNotRelayMessage
+	}
+	args, err := m.relayMessage.Inputs.UnpackValues(calldata[4:])
+	if err != nil {
+		return nil, err
+	}
+	if len(args) < 4 {
+		return nil, fmt.Errorf("relayMessage has %d arguments", len(args))
+	}
+	target, ok1 := args[0].(common.Address)
+	sender, ok2 := args[1].(common.Address)
+	message, ok3 := args[2].([]byte)
+	nonce, ok4 := args[3].(*big.Int)
+	if !ok1 || !ok2 || !ok3 || !ok4 {
+		return nil, fmt.Errorf("unexpected relayMessage arguments: %s", m.relayMessage.Sig())
+	}
+	return &rawdb.CrossDomainMessage{
+		Target:  target,
+		Sender:  sender,
+		Message: message,
+		Nonce:   nonce,
+	}, nil
+}
@@ -0,0 +1,227 @@
+package eth
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/MetisProtocol/l2geth/accounts/abi"
+	"github.com/MetisProtocol/l2geth/common"
+	"github.com/MetisProtocol/l2geth/core/rawdb"
+	"github.com/MetisProtocol/l2geth/core/types"
+	"github.com/MetisProtocol/l2geth/crypto"
+	"github.com/MetisProtocol/l2geth/ethdb"
+	"github.com/MetisProtocol/l2geth/params"
+	"github.com/MetisProtocol/l2geth/rollup/dump"
+)
+
+// messengerABI is the part of the OVM_L2CrossDomainMessenger ABI that is
+// indexed
+const messengerABI = `[
+	{"type":"function","name":"relayMessage","inputs":[{"name":"_target","type":"address"},{"name":"_sender","type":"address"},{"name":"_message","type":"bytes"},{"name":"_messageNonce","type":"uint256"}],"outputs":[]},
+	{"type":"event","name":"SentMessage","inputs":[{"name":"message","type":"bytes","indexed":false}]},
+	{"type":"event","name":"RelayedMessage","inputs":[{"name":"msgHash","type":"bytes32","indexed":false}]},
+	{"type":"event","name":"FailedRelayedMessage","inputs":[{"name":"msgHash","type":"bytes32","indexed":false}]}
+]`
+
+// newTestMessageIndexer creates an indexer backend for a messenger with the
+// test ABI on a chain that runs the OVM from genesis
+func newTestMessageIndexer(t *testing.T, db ethdb.Database, messenger common.Address) *MessageIndexer {
+	parsed, err := abi.JSON(strings.NewReader(messengerABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := &params.ChainConfig{OVMBlock: big.NewInt(0)}
+	indexer, err := newMessageIndexer(db, config, dump.OvmDumpAccount{Address: messenger, ABI: parsed})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return indexer
+}
+
+// relayMessageCalldata returns the relayMessage calldata of a cross domain message
+func relayMessageCalldata(t *testing.T, indexer *MessageIndexer, target, sender common.Address, message []byte, nonce int64) []byte {
+	args, err := indexer.relayMessage.Inputs.Pack(target, sender, message, big.NewInt(nonce))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return append(indexer.relayMessage.ID(), args...)
+}
+
+// processMessageBlock indexes the block as the chain indexer does after
+// resetting its section
+func processMessageBlock(t *testing.T, indexer *MessageIndexer, block *types.Block) {
+	t.Helper()
+	if err := indexer.Reset(context.Background(), block.NumberU64(), block.ParentHash()); err != nil {
+		t.Fatal(err)
+	}
+	if err := indexer.Process(context.Background(), block.Header()); err != nil {
+		t.Fatal(err)
+	}
+	if err := indexer.Commit(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// writeMessageBlock stores a block with the transactions and their receipts
+func writeMessageBlock(db ethdb.Database, number int64, extra []byte, txs []*types.Transaction, receipts []*types.Receipt) *types.Block {
+	header := &types.Header{Number: big.NewInt(number), Extra: extra}
+	block := types.NewBlock(header, txs, nil, receipts)
+	rawdb.WriteBlock(db, block)
+	rawdb.WriteReceipts(db, block.Hash(), block.NumberU64(), receipts)
+	for i, tx := range txs {
+		rawdb.WriteTransactionMetaAt(db, block.NumberU64(), uint64(i), tx.GetMeta())
+	}
+	return block
+}
+
+func TestMessageIndexer(t *testing.T) {
+	var (
+		db        = rawdb.NewMemoryDatabase()
+		messenger = common.HexToAddress("0x4200000000000000000000000000000000000007")
+		indexer   = newTestMessageIndexer(t, db, messenger)
+		l1Sender  = common.HexToAddress("0x1111")
+		l2Target  = common.HexToAddress("0x2222")
+		l2Sender  = common.HexToAddress("0x3333")
+		l1Target  = common.HexToAddress("0x4444")
+
+		depositData    = relayMessageCalldata(t, indexer, l2Target, l1Sender, []byte{0x01}, 1)
+		depositHash    = crypto.Keccak256Hash(depositData)
+		withdrawalData = relayMessageCalldata(t, indexer, l1Target, l2Sender, []byte{0x02}, 2)
+		withdrawalHash = crypto.Keccak256Hash(withdrawalData)
+	)
+	deposit := types.NewTransaction(0, messenger, new(big.Int), 1000000, new(big.Int), depositData)
+	deposit.SetTransactionMeta(types.NewTransactionMeta(big.NewInt(1), 0, &l1Sender, types.QueueOriginL1ToL2, nil, nil, nil))
+	withdrawal := types.NewTransaction(0, common.HexToAddress("0x5555"), new(big.Int), 1000000, new(big.Int), nil)
+	withdrawal.SetTransactionMeta(types.NewTransactionMeta(big.NewInt(1), 0, nil, types.QueueOriginSequencer, nil, nil, nil))
+
+	sentData, err := indexer.sentMessage.Inputs.NonIndexed().Pack(withdrawalData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	receipts := []*types.Receipt{
+		{Status: types.ReceiptStatusSuccessful, Logs: []*types.Log{{Address: messenger, Topics: []common.Hash{indexer.relayedMessage}, Data: depositHash.Bytes()}}},
+		{Status: types.ReceiptStatusSuccessful, Logs: []*types.Log{{Address: messenger, Topics: []common.Hash{indexer.sentMessage.ID()}, Data: sentData}}},
+	}
+	processMessageBlock(t, indexer, writeMessageBlock(db, 1, nil, []*types.Transaction{deposit, withdrawal}, receipts))
+
+	message := rawdb.ReadCrossDomainMessage(db, depositHash)
+	if message == nil {
+		t.Fatal("deposit not indexed")
+	}
+	if message.Direction != rawdb.MessageL1ToL2 || message.Status != rawdb.MessageRelayed || message.Sender != l1Sender || message.Target != l2Target || message.Nonce.Int64() != 1 {
+		t.Fatalf("deposit mismatch: %+v", message)
+	}
+	if message.BlockNumber != 1 || message.TxHash != deposit.Hash() {
+		t.Fatalf("deposit position mismatch: have %d %x, want 1 %x", message.BlockNumber, message.TxHash, deposit.Hash())
+	}
+	message = rawdb.ReadCrossDomainMessage(db, withdrawalHash)
+	if message == nil {
+		t.Fatal("withdrawal not indexed")
+	}
+	if message.Direction != rawdb.MessageL2ToL1 || message.Status != rawdb.MessageSent || message.Sender != l2Sender || message.Target != l1Target {
+		t.Fatalf("withdrawal mismatch: %+v", message)
+	}
+	if hashes := rawdb.ReadAddressMessageHashes(db, l1Sender); len(hashes) != 1 || hashes[0] != depositHash {
+		t.Fatalf("sender messages mismatch: %v", hashes)
+	}
+
+	// Reorging the block to one where the deposit failed replaces its messages
+	deposit = types.NewTransaction(1, messenger, new(big.Int), 1000000, new(big.Int), depositData)
+	deposit.SetTransactionMeta(types.NewTransactionMeta(big.NewInt(1), 0, &l1Sender, types.QueueOriginL1ToL2, nil, nil, nil))
+	receipts = []*types.Receipt{{Status: types.ReceiptStatusFailed}}
+	processMessageBlock(t, indexer, writeMessageBlock(db, 1, []byte("reorg"), []*types.Transaction{deposit}, receipts))
+
+	message = rawdb.ReadCrossDomainMessage(db, depositHash)
+	if message == nil || message.Status != rawdb.MessageFailed || message.TxHash != deposit.Hash() {
+		t.Fatalf("replayed deposit mismatch: %+v", message)
+	}
+	if rawdb.ReadCrossDomainMessage(db, withdrawalHash) != nil {
+		t.Fatal("reorged withdrawal not removed")
+	}
+	if hashes := rawdb.ReadAddressMessageHashes(db, l2Sender); len(hashes) != 0 {
+		t.Fatalf("reorged withdrawal still indexed by address: %v", hashes)
+	}
+	if hashes := rawdb.ReadAddressMessageHashes(db, l1Sender); len(hashes) != 1 {
+		t.Fatalf("sender messages mismatch: %v", hashes)
+	}
+}
+
+// A replay of a message in a later block replaces its relay until the replay
+// is reorged out, then the earlier relay is restored
+func TestMessageIndexerReplayReorg(t *testing.T) {
+	var (
+		db        = rawdb.NewMemoryDatabase()
+		messenger = common.HexToAddress("0x4200000000000000000000000000000000000007")
+		indexer   = newTestMessageIndexer(t, db, messenger)
+		l1Sender  = common.HexToAddress("0x1111")
+
+		depositData = relayMessageCalldata(t, indexer, common.HexToAddress("0x2222"), l1Sender, []byte{0x01}, 1)
+		depositHash = crypto.Keccak256Hash(depositData)
+	)
+	relay := func(nonce uint64, status uint64) (*types.Transaction, []*types.Receipt) {
+		tx := types.NewTransaction(nonce, messenger, new(big.Int), 1000000, new(big.Int), depositData)
+		tx.SetTransactionMeta(types.NewTransactionMeta(big.NewInt(1), 0, &l1Sender, types.QueueOriginL1ToL2, nil, nil, nil))
+		receipt := &types.Receipt{Status: status}
+		if status == types.ReceiptStatusSuccessful {
+			receipt.Logs = []*types.Log{{Address: messenger, Topics: []common.Hash{indexer.relayedMessage}, Data: depositHash.Bytes()}}
+		}
+		return tx, []*types.Receipt{receipt}
+	}
+	failed, receipts := relay(0, types.ReceiptStatusFailed)
+	processMessageBlock(t, indexer, writeMessageBlock(db, 1, nil, []*types.Transaction{failed}, receipts))
+
+	replay, receipts := relay(1, types.ReceiptStatusSuccessful)
+	processMessageBlock(t, indexer, writeMessageBlock(db, 2, nil, []*types.Transaction{replay}, receipts))
+	if message := rawdb.ReadCrossDomainMessage(db, depositHash); message == nil || message.Status != rawdb.MessageRelayed || message.TxHash != replay.Hash() {
+		t.Fatalf("replayed deposit mismatch: %+v", message)
+	}
+
+	// Reorging the replay out restores the failed relay
+	processMessageBlock(t, indexer, writeMessageBlock(db, 2, []byte("reorg"), nil, nil))
+	message := rawdb.ReadCrossDomainMessage(db, depositHash)
+	if message == nil || message.Status != rawdb.MessageFailed || message.BlockNumber != 1 || message.TxHash != failed.Hash() {
+		t.Fatalf("restored deposit mismatch: %+v", message)
+	}
+	if hashes := rawdb.ReadAddressMessageHashes(db, l1Sender); len(hashes) != 1 || hashes[0] != depositHash {
+		t.Fatalf("sender messages mismatch: %v", hashes)
+	}
+}
+
+// A reorg to a shorter chain removes the messages of the rewound blocks
+// beyond the new head
+func TestMessageIndexerResetRewound(t *testing.T) {
+	var (
+		db        = rawdb.NewMemoryDatabase()
+		messenger = common.HexToAddress("0x4200000000000000000000000000000000000007")
+		indexer   = newTestMessageIndexer(t, db, messenger)
+		l1Sender  = common.HexToAddress("0x1111")
+		hashes    []common.Hash
+	)
+	for number := int64(1); number <= 3; number++ {
+		data := relayMessageCalldata(t, indexer, common.HexToAddress("0x2222"), l1Sender, []byte{0x01}, number)
+		hashes = append(hashes, crypto.Keccak256Hash(data))
+
+		tx := types.NewTransaction(uint64(number), messenger, new(big.Int), 1000000, new(big.Int), data)
+		tx.SetTransactionMeta(types.NewTransactionMeta(big.NewInt(1), 0, &l1Sender, types.QueueOriginL1ToL2, nil, nil, nil))
+		processMessageBlock(t, indexer, writeMessageBlock(db, number, nil, []*types.Transaction{tx}, []*types.Receipt{{Status: types.ReceiptStatusSuccessful}}))
+	}
+	// The chain is rewound to a new block 2 without messages
+	processMessageBlock(t, indexer, writeMessageBlock(db, 2, []byte("reorg"), nil, nil))
+
+	if rawdb.ReadCrossDomainMessage(db, hashes[0]) == nil {
+		t.Fatal("message of the kept block removed")
+	}
+	for i, hash := range hashes[1:] {
+		if rawdb.ReadCrossDomainMessage(db, hash) != nil {
+			t.Fatalf("message of rewound block %d not removed", i+2)
+		}
+	}
+	if have := rawdb.ReadAddressMessageHashes(db, l1Sender); len(have) != 1 || have[0] != hashes[0] {
+		t.Fatalf("sender messages mismatch: %v", have)
+	}
+	if numbers := rawdb.ReadMessageBlockNumbers(db, 0); len(numbers) != 1 || numbers[0] != 1 {
+		t.Fatalf("indexed blocks mismatch: %v", numbers)
+	}
+}
@@ -0,0 +1,465 @@
+package tracers
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"sync/atomic"
+	"time"
+
+	"github.com/MetisProtocol/l2geth/accounts/abi"
+	"github.com/MetisProtocol/l2geth/common"
+	"github.com/MetisProtocol/l2geth/common/hexutil"
+	"github.com/MetisProtocol/l2geth/core/vm"
+)
+
+// OvmCallTracerName is the name that the native OVM call tracer is selected
+// by in the trace config
+const OvmCallTracerName = "ovmCallTracer"
+
+// ovmCallTypes maps the OVM_ExecutionManager methods that user contracts
+// make calls through to the type of the call that they represent
+var ovmCallTypes = map[string]string{
+	"ovmCALL":         "CALL",
+	"ovmSTATICCALL":   "STATICCALL",
+	"ovmDELEGATECALL": "DELEGATECALL",
+	"ovmCREATE":       "CREATE",
+	"ovmCREATE2":      "CREATE2",
+	"ovmCREATEEOA":    "CREATE",
+}
+
+// ovmCallFrame is a single call of the call tree. The unexported fields are
+// only used while the frame is on the call stack.
+type ovmCallFrame struct {
+	Type         string          `json:"type"`
+	From         common.Address  `json:"from"`
+	To           *common.Address `json:"to,omitempty"`
+	Value        *hexutil.Big    `json:"value,omitempty"`
+	Gas          *hexutil.Uint64 `json:"gas,omitempty"`
+	GasUsed      *hexutil.Uint64 `json:"gasUsed,omitempty"`
+	Input        hexutil.Bytes   `json:"input,omitempty"`
+	Output       hexutil.Bytes   `json:"output,omitempty"`
+	Error        string          `json:"error,omitempty"`
+	RevertReason string          `json:"revertReason,omitempty"`
+	Calls        []*ovmCallFrame `json:"calls,omitempty"`
+
+	gasIn   uint64
+	gasCost uint64
+	outOff  int64
+	outLen  int64
+}
+
+// OvmCallTracer is a native call tracer that reports the user level call
+// tree of an OVM transaction. Calls into the OVM_ExecutionManager are replaced
+// by the calls that it makes on behalf of the calling contract, calls to the
+// OVM_StateManager and the OVM_SafetyChecker are dropped and the sequencer
+// entrypoint and account contract frames of the sender are collapsed. The
+// call tree of transactions that do not execute through the
+// OVM_ExecutionManager is reported like the JavaScript callTracer does.
+type OvmCallTracer struct {
+	callstack []*ovmCallFrame
+	descended bool
+
+	inited           bool
+	executionManager common.Address
+	stateManager     common.Address
+	safetyChecker    common.Address
+	entrypoint       common.Address
+	emABI            abi.ABI
+
+	interrupt uint32 // Atomic flag to signal execution interruption
+	reason    error  // Textual reason for the interruption
+	err       error  // Error, if one has occurred
+}
+
+// NewOvmCallTracer creates a native OVM call tracer
+func NewOvmCallTracer() *OvmCallTracer {
+	return &OvmCallTracer{}
+}
+
+// Stop terminates execution of the tracer at the first opportune moment.
+func (t *OvmCallTracer) Stop(err error) {
+	t.reason = err
+	atomic.StoreUint32(&t.interrupt, 1)
+}
+
+// CaptureStart implements the Tracer interface to initialize the tracing operation.
+func (t *OvmCallTracer) CaptureStart(from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) error {
+	typ := "CALL"
+	if create {
+		typ = "CREATE"
+	}
+	t.callstack = []*ovmCallFrame{{
+		Type:  typ,
+		From:  from,
+		To:    &to,
+		Value: (*hexutil.Big)(value),
+		Gas:   uint64Ptr(gas),
+		Input: common.CopyBytes(input),
+	}}
+	return nil
+}
+
+// CaptureState implements the Tracer interface to trace a single step of VM execution.
+func (t *OvmCallTracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	if t.err != nil {
+		return nil
+	}
+	// Pick up the OVM contracts from the context of the first step
+	if !t.inited {
+		t.executionManager = env.Context.OvmExecutionManager.Address
+		t.stateManager = env.Context.OvmStateManager.Address
+		t.safetyChecker = env.Context.OvmSafetyChecker.Address
+		t.entrypoint = env.Context.OvmSequencerEntrypoint.Address
+		t.emABI = env.Context.OvmExecutionManager.ABI
+		t.inited = true
+	}
+	// If tracing was interrupted, set the error and abort the execution
+	if atomic.LoadUint32(&t.interrupt) > 0 {
+		t.err = t.reason
+		env.Cancel()
+		return nil
+	}
+	// If we've just descended into an inner call, retrieve its true allowance
+	if t.descended {
+		if depth >= len(t.callstack) {
+			t.callstack[len(t.callstack)-1].Gas = uint64Ptr(gas)
+		}
+		t.descended = false
+	}
+	// If an inner call returned, pop it off the call stack before the opcode
+	// is handled in the context of the caller
+	if depth == len(t.callstack)-1 {
+		t.pop(env, memory, stack, gas)
+	}
+	if err != nil {
+		t.fault(err)
+		return nil
+	}
+	switch op {
+	case vm.CREATE, vm.CREATE2:
+		t.push(&ovmCallFrame{
+			Type:    op.String(),
+			From:    contract.Address(),
+			Value:   (*hexutil.Big)(new(big.Int).Set(stack.Back(0))),
+			Input:   memory.GetCopy(stack.Back(1).Int64(), stack.Back(2).Int64()),
+			gasIn:   gas,
+			gasCost: cost,
+		})
+
+	case vm.CALL, vm.CALLCODE, vm.DELEGATECALL, vm.STATICCALL:
+		// Skip any pre-compile invocations, those are just fancy opcodes
+		to := common.BigToAddress(stack.Back(1))
+		if _, ok := vm.PrecompiledContractsIstanbul[to]; ok {
+			return nil
+		}
+		off := 0
+		if op == vm.CALL || op == vm.CALLCODE {
+			off = 1
+		}
+		call := &ovmCallFrame{
+			Type:    op.String(),
+			From:    contract.Address(),
+			To:      &to,
+			Input:   memory.GetCopy(stack.Back(2+off).Int64(), stack.Back(3+off).Int64()),
+			gasIn:   gas,
+			gasCost: cost,
+			outOff:  stack.Back(4 + off).Int64(),
+			outLen:  stack.Back(5 + off).Int64(),
+		}
+		if off == 1 {
+			call.Value = (*hexutil.Big)(new(big.Int).Set(stack.Back(2)))
+		}
+		t.push(call)
+
+	case vm.RETURN, vm.REVERT:
+		// Keep the returned data as it is, the memory of the caller only
+		// receives as much of it as the caller asked for
+		if depth == len(t.callstack) {
+			call := t.callstack[len(t.callstack)-1]
+			call.Output = memory.GetCopy(stack.Back(0).Int64(), stack.Back(1).Int64())
+			if op == vm.REVERT {
+				call.Error = "execution reverted"
+			}
+		}
+
+	case vm.SELFDESTRUCT:
+		to := common.BigToAddress(stack.Back(0))
+		t.append(&ovmCallFrame{
+			Type:  op.String(),
+			From:  contract.Address(),
+			To:    &to,
+			Value: (*hexutil.Big)(env.StateDB.GetBalance(contract.Address())),
+		})
+	}
+	return nil
+}
+
+// CaptureFault implements the Tracer interface to trace an execution fault
+// while running an opcode.
+func (t *OvmCallTracer) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	if t.err == nil {
+		t.fault(err)
+	}
+	return nil
+}
+
+// CaptureEnd is called after the call finishes to finalize the tracing.
+func (t *OvmCallTracer) CaptureEnd(output []byte, gasUsed uint64, d time.Duration, err error) error {
+	if len(t.callstack) == 0 {
+		return nil
+	}
+	root := t.callstack[0]
+	root.GasUsed = uint64Ptr(gasUsed)
+	root.Output = common.CopyBytes(output)
+	if err != nil && root.Error == "" {
+		root.Error = err.Error()
+	}
+	return nil
+}
+
+// GetResult returns the user level call tree of the traced transaction
+func (t *OvmCallTracer) GetResult() (json.RawMessage, error) {
+	if t.err != nil {
+		return nil, t.err
+	}
+	if len(t.callstack) == 0 {
+		return nil, errors.New("no transaction traced")
+	}
+	return json.Marshal(t.result())
+}
+
+// push adds a call that is about to be executed to the call stack
+func (t *OvmCallTracer) push(call *ovmCallFrame) {
+	t.callstack = append(t.callstack, call)
+	t.descended = true
+}
+
+// append adds a finished call to the calls of the topmost call
+func (t *OvmCallTracer) append(call *ovmCallFrame) {
+	parent := t.callstack[len(t.callstack)-1]
+	parent.Calls = append(parent.Calls, call)
+}
+
+// pop removes the topmost call after it returned to its caller and collects
+// its results from the state of the caller
+func (t *OvmCallTracer) pop(env *vm.EVM, memory *vm.Memory, stack *vm.Stack, gas uint64) {
+	call := t.callstack[len(t.callstack)-1]
+	t.callstack = t.callstack[:len(t.callstack)-1]
+
+	ret := stack.Back(0)
+	if call.Type == "CREATE" || call.Type == "CREATE2" {
+		call.GasUsed = uint64Ptr(call.gasIn - call.gasCost - gas)
+		if ret.Sign() != 0 {
+			to := common.BigToAddress(ret)
+			call.To = &to
+			call.Output = env.StateDB.GetCode(to)
+		} else if call.Error == "" {
+			call.Error = "internal failure"
+		}
+	} else if call.Gas != nil {
+		call.GasUsed = uint64Ptr(call.gasIn - call.gasCost + uint64(*call.Gas) - gas)
+		if ret.Sign() == 0 && call.Error == "" {
+			call.Error = "internal failure"
+		}
+	} else if ret.Sign() != 0 {
+		// The call didn't execute any code, either because the account has
+		// none or because it is implemented natively like the state manager
+		call.Output = memory.GetCopy(call.outOff, call.outLen)
+	}
+	t.append(call)
+}
+
+// fault marks the topmost call as failed and flattens it into its caller
+func (t *OvmCallTracer) fault(err error) {
+	if len(t.callstack) == 0 {
+		return
+	}
+	// If the topmost call already reverted, don't handle the additional fault again
+	if t.callstack[len(t.callstack)-1].Error != "" {
+		return
+	}
+	call := t.callstack[len(t.callstack)-1]
+	call.Error = err.Error()
+	if call.Gas != nil {
+		call.GasUsed = call.Gas
+	}
+	if len(t.callstack) > 1 {
+		t.callstack = t.callstack[:len(t.callstack)-1]
+		t.append(call)
+	}
+}
+
+// result builds the user level call tree from the traced calls
+func (t *OvmCallTracer) result() *ovmCallFrame {
+	root := t.callstack[0]
+	if !t.isExecutionManager(root) {
+		root.Calls = t.collapse(root.Calls, t.context(root, root.From))
+		decodeRevert(root)
+		return root
+	}
+	// The transaction is a call to ExecutionManager.run, the calls that it
+	// makes are made on behalf of the sender
+	calls := t.unwrap(t.collapseExecutionManager(root, root.From), root.From)
+	if len(calls) != 1 {
+		root.Calls = calls
+		return root
+	}
+	call := calls[0]
+	call.From = root.From
+	if root.Error != "" && call.Error == "" {
+		call.Error = root.Error
+	}
+	// The return data of the root call is already unwrapped by the EVM
+	if call.Error == "" {
+		call.Output = root.Output
+	}
+	return call
+}
+
+// collapse returns the user level calls of calls that were made by a contract
+// running in the context of the ctx address
+func (t *OvmCallTracer) collapse(calls []*ovmCallFrame, ctx common.Address) []*ovmCallFrame {
+	var collapsed []*ovmCallFrame
+	for _, call := range calls {
+		switch {
+		case t.isPlumbing(call):
+			continue
+		case t.isExecutionManager(call):
+			collapsed = append(collapsed, t.collapseExecutionManager(call, ctx)...)
+		default:
+			call.Calls = t.collapse(call.Calls, t.context(call, ctx))
+			decodeRevert(call)
+			collapsed = append(collapsed, call)
+		}
+	}
+	return collapsed
+}
+
+// collapseExecutionManager returns the calls that the OVM_ExecutionManager
+// made on behalf of a contract running in the context of the ctx address,
+// typed after the ExecutionManager method that was called
+func (t *OvmCallTracer) collapseExecutionManager(em *ovmCallFrame, ctx common.Address) []*ovmCallFrame {
+	var (
+		typ   string
+		value *big.Int
+	)
+	if method, err := t.emABI.MethodById(em.Input); err == nil {
+		typ = ovmCallTypes[method.RawName]
+		value = methodValue(method, em.Input)
+	}
+	var collapsed []*ovmCallFrame
+	for _, call := range em.Calls {
+		switch {
+		case t.isPlumbing(call):
+			continue
+		case t.isExecutionManager(call):
+			// The ExecutionManager calls itself to create contracts safely
+			collapsed = append(collapsed, t.collapseExecutionManager(call, ctx)...)
+			continue
+		}
+		if typ != "" {
+			call.Type = typ
+		}
+		if value != nil {
+			call.Value = (*hexutil.Big)(value)
+		}
+		call.From = ctx
+		call.Calls = t.collapse(call.Calls, t.context(call, ctx))
+		decodeRevert(call)
+		collapsed = append(collapsed, call)
+	}
+	return collapsed
+}
+
+// unwrap replaces the sequencer entrypoint and account contract calls of the
+// sender with the calls that they make
+func (t *OvmCallTracer) unwrap(calls []*ovmCallFrame, sender common.Address) []*ovmCallFrame {
+	var unwrapped []*ovmCallFrame
+	for _, call := range calls {
+		wrapper := call.Type == "DELEGATECALL" && call.From == sender
+		if call.To != nil && (*call.To == t.entrypoint || *call.To == sender) {
+			wrapper = true
+		}
+		if wrapper {
+			unwrapped = append(unwrapped, t.unwrap(call.Calls, sender)...)
+			continue
+		}
+		unwrapped = append(unwrapped, call)
+	}
+	return unwrapped
+}
+
+// isExecutionManager returns whether a call goes to the OVM_ExecutionManager
+func (t *OvmCallTracer) isExecutionManager(call *ovmCallFrame) bool {
+	return call.To != nil && *call.To != (common.Address{}) && *call.To == t.executionManager
+}
+
+// isPlumbing returns whether a call goes to an OVM contract that is only used
+// to implement the execution of user contracts
+func (t *OvmCallTracer) isPlumbing(call *ovmCallFrame) bool {
+	if call.To == nil || *call.To == (common.Address{}) {
+		return false
+	}
+	return *call.To == t.stateManager || *call.To == t.safetyChecker
+}
+
+// context returns the address that the calls made by call are made from when
+// its caller runs in the context of the ctx address
+func (t *OvmCallTracer) context(call *ovmCallFrame, ctx common.Address) common.Address {
+	if call.Type == "DELEGATECALL" || call.Type == "CALLCODE" || call.To == nil {
+		return ctx
+	}
+	return *call.To
+}
+
+// methodValue returns the _value argument of an ExecutionManager call if the
+// method has one
+func methodValue(method *abi.Method, input []byte) *big.Int {
+	args, err := method.Inputs.UnpackValues(input[4:])
+	if err != nil {
+		return nil
+	}
+	for i, arg := range method.Inputs {
+		if value, ok := args[i].(*big.Int); ok && arg.Name == "_value" {
+			return value
+		}
+	}
+	return nil
+}
+
+// decodeRevert strips the flag encoding of the OVM_ExecutionManager from the
+// revert data of a failed call and decodes its revert reason
+func decodeRevert(call *ovmCallFrame) {
+	if call.Error == "" || len(call.Output) == 0 {
+		return
+	}
+	call.Output = ovmRevertData(call.Output)
+	if reason, err := abi.UnpackRevert(call.Output); err == nil {
+		call.RevertReason = reason
+	}
+}
+
+// ovmRevertData returns the revert data of a contract that reverted through
+// the OVM_ExecutionManager, which is encoded as
+// abi.encode(flag, nuisanceGasLeft, ovmGasRefund, returnData). Data that isn't
+// encoded that way is returned as it is.
+func ovmRevertData(data []byte) []byte {
+	if len(data) < 5*32 || len(data)%32 != 0 {
+		return data
+	}
+	offset := new(big.Int).SetBytes(data[3*32 : 4*32])
+	if !offset.IsUint64() || offset.Uint64() != 4*32 {
+		return data
+	}
+	size := new(big.Int).SetBytes(data[4*32 : 5*32])
+	if !size.IsUint64() || size.Uint64() > uint64(len(data)-5*32) {
+		return data
+	}
+	return data[5*32 : 5*32+size.Uint64()]
+}
+
+// uint64Ptr returns a pointer to the hexutil encoding of n
+func uint64Ptr(n uint64) *hexutil.Uint64 {
+	return (*hexutil.Uint64)(&n)
+}
@@ -0,0 +1,208 @@
+package tracers
+
+import (
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/MetisProtocol/l2geth/accounts/abi"
+	"github.com/MetisProtocol/l2geth/common"
+	"github.com/MetisProtocol/l2geth/common/hexutil"
+	"github.com/MetisProtocol/l2geth/core"
+	"github.com/MetisProtocol/l2geth/core/rawdb"
+	"github.com/MetisProtocol/l2geth/core/vm"
+	"github.com/MetisProtocol/l2geth/params"
+	"github.com/MetisProtocol/l2geth/tests"
+)
+
+const ovmExecutionManagerABI = `[
+	{"type":"function","name":"ovmCALL","inputs":[{"name":"_gasLimit","type":"uint256"},{"name":"_address","type":"address"},{"name":"_calldata","type":"bytes"}],"outputs":[]},
+	{"type":"function","name":"ovmDELEGATECALL","inputs":[{"name":"_gasLimit","type":"uint256"},{"name":"_address","type":"address"},{"name":"_calldata","type":"bytes"}],"outputs":[]},
+	{"type":"function","name":"ovmSLOAD","inputs":[{"name":"_key","type":"bytes32"}],"outputs":[]}
+]`
+
+// revertReason returns the Error(string) encoding of reason
+func revertReason(t *testing.T, reason string) []byte {
+	typ, _ := abi.NewType("string", "", nil)
+	data, err := abi.Arguments{{Type: typ}}.Pack(reason)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return append(common.CopyBytes(abi.RevertSelector), data...)
+}
+
+// flaggedRevert returns data the way that the OVM_ExecutionManager reverts with it
+func flaggedRevert(t *testing.T, data []byte) []byte {
+	uint256, _ := abi.NewType("uint256", "", nil)
+	bytesTy, _ := abi.NewType("bytes", "", nil)
+	args := abi.Arguments{{Type: uint256}, {Type: uint256}, {Type: uint256}, {Type: bytesTy}}
+	encoded, err := args.Pack(big.NewInt(2), big.NewInt(0), big.NewInt(0), data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return encoded
+}
+
+func TestOvmCallTracerCollapse(t *testing.T) {
+	emABI, err := abi.JSON(strings.NewReader(ovmExecutionManagerABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		sender     = common.HexToAddress("0x1111")
+		em         = common.HexToAddress("0x4200000000000000000000000000000000000001")
+		sm         = common.HexToAddress("0x4200000000000000000000000000000000000002")
+		entrypoint = common.HexToAddress("0x4200000000000000000000000000000000000005")
+		impl       = common.HexToAddress("0x2222")
+		target     = common.HexToAddress("0x3333")
+		other      = common.HexToAddress("0x4444")
+
+		payload = []byte{0xde, 0xad, 0xbe, 0xef}
+		reason  = revertReason(t, "nope")
+	)
+	pack := func(method string, args ...interface{}) []byte {
+		data, err := emABI.Pack(method, args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+	call := func(from, to common.Address, input []byte, calls ...*ovmCallFrame) *ovmCallFrame {
+		return &ovmCallFrame{Type: "CALL", From: from, To: &to, Input: input, Calls: calls}
+	}
+	failed := call(em, other, []byte{0x01})
+	failed.Error = "execution reverted"
+	failed.Output = flaggedRevert(t, reason)
+
+	// The raw call tree of a sequencer transaction that calls target, which
+	// makes a failing call to other and reads its storage
+	root := call(sender, em, []byte{0xff, 0xff, 0xff, 0xff},
+		call(em, sm, nil),
+		call(em, entrypoint, nil,
+			call(entrypoint, em, pack("ovmCALL", big.NewInt(1), sender, []byte{}),
+				call(em, sm, nil),
+				call(em, sender, nil,
+					call(sender, em, pack("ovmDELEGATECALL", big.NewInt(1), impl, []byte{}),
+						call(em, impl, nil,
+							call(impl, em, pack("ovmCALL", big.NewInt(1), target, payload),
+								call(em, target, payload,
+									call(target, em, pack("ovmCALL", big.NewInt(1), other, []byte{0x01}), failed),
+									call(target, em, pack("ovmSLOAD", common.Hash{}), call(em, sm, nil)),
+								),
+							),
+						),
+					),
+				),
+			),
+		),
+	)
+	root.Output = []byte{0x42}
+
+	tracer := &OvmCallTracer{
+		callstack:        []*ovmCallFrame{root},
+		inited:           true,
+		executionManager: em,
+		stateManager:     sm,
+		entrypoint:       entrypoint,
+		emABI:            emABI,
+	}
+	res := tracer.result()
+	if res.Type != "CALL" || res.From != sender || *res.To != target {
+		t.Fatalf("root call mismatch: have %s %x -> %x, want CALL %x -> %x", res.Type, res.From, *res.To, sender, target)
+	}
+	if string(res.Input) != string(payload) || string(res.Output) != string([]byte{0x42}) {
+		t.Fatalf("root data mismatch: have input %x output %x", res.Input, res.Output)
+	}
+	if len(res.Calls) != 1 {
+		t.Fatalf("expected 1 inner call, got %d", len(res.Calls))
+	}
+	inner := res.Calls[0]
+	if inner.From != target || *inner.To != other {
+		t.Fatalf("inner call mismatch: have %x -> %x, want %x -> %x", inner.From, *inner.To, target, other)
+	}
+	if inner.RevertReason != "nope" {
+		t.Fatalf("revert reason mismatch: have %q, want %q", inner.RevertReason, "nope")
+	}
+	if string(inner.Output) != string(reason) {
+		t.Fatalf("revert data mismatch: have %x, want %x", inner.Output, reason)
+	}
+}
+
+func TestOvmCallTracerPlainCalls(t *testing.T) {
+	var (
+		sender = common.HexToAddress("0x1111")
+		caller = common.HexToAddress("0x2222")
+		callee = common.HexToAddress("0x3333")
+	)
+	// The callee stores the revert reason in memory and reverts with it
+	reason := revertReason(t, "nope")
+	var revert []byte
+	for i := 0; i < len(reason); i += 32 {
+		word := common.RightPadBytes(reason[i:], 32)[:32]
+		revert = append(revert, byte(vm.PUSH32))
+		revert = append(revert, word...)
+		revert = append(revert, byte(vm.PUSH1), byte(i), byte(vm.MSTORE))
+	}
+	revert = append(revert, byte(vm.PUSH1), byte(len(reason)), byte(vm.PUSH1), 0, byte(vm.REVERT))
+
+	// The caller calls the callee without asking for any return data
+	call := []byte{byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH20)}
+	call = append(call, callee.Bytes()...)
+	call = append(call, byte(vm.GAS), byte(vm.CALL), byte(vm.STOP))
+
+	statedb := tests.MakePreState(rawdb.NewMemoryDatabase(), core.GenesisAlloc{
+		caller: {Code: call, Balance: new(big.Int)},
+		callee: {Code: revert, Balance: new(big.Int)},
+	})
+	context := vm.Context{
+		CanTransfer: core.CanTransfer,
+		Transfer:    core.Transfer,
+		BlockNumber: big.NewInt(1),
+		Time:        big.NewInt(1),
+		Difficulty:  big.NewInt(0),
+		GasLimit:    uint64(6000000),
+		GasPrice:    big.NewInt(1),
+	}
+	tracer := NewOvmCallTracer()
+	evm := vm.NewEVM(context, statedb, params.TestChainConfig, vm.Config{Debug: true, Tracer: tracer})
+	if _, _, err := evm.Call(vm.AccountRef(sender), caller, nil, 100000, new(big.Int)); err != nil {
+		t.Fatal(err)
+	}
+	res, err := tracer.GetResult()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var root struct {
+		From  common.Address `json:"from"`
+		To    common.Address `json:"to"`
+		Calls []struct {
+			Type         string         `json:"type"`
+			From         common.Address `json:"from"`
+			To           common.Address `json:"to"`
+			Gas          hexutil.Uint64 `json:"gas"`
+			Output       hexutil.Bytes  `json:"output"`
+			Error        string         `json:"error"`
+			RevertReason string         `json:"revertReason"`
+		} `json:"calls"`
+	}
+	if err := json.Unmarshal(res, &root); err != nil {
+		t.Fatal(err)
+	}
+	if root.From != sender || root.To != caller {
+		t.Fatalf("root call mismatch: have %x -> %x, want %x -> %x", root.From, root.To, sender, caller)
+	}
+	if len(root.Calls) != 1 {
+		t.Fatalf("expected 1 inner call, got %d", len(root.Calls))
+	}
+	inner := root.Calls[0]
+	if inner.Type != "CALL" || inner.From != caller || inner.To != callee || inner.Gas == 0 {
+		t.Fatalf("inner call mismatch: %+v", inner)
+	}
+	if inner.Error != "execution reverted" || inner.RevertReason != "nope" {
+		t.Fatalf("inner call error mismatch: have %q (%q)", inner.Error, inner.RevertReason)
+	}
+	if string(inner.Output) != string(reason) {
+		t.Fatalf("revert data mismatch: have %x, want %x", inner.Output, reason)
+	}
+}
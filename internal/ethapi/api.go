@@ -22,6 +22,7 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"sort"
 	"strings"
 	"time"
 
@@ -36,9 +37,12 @@ import (
 	"github.com/MetisProtocol/l2geth/consensus/ethash"
 	"github.com/MetisProtocol/l2geth/core"
 	"github.com/MetisProtocol/l2geth/core/rawdb"
+	"github.com/MetisProtocol/l2geth/core/state"
 	"github.com/MetisProtocol/l2geth/core/types"
 	"github.com/MetisProtocol/l2geth/core/vm"
 	"github.com/MetisProtocol/l2geth/crypto"
+	"github.com/MetisProtocol/l2geth/diffdb"
+	"github.com/MetisProtocol/l2geth/ethdb"
 	"github.com/MetisProtocol/l2geth/log"
 	"github.com/MetisProtocol/l2geth/p2p"
 	"github.com/MetisProtocol/l2geth/params"
@@ -564,7 +568,11 @@ func (s *PublicBlockChainAPI) GetProof(ctx context.Context, address common.Addre
 	if state == nil || err != nil {
 		return nil, err
 	}
+	return getProof(state, address, storageKeys)
+}
 
+// getProof creates the Merkle-proof for an account and its storage keys.
+func getProof(state *state.StateDB, address common.Address, storageKeys []string) (*AccountResult, error) {
 	storageTrie := state.StorageTrie(address)
 	storageHash := types.EmptyRootHash
 	codeHash := state.GetCodeHash(address)
@@ -608,6 +616,76 @@ func (s *PublicBlockChainAPI) GetProof(ctx context.Context, address common.Addre
 	}, state.Error()
 }
 
+// HeaderMeta describes the block that a StateDiffProof belongs to.
+type HeaderMeta struct {
+	Number    *hexutil.Big   `json:"number"`
+	Hash      common.Hash    `json:"hash"`
+	StateRoot common.Hash    `json:"stateRoot"`
+	Timestamp hexutil.Uint64 `json:"timestamp"`
+}
+
+// StateDiffProof contains the Merkle-proofs of every account and storage slot
+// touched by a block. The proofs are made against the state that the block
+// was executed on, which is the state root of the parent block.
+type StateDiffProof struct {
+	Header       *HeaderMeta     `json:"header"`
+	PreStateRoot common.Hash     `json:"preStateRoot"`
+	Accounts     []AccountResult `json:"accounts"`
+}
+
+// GetStateDiffProof returns the Merkle-proofs of all the accounts and storage
+// slots that were touched by the given block.
+func (s *PublicBlockChainAPI) GetStateDiffProof(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*StateDiffProof, error) {
+	header, err := s.b.HeaderByNumberOrHash(ctx, blockNrOrHash)
+	if header == nil || err != nil {
+		return nil, err
+	}
+	if header.Number.Sign() == 0 {
+		return nil, errors.New("No state diff for the genesis block")
+	}
+	diff, err := s.b.GetDiff(header.Number)
+	if err != nil {
+		return nil, err
+	}
+	parent := rpc.BlockNumberOrHashWithHash(header.ParentHash, false)
+	state, parentHeader, err := s.b.StateAndHeaderByNumberOrHash(ctx, parent)
+	if state == nil || err != nil {
+		return nil, err
+	}
+
+	// Sort the addresses so that the response is deterministic
+	addresses := make([]common.Address, 0, len(diff))
+	for address := range diff {
+		addresses = append(addresses, address)
+	}
+	sort.Slice(addresses, func(i, j int) bool {
+		return bytes.Compare(addresses[i][:], addresses[j][:]) < 0
+	})
+	accounts := make([]AccountResult, len(addresses))
+	for i, address := range addresses {
+		keys := make([]string, len(diff[address]))
+		for j, key := range diff[address] {
+			keys[j] = key.Key.Hex()
+		}
+		proof, err := getProof(state, address, keys)
+		if err != nil {
+			return nil, err
+		}
+		accounts[i] = *proof
+	}
+
+	return &StateDiffProof{
+		Header: &HeaderMeta{
+			Number:    (*hexutil.Big)(header.Number),
+			Hash:      header.Hash(),
+			StateRoot: header.Root,
+			Timestamp: hexutil.Uint64(header.Time),
+		},
+		PreStateRoot: parentHeader.Root,
+		Accounts:     accounts,
+	}, nil
+}
+
 // GetHeaderByNumber returns the requested canonical block header.
 // * When blockNr is -1 the chain head is returned.
 // * When blockNr is -2 the pending chain head is returned.
@@ -862,11 +940,14 @@ func DoCall(ctx context.Context, b Backend, args CallArgs, blockNrOrHash rpc.Blo
 
 	blockNumber := header.Number
 	timestamp := new(big.Int).SetUint64(header.Time)
+	// The OVM rules apply by the L2 block number, the L1 block number is only
+	// used for the NUMBER opcode
+	isOVM := b.ChainConfig().IsOVM(header.Number)
 
 	// Create new call message
 	var msg core.Message
 	msg = types.NewMessage(addr, args.To, 0, value, gas, gasPrice, data, false, &addr, nil, types.QueueOriginSequencer)
-	if vm.UsingOVM {
+	if isOVM {
 		cfg := b.ChainConfig()
 		executionManager := cfg.StateDump.Accounts["OVM_ExecutionManager"]
 		stateManager := cfg.StateDump.Accounts["OVM_StateManager"]
@@ -877,10 +958,11 @@ func DoCall(ctx context.Context, b Backend, args CallArgs, blockNrOrHash rpc.Blo
 		if block != nil {
 			txs := block.Transactions()
 			if header.Number.Uint64() != 0 {
-				if len(txs) != 1 {
-					return nil, 0, false, fmt.Errorf("block %d has more than 1 transaction", header.Number.Uint64())
+				if len(txs) == 0 {
+					return nil, 0, false, fmt.Errorf("block %d has no transactions", header.Number.Uint64())
 				}
-				tx := txs[0]
+				// Use the L1 context after the last transaction of the block
+				tx := txs[len(txs)-1]
 				blockNumber = tx.L1BlockNumber()
 				timestamp = new(big.Int).SetUint64(tx.L1Timestamp())
 			}
@@ -918,8 +1000,9 @@ func DoCall(ctx context.Context, b Backend, args CallArgs, blockNrOrHash rpc.Blo
 	// Setup the gas pool (also for unmetered requests)
 	// and apply the message.
 	gp := new(core.GasPool).AddGas(math.MaxUint64)
-	if vm.UsingOVM {
+	if isOVM {
 		evm.Context.EthCallSender = &addr
+		evm.Context.L2BlockNumber = header.Number
 		evm.Context.BlockNumber = blockNumber
 		evm.Context.Time = timestamp
 	}
@@ -1231,6 +1314,21 @@ func (s *PublicBlockChainAPI) rpcMarshalBlock(b *types.Block, inclTx bool, fullT
 	if inclTx {
 		fields["totalDifficulty"] = (*hexutil.Big)(s.b.GetTd(b.Hash()))
 	}
+	// Add the L1 context that anchors the block
+	fields["l1BlockNumber"] = nil
+	fields["l1Timestamp"] = nil
+	fields["l1BlockHash"] = nil
+	db := s.b.ChainDb()
+	if rawdb.ReadCanonicalHash(db, b.NumberU64()) != b.Hash() {
+		return fields, err
+	}
+	if context := rawdb.ReadBlockOVMContext(db, b.NumberU64()); context != nil {
+		fields["l1BlockNumber"] = hexutil.Uint64(context.BlockNumber)
+		fields["l1Timestamp"] = hexutil.Uint64(context.Timestamp)
+		if context.BlockHash != nil {
+			fields["l1BlockHash"] = *context.BlockHash
+		}
+	}
 	return fields, err
 }
 
@@ -1510,6 +1608,25 @@ func (s *PublicTransactionPoolAPI) GetTransactionReceipt(ctx context.Context, ha
 	if receipt.ContractAddress != (common.Address{}) {
 		fields["contractAddress"] = receipt.ContractAddress
 	}
+	// Report whether the transaction was submitted to L1 in a batch yet
+	status := newRPCTransactionStatus(s.b.ChainDb(), tx)
+	fields["rollupStatus"] = status.Status
+	fields["batchIndex"] = status.BatchIndex
+	fields["batchL1BlockNumber"] = status.BatchL1BlockNumber
+	fields["batchSubmitter"] = status.BatchSubmitter
+	// Add the fee components that were charged by the sequencer
+	fields["l1GasUsed"] = nil
+	fields["l1GasPrice"] = nil
+	fields["l1Fee"] = nil
+	fields["l2GasPrice"] = nil
+	fields["l2Fee"] = nil
+	if fee := rawdb.ReadTransactionFee(s.b.ChainDb(), hash); fee != nil {
+		fields["l1GasUsed"] = hexutil.Uint64(fee.L1GasUsed)
+		fields["l1GasPrice"] = (*hexutil.Big)(fee.L1GasPrice)
+		fields["l1Fee"] = (*hexutil.Big)(fee.L1Fee)
+		fields["l2GasPrice"] = (*hexutil.Big)(fee.L2GasPrice)
+		fields["l2Fee"] = (*hexutil.Big)(fee.L2Fee)
+	}
 	return fields, nil
 }
 
@@ -1646,10 +1763,15 @@ func SubmitTransaction(ctx context.Context, b Backend, tx *types.Transaction) (c
 	return tx.Hash(), nil
 }
 
+// isOVM returns whether the OVM rules apply to the current block
+func isOVM(b Backend) bool {
+	return b.ChainConfig().IsOVM(b.CurrentBlock().Number())
+}
+
 // SendTransaction creates a transaction for the given argument, sign it and submit it to the
 // transaction pool.
 func (s *PublicTransactionPoolAPI) SendTransaction(ctx context.Context, args SendTxArgs) (common.Hash, error) {
-	if vm.UsingOVM {
+	if isOVM(s.b) {
 		return common.Hash{}, errOVMUnsupported
 	}
 	// Look up the wallet containing the requested signer
@@ -1684,7 +1806,7 @@ func (s *PublicTransactionPoolAPI) SendTransaction(ctx context.Context, args Sen
 // FillTransaction fills the defaults (nonce, gas, gasPrice) on a given unsigned transaction,
 // and returns it to the caller for further processing (signing + broadcast)
 func (s *PublicTransactionPoolAPI) FillTransaction(ctx context.Context, args SendTxArgs) (*SignTransactionResult, error) {
-	if vm.UsingOVM {
+	if isOVM(s.b) {
 		return nil, errOVMUnsupported
 	}
 	// Set some sanity defaults and terminate on failure
@@ -1731,7 +1853,7 @@ func (s *PublicTransactionPoolAPI) SendRawTransaction(ctx context.Context, encod
 //
 // https://github.com/ethereum/wiki/wiki/JSON-RPC#eth_sign
 func (s *PublicTransactionPoolAPI) Sign(addr common.Address, data hexutil.Bytes) (hexutil.Bytes, error) {
-	if vm.UsingOVM {
+	if isOVM(s.b) {
 		return nil, errOVMUnsupported
 	}
 	// Look up the wallet containing the requested signer
@@ -1759,7 +1881,7 @@ type SignTransactionResult struct {
 // The node needs to have the private key of the account corresponding with
 // the given from address and it needs to be unlocked.
 func (s *PublicTransactionPoolAPI) SignTransaction(ctx context.Context, args SendTxArgs) (*SignTransactionResult, error) {
-	if vm.UsingOVM {
+	if isOVM(s.b) {
 		return nil, errOVMUnsupported
 	}
 	if args.Gas == nil {
@@ -1936,6 +2058,345 @@ func (api *PublicRollupAPI) GasPrices(ctx context.Context) (*gasPrices, error) {
 	}, nil
 }
 
+// maxFeeHistory is the largest number of gas price samples that are returned
+// by a single call to FeeHistory
+const maxFeeHistory = 1024
+
+// feeHistoryPercentiles are the percentiles of the L1 and L2 gas prices that
+// are returned by FeeHistory
+var feeHistoryPercentiles = []float64{10, 25, 50, 75, 90}
+
+// FeeHistory is a series of L1 and L2 gas price samples along with the
+// percentiles of each price over the series
+type FeeHistory struct {
+	OldestTimestamp       hexutil.Uint64   `json:"oldestTimestamp"`
+	Timestamps            []hexutil.Uint64 `json:"timestamps"`
+	L1GasPrices           []*hexutil.Big   `json:"l1GasPrices"`
+	L2GasPrices           []*hexutil.Big   `json:"l2GasPrices"`
+	Percentiles           []float64        `json:"percentiles"`
+	L1GasPricePercentiles []*hexutil.Big   `json:"l1GasPricePercentiles"`
+	L2GasPricePercentiles []*hexutil.Big   `json:"l2GasPricePercentiles"`
+}
+
+// FeeHistory returns up to count of the L1 and L2 gas price samples taken by
+// the node at or before the newest timestamp. The latest samples are used when
+// newest is not set.
+func (api *PublicRollupAPI) FeeHistory(ctx context.Context, count hexutil.Uint64, newest *hexutil.Uint64) (*FeeHistory, error) {
+	limit := int(count)
+	if limit <= 0 || limit > maxFeeHistory {
+		limit = maxFeeHistory
+	}
+	var timestamp uint64
+	if newest != nil {
+		timestamp = uint64(*newest)
+	}
+	samples, err := api.b.GasPriceHistory(ctx, limit, timestamp)
+	if err != nil {
+		return nil, err
+	}
+	result := &FeeHistory{
+		Timestamps:  make([]hexutil.Uint64, len(samples)),
+		L1GasPrices: make([]*hexutil.Big, len(samples)),
+		L2GasPrices: make([]*hexutil.Big, len(samples)),
+		Percentiles: feeHistoryPercentiles,
+	}
+	l1GasPrices := make([]*big.Int, len(samples))
+	l2GasPrices := make([]*big.Int, len(samples))
+	for i, sample := range samples {
+		result.Timestamps[i] = hexutil.Uint64(sample.Timestamp)
+		result.L1GasPrices[i] = (*hexutil.Big)(sample.L1GasPrice)
+		result.L2GasPrices[i] = (*hexutil.Big)(sample.L2GasPrice)
+		l1GasPrices[i] = sample.L1GasPrice
+		l2GasPrices[i] = sample.L2GasPrice
+	}
+	if len(samples) > 0 {
+		result.OldestTimestamp = hexutil.Uint64(samples[0].Timestamp)
+	}
+	result.L1GasPricePercentiles = gasPricePercentiles(l1GasPrices, feeHistoryPercentiles)
+	result.L2GasPricePercentiles = gasPricePercentiles(l2GasPrices, feeHistoryPercentiles)
+	return result, nil
+}
+
+// gasPricePercentiles returns the gas prices at each of the percentiles, which
+// range from 0 to 100. The nearest rank of the sorted prices is used.
+func gasPricePercentiles(prices []*big.Int, percentiles []float64) []*hexutil.Big {
+	result := make([]*hexutil.Big, len(percentiles))
+	if len(prices) == 0 {
+		return result
+	}
+	sorted := make([]*big.Int, len(prices))
+	copy(sorted, prices)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Cmp(sorted[j]) < 0
+	})
+	for i, p := range percentiles {
+		pos := p / 100 * float64(len(sorted))
+		rank := int(pos)
+		if float64(rank) < pos {
+			rank++
+		}
+		if rank < 1 {
+			rank = 1
+		}
+		if rank > len(sorted) {
+			rank = len(sorted)
+		}
+		result[i] = (*hexutil.Big)(sorted[rank-1])
+	}
+	return result
+}
+
+// RPCFeeBreakdown explains how the `tx.gasLimit` of a transaction is derived
+// from its L1 calldata cost and its L2 execution cost
+type RPCFeeBreakdown struct {
+	ZeroBytes         hexutil.Uint64 `json:"zeroBytes"`
+	NonZeroBytes      hexutil.Uint64 `json:"nonZeroBytes"`
+	Overhead          hexutil.Uint64 `json:"overhead"`
+	L1GasLimit        *hexutil.Big   `json:"l1GasLimit"`
+	L1GasPrice        *hexutil.Big   `json:"l1GasPrice"`
+	L1Fee             *hexutil.Big   `json:"l1Fee"`
+	L2GasLimit        *hexutil.Big   `json:"l2GasLimit"`
+	RoundedL2GasLimit *hexutil.Big   `json:"roundedL2GasLimit"`
+	L2GasPrice        *hexutil.Big   `json:"l2GasPrice"`
+	L2Fee             *hexutil.Big   `json:"l2Fee"`
+	TxGasLimit        *hexutil.Big   `json:"txGasLimit"`
+	TxGasPrice        *hexutil.Big   `json:"txGasPrice"`
+	Fee               *hexutil.Big   `json:"fee"`
+	FeeThresholdDown  *float64       `json:"feeThresholdDown"`
+	FeeThresholdUp    *float64       `json:"feeThresholdUp"`
+}
+
+// EstimateFee estimates the gas used by the call against the pending block
+// and returns the breakdown of the fee that the transaction must pay. The
+// `txGasLimit` is the same value that is returned by `eth_estimateGas`.
+func (api *PublicRollupAPI) EstimateFee(ctx context.Context, args CallArgs) (*RPCFeeBreakdown, error) {
+	blockNrOrHash := rpc.BlockNumberOrHashWithNumber(rpc.PendingBlockNumber)
+	gasUsed, err := legacyDoEstimateGas(ctx, api.b, args, blockNrOrHash, api.b.RPCGasCap())
+	if err != nil {
+		return nil, err
+	}
+	l1GasPrice, err := api.b.SuggestL1GasPrice(ctx)
+	if err != nil {
+		return nil, err
+	}
+	l2GasPrice, err := api.b.SuggestL2GasPrice(ctx)
+	if err != nil {
+		return nil, err
+	}
+	data := []byte{}
+	if args.Data != nil {
+		data = *args.Data
+	}
+	l2GasLimit := new(big.Int).SetUint64(uint64(gasUsed))
+	breakdown := fees.CalculateFeeBreakdown(data, l1GasPrice, l2GasLimit, l2GasPrice)
+	result := &RPCFeeBreakdown{
+		ZeroBytes:         hexutil.Uint64(breakdown.ZeroBytes),
+		NonZeroBytes:      hexutil.Uint64(breakdown.NonZeroBytes),
+		Overhead:          hexutil.Uint64(breakdown.Overhead),
+		L1GasLimit:        (*hexutil.Big)(breakdown.L1GasLimit),
+		L1GasPrice:        (*hexutil.Big)(breakdown.L1GasPrice),
+		L1Fee:             (*hexutil.Big)(breakdown.L1Fee),
+		L2GasLimit:        (*hexutil.Big)(breakdown.L2GasLimit),
+		RoundedL2GasLimit: (*hexutil.Big)(breakdown.RoundedL2GasLimit),
+		L2GasPrice:        (*hexutil.Big)(breakdown.L2GasPrice),
+		L2Fee:             (*hexutil.Big)(breakdown.L2Fee),
+		TxGasLimit:        (*hexutil.Big)(breakdown.TxGasLimit),
+		TxGasPrice:        (*hexutil.Big)(fees.BigTxGasPrice),
+		Fee:               (*hexutil.Big)(breakdown.Fee),
+	}
+	down, up, err := api.b.FeeThresholds()
+	if err != nil {
+		return nil, err
+	}
+	if down != nil {
+		value, _ := down.Float64()
+		result.FeeThresholdDown = &value
+	}
+	if up != nil {
+		value, _ := up.Float64()
+		result.FeeThresholdUp = &value
+	}
+	return result, nil
+}
+
+// GetStateDiff returns the accounts and storage slots that were touched by the
+// given block. Slots that were written to are marked as mutated.
+func (api *PublicRollupAPI) GetStateDiff(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (diffdb.Diff, error) {
+	header, err := api.b.HeaderByNumberOrHash(ctx, blockNrOrHash)
+	if header == nil || err != nil {
+		return nil, err
+	}
+	return api.b.GetDiff(header.Number)
+}
+
+// maxStateRootMismatches is the largest number of state root mismatches that
+// are returned by a single call to GetStateRootMismatches
+const maxStateRootMismatches = 1000
+
+// StateRootMismatch is a state root submitted to L1 that does not match the
+// state root of the local block at the same index
+type StateRootMismatch struct {
+	Index       hexutil.Uint64 `json:"index"`
+	BatchIndex  hexutil.Uint64 `json:"batchIndex"`
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+	BlockHash   common.Hash    `json:"blockHash"`
+	LocalRoot   common.Hash    `json:"localRoot"`
+	RemoteRoot  common.Hash    `json:"remoteRoot"`
+}
+
+// GetStateRootMismatches returns up to count state root mismatches that were
+// detected by the verifier, starting at the CTC index start. A count of zero
+// returns as many mismatches as allowed.
+func (api *PublicRollupAPI) GetStateRootMismatches(ctx context.Context, start hexutil.Uint64, count hexutil.Uint64) []*StateRootMismatch {
+	limit := int(count)
+	if limit <= 0 || limit > maxStateRootMismatches {
+		limit = maxStateRootMismatches
+	}
+	mismatches := rawdb.ReadStateRootMismatches(api.b.ChainDb(), uint64(start), limit)
+	result := make([]*StateRootMismatch, len(mismatches))
+	for i, mismatch := range mismatches {
+		result[i] = &StateRootMismatch{
+			Index:       hexutil.Uint64(mismatch.Index),
+			BatchIndex:  hexutil.Uint64(mismatch.BatchIndex),
+			BlockNumber: hexutil.Uint64(mismatch.BlockNumber),
+			BlockHash:   mismatch.BlockHash,
+			LocalRoot:   mismatch.LocalRoot,
+			RemoteRoot:  mismatch.RemoteRoot,
+		}
+	}
+	return result
+}
+
+// GetTransactionByIndex returns the transaction with the Canonical Transaction
+// Chain index
+func (api *PublicRollupAPI) GetTransactionByIndex(ctx context.Context, index hexutil.Uint64) (*RPCTransaction, error) {
+	return api.transactionByLookup(ctx, rawdb.ReadCTCLookupEntry(api.b.ChainDb(), uint64(index)))
+}
+
+// GetTransactionByQueueIndex returns the L1 to L2 transaction with the queue
+// index
+func (api *PublicRollupAPI) GetTransactionByQueueIndex(ctx context.Context, queueIndex hexutil.Uint64) (*RPCTransaction, error) {
+	return api.transactionByLookup(ctx, rawdb.ReadQueueLookupEntry(api.b.ChainDb(), uint64(queueIndex)))
+}
+
+func (api *PublicRollupAPI) transactionByLookup(ctx context.Context, entry *rawdb.RollupLookupEntry) (*RPCTransaction, error) {
+	if entry == nil {
+		return nil, nil
+	}
+	block, err := api.b.BlockByHash(ctx, entry.BlockHash)
+	if block == nil || err != nil {
+		return nil, err
+	}
+	return newRPCTransactionFromBlockIndex(block, entry.TxIndex), nil
+}
+
+// RPCBatch is a transaction batch that was appended to the Canonical
+// Transaction Chain along with the location of its transactions
+type RPCBatch struct {
+	Index             hexutil.Uint64         `json:"index"`
+	Root              common.Hash            `json:"root"`
+	Size              hexutil.Uint64         `json:"size"`
+	PrevTotalElements hexutil.Uint64         `json:"prevTotalElements"`
+	L1BlockNumber     hexutil.Uint64         `json:"l1BlockNumber"`
+	L1Timestamp       hexutil.Uint64         `json:"l1Timestamp"`
+	Submitter         common.Address         `json:"submitter"`
+	Transactions      []*RPCBatchTransaction `json:"transactions"`
+}
+
+// RPCBatchTransaction is the location of a transaction of a batch
+type RPCBatchTransaction struct {
+	Index       hexutil.Uint64 `json:"index"`
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+	BlockHash   common.Hash    `json:"blockHash"`
+	Hash        common.Hash    `json:"hash"`
+}
+
+// GetBatch returns the transaction batch with the batch index. Transactions
+// of the batch that are not in the local chain are left out.
+func (api *PublicRollupAPI) GetBatch(ctx context.Context, index hexutil.Uint64) *RPCBatch {
+	db := api.b.ChainDb()
+	batch := rawdb.ReadTransactionBatch(db, uint64(index))
+	if batch == nil {
+		return nil
+	}
+	result := &RPCBatch{
+		Index:             hexutil.Uint64(batch.Index),
+		Root:              batch.Root,
+		Size:              hexutil.Uint64(batch.Size),
+		PrevTotalElements: hexutil.Uint64(batch.PrevTotalElements),
+		L1BlockNumber:     hexutil.Uint64(batch.BlockNumber),
+		L1Timestamp:       hexutil.Uint64(batch.Timestamp),
+		Submitter:         batch.Submitter,
+		Transactions:      []*RPCBatchTransaction{},
+	}
+	for i := batch.PrevTotalElements; i < batch.PrevTotalElements+batch.Size; i++ {
+		entry := rawdb.ReadCTCLookupEntry(db, i)
+		if entry == nil {
+			continue
+		}
+		result.Transactions = append(result.Transactions, &RPCBatchTransaction{
+			Index:       hexutil.Uint64(i),
+			BlockNumber: hexutil.Uint64(entry.BlockNumber),
+			BlockHash:   entry.BlockHash,
+			Hash:        entry.TxHash,
+		})
+	}
+	return result
+}
+
+// RPCCrossDomainMessage is a message of the L2 cross domain messenger along
+// with the transaction that it was last indexed from
+type RPCCrossDomainMessage struct {
+	Hash            common.Hash    `json:"hash"`
+	Direction       string         `json:"direction"`
+	Status          string         `json:"status"`
+	Sender          common.Address `json:"sender"`
+	Target          common.Address `json:"target"`
+	Message         hexutil.Bytes  `json:"message"`
+	Nonce           *hexutil.Big   `json:"nonce"`
+	BlockNumber     hexutil.Uint64 `json:"blockNumber"`
+	TransactionHash common.Hash    `json:"transactionHash"`
+}
+
+func newRPCCrossDomainMessage(hash common.Hash, message *rawdb.CrossDomainMessage) *RPCCrossDomainMessage {
+	return &RPCCrossDomainMessage{
+		Hash:            hash,
+		Direction:       message.Direction.String(),
+		Status:          message.Status.String(),
+		Sender:          message.Sender,
+		Target:          message.Target,
+		Message:         message.Message,
+		Nonce:           (*hexutil.Big)(message.Nonce),
+		BlockNumber:     hexutil.Uint64(message.BlockNumber),
+		TransactionHash: message.TxHash,
+	}
+}
+
+// GetMessagesByAddress returns the indexed cross domain messages that the
+// address is the sender or the target of in block order
+func (api *PublicRollupAPI) GetMessagesByAddress(ctx context.Context, address common.Address) []*RPCCrossDomainMessage {
+	db := api.b.ChainDb()
+	messages := []*RPCCrossDomainMessage{}
+	for _, hash := range rawdb.ReadAddressMessageHashes(db, address) {
+		if message := rawdb.ReadCrossDomainMessage(db, hash); message != nil {
+			messages = append(messages, newRPCCrossDomainMessage(hash, message))
+		}
+	}
+	return messages
+}
+
+// GetMessageStatus returns the indexed cross domain message with the hash
+// along with its status. L2 to L1 messages are sent once they are indexed,
+// relaying them on L1 is not tracked. L1 to L2 messages are either relayed or
+// failed.
+func (api *PublicRollupAPI) GetMessageStatus(ctx context.Context, hash common.Hash) *RPCCrossDomainMessage {
+	message := rawdb.ReadCrossDomainMessage(api.b.ChainDb(), hash)
+	if message == nil {
+		return nil
+	}
+	return newRPCCrossDomainMessage(hash, message)
+}
+
 // PrivatelRollupAPI provides private RPC methods to control the sequencer.
 // These methods can be abused by external users and must be considered insecure for use by untrusted users.
 type PrivateRollupAPI struct {
@@ -2151,3 +2612,60 @@ func (s *PublicNetAPI) PeerCount() hexutil.Uint {
 func (s *PublicNetAPI) Version() string {
 	return fmt.Sprintf("%d", s.networkVersion)
 }
+
+// The rollup status of a transaction. Pending transactions are waiting in the
+// transaction pool, sequenced transactions are in the chain and batched
+// transactions were also submitted to the Canonical Transaction Chain on L1.
+const (
+	TransactionStatusPending   = "pending"
+	TransactionStatusSequenced = "sequenced"
+	TransactionStatusBatched   = "batched"
+)
+
+// RPCTransactionStatus is the rollup status of a transaction along with the
+// batch that it was submitted to L1 in
+type RPCTransactionStatus struct {
+	Status             string          `json:"status"`
+	Index              *hexutil.Uint64 `json:"index"`
+	BatchIndex         *hexutil.Uint64 `json:"batchIndex"`
+	BatchL1BlockNumber *hexutil.Uint64 `json:"batchL1BlockNumber"`
+	BatchSubmitter     *common.Address `json:"batchSubmitter"`
+}
+
+// GetTransactionStatus returns whether the transaction with the hash is
+// pending, sequenced or batched
+func (api *PublicRollupAPI) GetTransactionStatus(ctx context.Context, hash common.Hash) (*RPCTransactionStatus, error) {
+	tx, _, _, _ := rawdb.ReadTransaction(api.b.ChainDb(), hash)
+	if tx != nil {
+		return newRPCTransactionStatus(api.b.ChainDb(), tx), nil
+	}
+	if api.b.GetPoolTransaction(hash) != nil {
+		return &RPCTransactionStatus{Status: TransactionStatusPending}, nil
+	}
+	return nil, nil
+}
+
+// newRPCTransactionStatus returns the status of a transaction that is in the
+// chain. The batch membership is only used if the transaction at its index
+// is still the same one, as the index may have been reorganized since.
+func newRPCTransactionStatus(db ethdb.Reader, tx *types.Transaction) *RPCTransactionStatus {
+	status := &RPCTransactionStatus{Status: TransactionStatusSequenced}
+	meta := tx.GetMeta()
+	if meta == nil || meta.Index == nil {
+		return status
+	}
+	index := *meta.Index
+	status.Index = (*hexutil.Uint64)(&index)
+	if entry := rawdb.ReadCTCLookupEntry(db, index); entry == nil || entry.TxHash != tx.Hash() {
+		return status
+	}
+	entry := rawdb.ReadTxBatchEntry(db, index)
+	if entry == nil {
+		return status
+	}
+	status.Status = TransactionStatusBatched
+	status.BatchIndex = (*hexutil.Uint64)(&entry.BatchIndex)
+	status.BatchL1BlockNumber = (*hexutil.Uint64)(&entry.L1BlockNumber)
+	status.BatchSubmitter = &entry.Submitter
+	return status
+}
@@ -25,9 +25,11 @@ import (
 	"github.com/MetisProtocol/l2geth/common"
 	"github.com/MetisProtocol/l2geth/core"
 	"github.com/MetisProtocol/l2geth/core/bloombits"
+	"github.com/MetisProtocol/l2geth/core/rawdb"
 	"github.com/MetisProtocol/l2geth/core/state"
 	"github.com/MetisProtocol/l2geth/core/types"
 	"github.com/MetisProtocol/l2geth/core/vm"
+	"github.com/MetisProtocol/l2geth/diffdb"
 	"github.com/MetisProtocol/l2geth/eth/downloader"
 	"github.com/MetisProtocol/l2geth/ethdb"
 	"github.com/MetisProtocol/l2geth/event"
@@ -95,7 +97,10 @@ type Backend interface {
 	SetL1GasPrice(context.Context, *big.Int) error
 	SuggestL2GasPrice(context.Context) (*big.Int, error)
 	SetL2GasPrice(context.Context, *big.Int) error
+	FeeThresholds() (*big.Float, *big.Float, error)
+	GasPriceHistory(ctx context.Context, count int, newest uint64) ([]*rawdb.GasPriceSample, error)
 	IngestTransactions([]*types.Transaction) error
+	GetDiff(*big.Int) (diffdb.Diff, error)
 }
 
 func GetAPIs(apiBackend Backend) []rpc.API {
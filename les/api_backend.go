@@ -30,6 +30,7 @@ import (
 	"github.com/MetisProtocol/l2geth/core/state"
 	"github.com/MetisProtocol/l2geth/core/types"
 	"github.com/MetisProtocol/l2geth/core/vm"
+	"github.com/MetisProtocol/l2geth/diffdb"
 	"github.com/MetisProtocol/l2geth/eth/downloader"
 	"github.com/MetisProtocol/l2geth/eth/gasprice"
 	"github.com/MetisProtocol/l2geth/ethdb"
@@ -86,6 +87,10 @@ func (b *LesApiBackend) IngestTransactions([]*types.Transaction) error {
 	panic("not implemented")
 }
 
+func (b *LesApiBackend) GetDiff(*big.Int) (diffdb.Diff, error) {
+	return nil, errors.New("Diffs not supported in light client mode")
+}
+
 func (b *LesApiBackend) HeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Header, error) {
 	if number == rpc.LatestBlockNumber || number == rpc.PendingBlockNumber {
 		return b.eth.blockchain.CurrentHeader(), nil
@@ -296,6 +301,16 @@ func (b *LesApiBackend) SetL2GasPrice(ctx context.Context, gasPrice *big.Int) er
 	panic("SetExecutionPrice is not implemented")
 }
 
+// NB: Non sequencer nodes do not verify fees.
+func (b *LesApiBackend) FeeThresholds() (*big.Float, *big.Float, error) {
+	return nil, nil, errors.New("Fee thresholds not supported in light client mode")
+}
+
+// NB: Non sequencer nodes do not track the gas price history.
+func (b *LesApiBackend) GasPriceHistory(ctx context.Context, count int, newest uint64) ([]*rawdb.GasPriceSample, error) {
+	return nil, errors.New("Gas price history not supported in light client mode")
+}
+
 func (b *LesApiBackend) ChainDb() ethdb.Database {
 	return b.eth.chainDb
 }
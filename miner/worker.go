@@ -34,6 +34,7 @@ import (
 	"github.com/MetisProtocol/l2geth/event"
 	"github.com/MetisProtocol/l2geth/log"
 	"github.com/MetisProtocol/l2geth/params"
+	"github.com/MetisProtocol/l2geth/rollup"
 	mapset "github.com/deckarep/golang-set"
 )
 
@@ -75,6 +76,11 @@ const (
 
 	// staleThreshold is the maximum depth of the acceptable stale block.
 	staleThreshold = 7
+
+	// rollupBlockTimeout is the maximum time to wait for the block that
+	// includes a rollup transaction to be added to the chain when the
+	// request carries no deadline.
+	rollupBlockTimeout = 30 * time.Second
 )
 
 // environment is the worker's current environment and holds all of the current state information.
@@ -140,8 +146,7 @@ type worker struct {
 	chainHeadSub event.Subscription
 	chainSideCh  chan core.ChainSideEvent
 	chainSideSub event.Subscription
-	rollupCh     chan core.NewTxsEvent
-	rollupSub    event.Subscription
+	rollupCh     <-chan rollup.TxCommitRequest
 
 	// Channels
 	newWorkCh          chan *newWorkReq
@@ -196,7 +201,6 @@ func newWorker(config *Config, chainConfig *params.ChainConfig, engine consensus
 		unconfirmed:        newUnconfirmedBlocks(eth.BlockChain(), miningLogAtDepth),
 		pendingTasks:       make(map[common.Hash]*task),
 		txsCh:              make(chan core.NewTxsEvent, txChanSize),
-		rollupCh:           make(chan core.NewTxsEvent, 1),
 		chainHeadCh:        make(chan core.ChainHeadEvent, chainHeadChanSize),
 		chainSideCh:        make(chan core.ChainSideEvent, chainSideChanSize),
 		newWorkCh:          make(chan *newWorkReq),
@@ -209,8 +213,8 @@ func newWorker(config *Config, chainConfig *params.ChainConfig, engine consensus
 	}
 	// Subscribe NewTxsEvent for tx pool
 	worker.txsSub = eth.TxPool().SubscribeNewTxsEvent(worker.txsCh)
-	// channel directly to the miner
-	worker.rollupSub = eth.SyncService().SubscribeNewTxsEvent(worker.rollupCh)
+	// Receive the transactions to commit directly from the sync service
+	worker.rollupCh = eth.SyncService().TxCommitRequests()
 
 	// Subscribe events for blockchain
 	worker.chainHeadSub = eth.BlockChain().SubscribeChainHeadEvent(worker.chainHeadCh)
@@ -416,7 +420,6 @@ func (w *worker) mainLoop() {
 	defer w.txsSub.Unsubscribe()
 	defer w.chainHeadSub.Unsubscribe()
 	defer w.chainSideSub.Unsubscribe()
-	defer w.rollupSub.Unsubscribe()
 
 	for {
 		select {
@@ -461,53 +464,11 @@ func (w *worker) mainLoop() {
 					w.commit(uncles, nil, true, start)
 				}
 			}
-		// Read from the sync service and mine single txs
-		// as they come. Wait for the block to be mined before
-		// reading the next tx from the channel when there is
-		// not an error processing the transaction.
-		case ev := <-w.rollupCh:
-			if len(ev.Txs) == 0 {
-				log.Warn("No transaction sent to miner from syncservice")
-				continue
-			}
-			tx := ev.Txs[0]
-			log.Debug("Attempting to commit rollup transaction", "hash", tx.Hash().Hex())
-			// Build the block with the tx and add it to the chain. This will
-			// send the block through the `taskCh` and then through the
-			// `resultCh` which ultimately adds the block to the blockchain
-			// through `bc.WriteBlockWithState`
-			if err := w.commitNewTx(tx); err == nil {
-				// `chainHeadCh` is written to when a new block is added to the
-				// tip of the chain. Reading from the channel will block until
-				// the ethereum block is added to the chain downstream of `commitNewTx`.
-				// This will result in a deadlock if we call `commitNewTx` with
-				// a transaction that cannot be added to the chain, so this
-				// should be updated to a select statement that can also listen
-				// for errors.
-				head := <-w.chainHeadCh
-				txs := head.Block.Transactions()
-				if len(txs) == 0 {
-					log.Warn("No transactions in block")
-					continue
-				}
-				txn := txs[0]
-				height := head.Block.Number().Uint64()
-				log.Debug("Miner got new head", "height", height, "block-hash", head.Block.Hash().Hex(), "tx-hash", txn.Hash().Hex(), "tx-hash", tx.Hash().Hex())
-
-				// Prevent memory leak by cleaning up pending tasks
-				// This is mostly copied from the `newWorkLoop`
-				// `clearPending` function and must be called
-				// periodically to clean up pending tasks. This
-				// function was originally called in `newWorkLoop`
-				// but the OVM implementation no longer uses that code path.
-				w.pendingMu.Lock()
-				for h := range w.pendingTasks {
-					delete(w.pendingTasks, h)
-				}
-				w.pendingMu.Unlock()
-			} else {
-				log.Debug("Problem committing transaction: %w", err)
-			}
+		// Read from the sync service and mine txs as they come. Every
+		// request is answered with the block that includes the tx or with an
+		// error so that the sync service never waits forever.
+		case req := <-w.rollupCh:
+			w.handleRollupRequests(req)
 
 		case ev := <-w.txsCh:
 			// Apply transactions to the pending state if we're not mining.
@@ -674,7 +635,7 @@ func (w *worker) resultLoop() {
 
 // makeCurrent creates a new environment for the current cycle.
 func (w *worker) makeCurrent(parent *types.Block, header *types.Header) error {
-	state, err := w.chain.StateAt(parent.Root())
+	state, err := w.chain.StateAtWithDiffDb(parent.Root())
 	if err != nil {
 		return err
 	}
@@ -886,52 +847,206 @@ func (w *worker) commitTransactions(txs *types.TransactionsByPriceAndNonce, coin
 	return false
 }
 
-// commitNewTx is an OVM addition that mines a block with a single tx in it.
-// It needs to return an error in the case there is an error to prevent waiting
-// on reading from a channel that is written to when a new block is added to the
-// chain.
-func (w *worker) commitNewTx(tx *types.Transaction) error {
-	w.mu.RLock()
-	defer w.mu.RUnlock()
-	tstart := time.Now()
+// handleRollupRequests commits the transactions of the requests from the sync
+// service to new blocks and answers every request with the block that includes
+// its transaction or with an error. When multi transaction blocks are enabled,
+// the queue origin sequencer transactions that follow within the block window
+// are included in the same block.
+func (w *worker) handleRollupRequests(req rollup.TxCommitRequest) {
+	for next := &req; next != nil; {
+		var reqs []rollup.TxCommitRequest
+		reqs, next = w.collectRollupRequests(*next)
+		if len(reqs) == 0 {
+			continue
+		}
+		block, err := w.commitRollupRequests(reqs)
+		for _, r := range reqs {
+			r.Result <- rollup.TxCommitResult{Block: block, Err: err}
+		}
+	}
+}
+
+// collectRollupRequests collects the requests whose transactions are included
+// in the same block as the transaction of the first request. It returns the
+// first request that cannot be included in the block, if any.
+func (w *worker) collectRollupRequests(first rollup.TxCommitRequest) ([]rollup.TxCommitRequest, *rollup.TxCommitRequest) {
+	if first.Tx == nil {
+		rejectRollupRequest(first)
+		return nil, nil
+	}
+	reqs := []rollup.TxCommitRequest{first}
+	max := w.eth.SyncService().MaxTxsPerBlock()
+	if max <= 1 || first.Tx.QueueOrigin() != types.QueueOriginSequencer {
+		return reqs, nil
+	}
+	window := time.NewTimer(w.eth.SyncService().BlockWindow())
+	defer window.Stop()
 
+	for len(reqs) < max {
+		select {
+		case req := <-w.rollupCh:
+			if req.Tx == nil {
+				rejectRollupRequest(req)
+				continue
+			}
+			if !sharesRollupBlock(first.Tx, req.Tx) {
+				return reqs, &req
+			}
+			reqs = append(reqs, req)
+		case <-window.C:
+			return reqs, nil
+		case <-w.exitCh:
+			return reqs, nil
+		}
+	}
+	return reqs, nil
+}
+
+// rejectRollupRequest answers a request without a transaction
+func rejectRollupRequest(req rollup.TxCommitRequest) {
+	log.Warn("No transaction sent to miner from syncservice")
+	req.Result <- rollup.TxCommitResult{Err: fmt.Errorf("%w: nil transaction", rollup.ErrTxCommitFailed)}
+}
+
+// sharesRollupBlock returns true if the transaction can be included in the
+// same block as the first transaction of the block. Only queue origin
+// sequencer transactions with the same L1 context share a block, as the
+// timestamp of the block is the L1 timestamp of its transactions.
+func sharesRollupBlock(first, tx *types.Transaction) bool {
+	if tx.QueueOrigin() != types.QueueOriginSequencer || tx.L1Timestamp() != first.L1Timestamp() {
+		return false
+	}
+	a, b := first.L1BlockNumber(), tx.L1BlockNumber()
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Cmp(b) == 0
+}
+
+// commitRollupRequests builds a block with the transactions of the requests
+// and waits until it is added to the chain
+func (w *worker) commitRollupRequests(reqs []rollup.TxCommitRequest) (*types.Block, error) {
+	txs := make(types.Transactions, len(reqs))
+	for i, req := range reqs {
+		txs[i] = req.Tx
+	}
+	log.Debug("Attempting to commit rollup transactions", "count", len(txs), "hash", txs[0].Hash().Hex())
+	// The L1 context of the txs must not go back in time compared to the
+	// parent, the txs of a block share their L1 timestamp.
 	parent := w.chain.CurrentBlock()
-	num := parent.Number()
+	if err := w.eth.SyncService().ContextPolicy().EnforceBlock(parent, reqs); err != nil {
+		log.Error("Problem enforcing L1 context", "count", len(txs), "hash", txs[0].Hash().Hex(), "msg", err)
+		return nil, fmt.Errorf("%w: cannot commit transactions at block %d: %v", rollup.ErrTxCommitFailed, parent.NumberU64()+1, err)
+	}
+	// Build the block with the txs and add it to the chain. This will
+	// send the block through the `taskCh` and then through the
+	// `resultCh` which ultimately adds the block to the blockchain
+	// through `bc.WriteBlockWithState`
+	if err := w.commitNewTxs(txs); err != nil {
+		log.Error("Problem committing transactions", "count", len(txs), "hash", txs[0].Hash().Hex(), "msg", err)
+		return nil, fmt.Errorf("%w: %v", rollup.ErrTxCommitFailed, err)
+	}
+	return w.waitForRollupBlock(txs[len(txs)-1], rollupDeadline(reqs))
+}
 
-	// Preserve liveliness as best as possible. Must panic on L1 to L2
-	// transactions as the timestamp cannot be malleated
-	if parent.Time() > tx.L1Timestamp() {
-		log.Error("Monotonicity violation", "index", num)
-		if tx.QueueOrigin() == types.QueueOriginSequencer {
-			tx.SetL1Timestamp(parent.Time())
-			prev := parent.Transactions()
-			if len(prev) == 1 {
-				tx.SetL1BlockNumber(prev[0].L1BlockNumber().Uint64())
-			} else {
-				log.Error("Cannot recover L1 Blocknumber")
+// rollupDeadline returns the earliest deadline of the requests, so that the
+// worker never waits for a block after one of the callers gave up on it
+func rollupDeadline(reqs []rollup.TxCommitRequest) time.Time {
+	var deadline time.Time
+	for _, req := range reqs {
+		if !req.Deadline.IsZero() && (deadline.IsZero() || req.Deadline.Before(deadline)) {
+			deadline = req.Deadline
+		}
+	}
+	if deadline.IsZero() {
+		deadline = time.Now().Add(rollupBlockTimeout)
+	}
+	return deadline
+}
+
+// waitForRollupBlock waits until the block that includes the rollup
+// transaction is added to the chain. Chain head events for other blocks are
+// skipped, so that a block from an earlier request that timed out cannot be
+// mistaken for the block of this transaction. It gives up at the deadline.
+func (w *worker) waitForRollupBlock(tx *types.Transaction, deadline time.Time) (*types.Block, error) {
+	timeout := time.NewTimer(time.Until(deadline))
+	defer timeout.Stop()
+
+	for {
+		select {
+		case head := <-w.chainHeadCh:
+			if !blockHasTransaction(head.Block, tx.Hash()) {
+				log.Warn("Skipping unexpected chain head", "number", head.Block.NumberU64(), "hash", head.Block.Hash().Hex(), "tx-hash", tx.Hash().Hex())
+				continue
 			}
-		} else {
-			log.Error("Cannot recover from monotonicity violation")
+			log.Debug("Miner got new head", "height", head.Block.NumberU64(), "block-hash", head.Block.Hash().Hex(), "tx-hash", tx.Hash().Hex())
+
+			// Prevent memory leak by cleaning up pending tasks
+			// This is mostly copied from the `newWorkLoop`
+			// `clearPending` function and must be called
+			// periodically to clean up pending tasks. This
+			// function was originally called in `newWorkLoop`
+			// but the OVM implementation no longer uses that code path.
+			w.pendingMu.Lock()
+			for h := range w.pendingTasks {
+				delete(w.pendingTasks, h)
+			}
+			w.pendingMu.Unlock()
+			return head.Block, nil
+		case <-timeout.C:
+			return nil, fmt.Errorf("%w: block with transaction %s not added to chain", rollup.ErrTxCommitTimeout, tx.Hash().Hex())
+		case <-w.exitCh:
+			return nil, fmt.Errorf("%w: miner stopped", rollup.ErrTxCommitFailed)
 		}
 	}
+}
+
+// blockHasTransaction returns true if the block includes the transaction
+func blockHasTransaction(block *types.Block, hash common.Hash) bool {
+	for _, tx := range block.Transactions() {
+		if tx.Hash() == hash {
+			return true
+		}
+	}
+	return false
+}
+
+// commitNewTxs is an OVM addition that mines a block with the txs in it, in
+// the given order. It needs to return an error in the case there is an error
+// to prevent waiting on reading from a channel that is written to when a new
+// block is added to the chain.
+func (w *worker) commitNewTxs(txs types.Transactions) error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	tstart := time.Now()
+
+	parent := w.chain.CurrentBlock()
+	num := parent.Number()
+	first := txs[0]
 
 	// Fill in the index field in the tx meta if it is `nil`.
 	// This should only ever happen in the case of the sequencer
 	// receiving a queue origin sequencer transaction. The verifier
 	// should always receive transactions with an index as they
 	// have already been confirmed in the canonical transaction chain.
-	// Use the parent's block number because the CTC is 0 indexed.
-	if meta := tx.GetMeta(); meta.Index == nil {
-		index := num.Uint64()
-		meta.Index = &index
-		tx.SetTransactionMeta(meta)
+	// The indices must follow the index of the last tx in the parent.
+	next := nextRollupIndex(parent)
+	for i, tx := range txs {
+		index := next + uint64(i)
+		meta := tx.GetMeta()
+		if meta.Index == nil {
+			meta.Index = &index
+			tx.SetTransactionMeta(meta)
+		} else if *meta.Index != index {
+			return fmt.Errorf("Unexpected index %d for transaction %s, expected %d", *meta.Index, tx.Hash().Hex(), index)
+		}
 	}
 	header := &types.Header{
 		ParentHash: parent.Hash(),
 		Number:     new(big.Int).Add(num, common.Big1),
 		GasLimit:   w.config.GasFloor,
 		Extra:      w.extra,
-		Time:       tx.L1Timestamp(),
+		Time:       first.L1Timestamp(),
 	}
 	if err := w.engine.Prepare(w.chain, header); err != nil {
 		return fmt.Errorf("Failed to prepare header for mining: %w", err)
@@ -941,16 +1056,32 @@ func (w *worker) commitNewTx(tx *types.Transaction) error {
 	if err != nil {
 		return fmt.Errorf("Failed to create mining context: %w", err)
 	}
-	transactions := make(map[common.Address]types.Transactions)
-	acc, _ := types.Sender(w.current.signer, tx)
-	transactions[acc] = types.Transactions{tx}
-	txs := types.NewTransactionsByPriceAndNonce(w.current.signer, transactions)
-	if w.commitTransactions(txs, w.coinbase, nil) {
-		return errors.New("Cannot commit transaction in miner")
+	// Commit the txs one at a time as they must not be reordered by price
+	for _, tx := range txs {
+		acc, _ := types.Sender(w.current.signer, tx)
+		transactions := map[common.Address]types.Transactions{acc: {tx}}
+		if w.commitTransactions(types.NewTransactionsByPriceAndNonce(w.current.signer, transactions), w.coinbase, nil) {
+			return errors.New("Cannot commit transaction in miner")
+		}
+	}
+	if len(w.current.txs) != len(txs) {
+		return fmt.Errorf("Cannot commit %d of %d transactions in miner", len(txs)-len(w.current.txs), len(txs))
 	}
 	return w.commit(nil, w.fullTaskHook, true, tstart)
 }
 
+// nextRollupIndex returns the CTC index of the tx that follows the last tx
+// of the parent block. Blocks with a tx without an index hold a single tx, so
+// the parent's block number is used because the CTC is 0 indexed.
+func nextRollupIndex(parent *types.Block) uint64 {
+	if txs := parent.Transactions(); len(txs) != 0 {
+		if index := txs[len(txs)-1].GetMeta().Index; index != nil {
+			return *index + 1
+		}
+	}
+	return parent.NumberU64()
+}
+
 // commitNewWork generates several new sealing tasks based on the parent block.
 func (w *worker) commitNewWork(interrupt *int32, noempty bool, timestamp int64) {
 	w.mu.RLock()
@@ -1099,15 +1230,19 @@ func (w *worker) commit(uncles []*types.Header, interval func(), update bool, st
 			feesEth := new(big.Float).Quo(new(big.Float).SetInt(feesWei), new(big.Float).SetInt(big.NewInt(params.Ether)))
 
 			txs := block.Transactions()
-			if len(txs) != 1 {
-				return fmt.Errorf("Block created with not %d transactions at %d", len(txs), block.NumberU64())
+			if len(txs) == 0 {
+				return fmt.Errorf("Block created without transactions at %d", block.NumberU64())
 			}
-			tx := txs[0]
+			tx := txs[len(txs)-1]
 			bn := tx.L1BlockNumber()
 			if bn == nil {
 				bn = new(big.Int)
 			}
-			log.Info("New block", "index", block.Number().Uint64()-uint64(1), "l1-timestamp", tx.L1Timestamp(), "l1-blocknumber", bn.Uint64(), "tx-hash", tx.Hash().Hex(),
+			index := block.NumberU64() - 1
+			if meta := tx.GetMeta(); meta.Index != nil {
+				index = *meta.Index
+			}
+			log.Info("New block", "index", index, "txs", len(txs), "l1-timestamp", tx.L1Timestamp(), "l1-blocknumber", bn.Uint64(), "tx-hash", tx.Hash().Hex(),
 				"queue-orign", tx.QueueOrigin(), "gas", block.GasUsed(), "fees", feesEth, "elapsed", common.PrettyDuration(time.Since(start)))
 
 		case <-w.exitCh:
@@ -537,3 +537,17 @@ func testAdjustInterval(t *testing.T, chainConfig *params.ChainConfig, engine co
 		t.Error("interval reset timeout")
 	}
 }
+
+// The worker waits for a rollup block until the earliest deadline of its
+// requests, so that it never outlasts the SyncService
+func TestRollupDeadline(t *testing.T) {
+	now := time.Now()
+	reqs := []rollup.TxCommitRequest{{}, {Deadline: now.Add(2 * time.Second)}, {Deadline: now.Add(time.Second)}}
+	if deadline := rollupDeadline(reqs); !deadline.Equal(now.Add(time.Second)) {
+		t.Fatalf("deadline mismatch: have %v, want %v", deadline, now.Add(time.Second))
+	}
+	// Requests without a deadline fall back to the default timeout
+	if deadline := rollupDeadline(reqs[:1]); deadline.Before(now.Add(rollupBlockTimeout)) {
+		t.Fatalf("deadline %v before the default timeout", deadline)
+	}
+}
@@ -90,7 +90,7 @@ type KeyStore struct{ keystore *keystore.KeyStore }
 
 // NewKeyStore creates a keystore for the given directory.
 func NewKeyStore(keydir string, scryptN, scryptP int) *KeyStore {
-	return &KeyStore{keystore: keystore.NewKeyStore(keydir, scryptN, scryptP)}
+	return &KeyStore{keystore: keystore.NewKeyStore(keydir, scryptN, scryptP, false)}
 }
 
 // HasAddress reports whether a key with the given address is present.
@@ -95,6 +95,10 @@ type Config struct {
 	// NoUSB disables hardware wallet monitoring and connectivity.
 	NoUSB bool `toml:",omitempty"`
 
+	// UsingOVM adds the deterministic clique signer key of OVM nodes to the
+	// keystore.
+	UsingOVM bool `toml:",omitempty"`
+
 	// SmartCardDaemonPath is the path to the smartcard daemon's socket
 	SmartCardDaemonPath string `toml:",omitempty"`
 
@@ -493,7 +497,7 @@ func makeAccountManager(conf *Config) (*accounts.Manager, string, error) {
 		// If/when we implement some form of lockfile for USB and keystore wallets,
 		// we can have both, but it's very confusing for the user to see the same
 		// accounts in both externally and locally, plus very racey.
-		backends = append(backends, keystore.NewKeyStore(keydir, scryptN, scryptP))
+		backends = append(backends, keystore.NewKeyStore(keydir, scryptN, scryptP, conf.UsingOVM))
 		if !conf.NoUSB {
 			// Start a USB hub for Ledger hardware wallets
 			if ledgerhub, err := usbwallet.NewLedgerHub(); err != nil {
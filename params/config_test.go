@@ -70,6 +70,17 @@ func TestCheckCompatible(t *testing.T) {
 				RewindTo:     9,
 			},
 		},
+		{
+			stored: &ChainConfig{},
+			new:    &ChainConfig{OVMBlock: big.NewInt(0)},
+			head:   100,
+			wantErr: &ConfigCompatError{
+				What:         "OVM switch block",
+				StoredConfig: nil,
+				NewConfig:    big.NewInt(0),
+				RewindTo:     0,
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -79,3 +90,16 @@ func TestCheckCompatible(t *testing.T) {
 		}
 	}
 }
+
+func TestOVMRules(t *testing.T) {
+	config := &ChainConfig{OVMBlock: big.NewInt(10)}
+	if config.Rules(big.NewInt(9)).IsOVM {
+		t.Fatal("OVM rules before the switch block")
+	}
+	if !config.Rules(big.NewInt(10)).IsOVM {
+		t.Fatal("no OVM rules at the switch block")
+	}
+	if TestChainConfig.Rules(big.NewInt(10)).IsOVM {
+		t.Fatal("OVM rules without a switch block")
+	}
+}
@@ -42,6 +42,15 @@ type Batch struct {
 	Submitter         common.Address `json:"submitter"`
 }
 
+// StateRoot represents a state root that was submitted to layer one as part
+// of a state commitment batch. The index is the Canonical Transaction Chain
+// index of the transaction that results in the state root.
+type StateRoot struct {
+	Index      uint64      `json:"index"`
+	BatchIndex uint64      `json:"batchIndex"`
+	Value      common.Hash `json:"value"`
+}
+
 // EthContext represents the L1 EVM context that is injected into
 // the OVM at runtime. It is updated with each `enqueue` transaction
 // and needs to be fetched from a remote server to be updated when
@@ -130,10 +139,43 @@ type RollupClient interface {
 	GetLatestTransactionBatch() (*Batch, []*types.Transaction, error)
 	GetLatestTransactionBatchIndex() (*uint64, error)
 	GetTransactionBatch(uint64) (*Batch, []*types.Transaction, error)
+	GetLatestStateRootBatchIndex() (*uint64, error)
+	GetStateRootBatch(uint64) (*Batch, []*StateRoot, error)
 	SyncStatus(Backend) (*SyncStatus, error)
 	GetL1GasPrice() (*big.Int, error)
 }
 
+// notifier is implemented by RollupClients that are pushed new elements. The
+// SyncService syncs as soon as it is notified instead of waiting for the next
+// poll.
+type notifier interface {
+	Notify() <-chan struct{}
+}
+
+// NewRollupClient creates the RollupClient of the configured ClientType
+func NewRollupClient(cfg Config, chainID *big.Int) (RollupClient, error) {
+	switch cfg.ClientType {
+	case ClientHTTP:
+		return newHTTPClient(cfg.RollupClientHttp, cfg.ClientQuorum, chainID)
+	case ClientWebsocket:
+		if cfg.RollupClientWs == "" {
+			return nil, fmt.Errorf("%w: no websocket endpoint for the rollup client", errBadConfig)
+		}
+		http, err := newHTTPClient(cfg.RollupClientHttp, cfg.ClientQuorum, chainID)
+		if err != nil {
+			return nil, err
+		}
+		return NewWSClient(cfg.RollupClientWs, http, chainID), nil
+	case ClientReplay:
+		if cfg.RollupClientReplayPath == "" {
+			return nil, fmt.Errorf("%w: no archive for the replay client", errBadConfig)
+		}
+		return NewReplayClient(cfg.RollupClientReplayPath, chainID)
+	default:
+		return nil, fmt.Errorf("%w: unknown client type %d", errBadConfig, cfg.ClientType)
+	}
+}
+
 // Client is an HTTP based RollupClient
 type Client struct {
 	client  *resty.Client
@@ -155,6 +197,13 @@ type TransactionBatchResponse struct {
 	Transactions []*transaction `json:"transactions"`
 }
 
+// StateRootBatchResponse represents the response from the remote server
+// when querying state root batches.
+type StateRootBatchResponse struct {
+	Batch      *Batch       `json:"batch"`
+	StateRoots []*StateRoot `json:"stateRoots"`
+}
+
 // NewClient create a new Client given a remote HTTP url and a chain id
 func NewClient(url string, chainID *big.Int) *Client {
 	client := resty.New()
@@ -607,6 +656,66 @@ func (c *Client) GetTransactionBatch(index uint64) (*Batch, []*types.Transaction
 	return parseTransactionBatchResponse(txBatch, c.signer)
 }
 
+// GetLatestStateRootBatchIndex returns the index of the latest state root
+// batch
+func (c *Client) GetLatestStateRootBatchIndex() (*uint64, error) {
+	response, err := c.client.R().
+		SetPathParams(map[string]string{
+			"chainId": c.chainID,
+		}).
+		SetResult(&StateRootBatchResponse{}).
+		Get("/batch/stateroot/latest/{chainId}")
+
+	if err != nil {
+		return nil, fmt.Errorf("Cannot get latest state root batch: %w", err)
+	}
+	res, ok := response.Result().(*StateRootBatchResponse)
+	if !ok {
+		return nil, errors.New("Cannot parse state root batch response")
+	}
+	batch, _, err := parseStateRootBatchResponse(res)
+	if err != nil {
+		return nil, err
+	}
+	index := batch.Index
+	return &index, nil
+}
+
+// GetStateRootBatch will return the state root batch by batch index
+func (c *Client) GetStateRootBatch(index uint64) (*Batch, []*StateRoot, error) {
+	str := strconv.FormatUint(index, 10)
+	response, err := c.client.R().
+		SetResult(&StateRootBatchResponse{}).
+		SetPathParams(map[string]string{
+			"index":   str,
+			"chainId": c.chainID,
+		}).
+		Get("/batch/stateroot/index/{index}/{chainId}")
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("Cannot get state root batch %d: %w", index, err)
+	}
+	res, ok := response.Result().(*StateRootBatchResponse)
+	if !ok {
+		return nil, nil, errors.New("Cannot parse state root batch response")
+	}
+	return parseStateRootBatchResponse(res)
+}
+
+// parseStateRootBatchResponse will turn a StateRootBatchResponse into a Batch
+// and its state roots
+func parseStateRootBatchResponse(res *StateRootBatchResponse) (*Batch, []*StateRoot, error) {
+	if res == nil || res.Batch == nil {
+		return nil, nil, errElementNotFound
+	}
+	for _, root := range res.StateRoots {
+		if root == nil {
+			return nil, nil, fmt.Errorf("Cannot parse state root batch %d: nil state root", res.Batch.Index)
+		}
+	}
+	return res.Batch, res.StateRoots, nil
+}
+
 // parseTransactionBatchResponse will turn a TransactionBatchResponse into a
 // Batch and its corresponding types.Transactions
 func parseTransactionBatchResponse(txBatch *TransactionBatchResponse, signer *types.EIP155Signer) (*Batch, []*types.Transaction, error) {
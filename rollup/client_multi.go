@@ -0,0 +1,461 @@
+package rollup
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/MetisProtocol/l2geth/common"
+	"github.com/MetisProtocol/l2geth/core/types"
+	"github.com/MetisProtocol/l2geth/crypto"
+	"github.com/MetisProtocol/l2geth/log"
+	"github.com/MetisProtocol/l2geth/metrics"
+	"github.com/MetisProtocol/l2geth/rlp"
+)
+
+var (
+	// failoverCounter counts the number of times that a request failed over
+	// to the next data transport layer endpoint
+	failoverCounter = metrics.NewRegisteredCounter("rollup/client/failover", nil)
+	// disagreementCounter counts the number of times that the data transport
+	// layer endpoints returned different responses for the same element
+	disagreementCounter = metrics.NewRegisteredCounter("rollup/client/disagreement", nil)
+	// noQuorumCounter counts the number of times that not enough endpoints
+	// agreed on an element
+	noQuorumCounter = metrics.NewRegisteredCounter("rollup/client/noquorum", nil)
+)
+
+// errNoQuorum is returned when not enough data transport layer endpoints
+// agree on the response for an element
+var errNoQuorum = errors.New("no quorum")
+
+// MultiClient is a RollupClient that is backed by several data transport
+// layer endpoints. Requests go to the current endpoint and fail over to the
+// next one in round-robin order on errors. When the quorum is larger than
+// one, transaction batches, state root batches and enqueues are requested
+// from every endpoint and only returned when at least quorum endpoints agree
+// on them, so that a single compromised or lagging endpoint cannot feed a bad
+// element.
+type MultiClient struct {
+	clients []RollupClient
+	names   []string
+	quorum  int
+	current uint32
+}
+
+// NewMultiClient creates a MultiClient from the clients, the names are used
+// for logging
+func NewMultiClient(clients []RollupClient, names []string, quorum int) (*MultiClient, error) {
+	if len(clients) == 0 {
+		return nil, fmt.Errorf("%w: no rollup client endpoints", errBadConfig)
+	}
+	if len(names) != len(clients) {
+		return nil, fmt.Errorf("%w: %d names for %d rollup clients", errBadConfig, len(names), len(clients))
+	}
+	if quorum > len(clients) {
+		return nil, fmt.Errorf("%w: quorum %d larger than %d rollup client endpoints", errBadConfig, quorum, len(clients))
+	}
+	return &MultiClient{
+		clients: clients,
+		names:   names,
+		quorum:  quorum,
+	}, nil
+}
+
+// newHTTPClient creates an HTTP based RollupClient for the comma separated
+// endpoints, several endpoints are combined into a MultiClient
+func newHTTPClient(endpoints string, quorum int, chainID *big.Int) (RollupClient, error) {
+	var urls []string
+	for _, url := range strings.Split(endpoints, ",") {
+		if url = strings.TrimSpace(url); url != "" {
+			urls = append(urls, url)
+		}
+	}
+	if len(urls) <= 1 && quorum <= 1 {
+		return NewClient(strings.TrimSpace(endpoints), chainID), nil
+	}
+	clients := make([]RollupClient, len(urls))
+	for i, url := range urls {
+		clients[i] = NewClient(url, chainID)
+	}
+	return NewMultiClient(clients, urls, quorum)
+}
+
+// failover calls fn with the current client and then with each of the
+// others until one succeeds. The first client to succeed becomes the current
+// client. When every client fails, errElementNotFound is preferred over
+// other errors so that callers can tell a missing element apart from a
+// broken endpoint.
+func (c *MultiClient) failover(method string, fn func(RollupClient) error) error {
+	start := atomic.LoadUint32(&c.current)
+	n := uint32(len(c.clients))
+
+	var errs []error
+	for i := uint32(0); i < n; i++ {
+		idx := (start + i) % n
+		err := fn(c.clients[idx])
+		if err == nil {
+			if i != 0 {
+				atomic.CompareAndSwapUint32(&c.current, start, idx)
+				log.Warn("Failed over rollup client", "method", method, "from", c.names[start], "to", c.names[idx])
+			}
+			return nil
+		}
+		log.Debug("Rollup client request failed", "method", method, "endpoint", c.names[idx], "msg", err)
+		failoverCounter.Inc(1)
+		errs = append(errs, err)
+	}
+	for _, err := range errs {
+		if errors.Is(err, errElementNotFound) {
+			return err
+		}
+	}
+	return errs[len(errs)-1]
+}
+
+// agreement is the response of a single endpoint when checking for a quorum
+type agreement struct {
+	digest common.Hash
+	value  interface{}
+	err    error
+}
+
+// agree requests the element from every endpoint concurrently and returns the
+// response that at least quorum endpoints agree on. The digest identifies a
+// response so that responses can be compared.
+func (c *MultiClient) agree(element string, fn func(RollupClient) (interface{}, common.Hash, error)) (interface{}, error) {
+	responses := make([]agreement, len(c.clients))
+	var wg sync.WaitGroup
+	for i, client := range c.clients {
+		wg.Add(1)
+		go func(i int, client RollupClient) {
+			defer wg.Done()
+			value, digest, err := fn(client)
+			responses[i] = agreement{digest: digest, value: value, err: err}
+		}(i, client)
+	}
+	wg.Wait()
+
+	votes := make(map[common.Hash]int)
+	var errs []error
+	for _, res := range responses {
+		if res.err != nil {
+			errs = append(errs, res.err)
+			continue
+		}
+		votes[res.digest]++
+	}
+	for _, res := range responses {
+		if res.err == nil && votes[res.digest] >= c.quorum {
+			if len(votes) > 1 {
+				c.reportDisagreement(element, responses)
+			}
+			return res.value, nil
+		}
+	}
+
+	if len(votes) > 1 {
+		c.reportDisagreement(element, responses)
+	}
+	noQuorumCounter.Inc(1)
+	// Every endpoint that responded reports that the element does not exist
+	if len(votes) == 0 {
+		for _, err := range errs {
+			if errors.Is(err, errElementNotFound) {
+				return nil, err
+			}
+		}
+	}
+	return nil, fmt.Errorf("%w for %s: %d of %d endpoints responded, %d responses required to agree",
+		errNoQuorum, element, len(c.clients)-len(errs), len(c.clients), c.quorum)
+}
+
+// reportDisagreement logs the response of every endpoint and records the
+// disagreement in the metrics
+func (c *MultiClient) reportDisagreement(element string, responses []agreement) {
+	disagreementCounter.Inc(1)
+	ctx := []interface{}{"element", element}
+	for i, res := range responses {
+		if res.err != nil {
+			ctx = append(ctx, c.names[i], res.err.Error())
+		} else {
+			ctx = append(ctx, c.names[i], res.digest.Hex())
+		}
+	}
+	log.Error("Rollup client endpoints disagree", ctx...)
+}
+
+// transactionDigest commits to a transaction and its meta
+func transactionDigest(tx *types.Transaction) ([]byte, error) {
+	enc, err := rlp.EncodeToBytes(tx)
+	if err != nil {
+		return nil, err
+	}
+	return append(enc, types.TxMetaEncode(tx.GetMeta())...), nil
+}
+
+// GetEnqueue returns the `enqueue` transaction by queue index. When a quorum
+// is configured, enough endpoints must agree on the transaction.
+func (c *MultiClient) GetEnqueue(index uint64) (*types.Transaction, error) {
+	if c.quorum <= 1 {
+		var tx *types.Transaction
+		err := c.failover("GetEnqueue", func(client RollupClient) error {
+			var err error
+			tx, err = client.GetEnqueue(index)
+			return err
+		})
+		return tx, err
+	}
+	value, err := c.agree(fmt.Sprintf("enqueue %d", index), func(client RollupClient) (interface{}, common.Hash, error) {
+		tx, err := client.GetEnqueue(index)
+		if err != nil {
+			return nil, common.Hash{}, err
+		}
+		enc, err := transactionDigest(tx)
+		if err != nil {
+			return nil, common.Hash{}, err
+		}
+		return tx, crypto.Keccak256Hash(enc), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.(*types.Transaction), nil
+}
+
+// batchResponse is a transaction batch along with its transactions
+type batchResponse struct {
+	batch *Batch
+	txs   []*types.Transaction
+}
+
+// GetTransactionBatch returns the transaction batch by index. When a quorum
+// is configured, enough endpoints must agree on the batch and each of its
+// transactions.
+func (c *MultiClient) GetTransactionBatch(index uint64) (*Batch, []*types.Transaction, error) {
+	if c.quorum <= 1 {
+		var (
+			batch *Batch
+			txs   []*types.Transaction
+		)
+		err := c.failover("GetTransactionBatch", func(client RollupClient) error {
+			var err error
+			batch, txs, err = client.GetTransactionBatch(index)
+			return err
+		})
+		return batch, txs, err
+	}
+	value, err := c.agree(fmt.Sprintf("batch %d", index), func(client RollupClient) (interface{}, common.Hash, error) {
+		batch, txs, err := client.GetTransactionBatch(index)
+		if err != nil {
+			return nil, common.Hash{}, err
+		}
+		enc, err := rlp.EncodeToBytes(batch)
+		if err != nil {
+			return nil, common.Hash{}, err
+		}
+		for _, tx := range txs {
+			txEnc, err := transactionDigest(tx)
+			if err != nil {
+				return nil, common.Hash{}, err
+			}
+			enc = append(enc, crypto.Keccak256(txEnc)...)
+		}
+		return &batchResponse{batch: batch, txs: txs}, crypto.Keccak256Hash(enc), nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	res := value.(*batchResponse)
+	return res.batch, res.txs, nil
+}
+
+// GetLatestEnqueue returns the latest `enqueue` transaction
+func (c *MultiClient) GetLatestEnqueue() (*types.Transaction, error) {
+	var tx *types.Transaction
+	err := c.failover("GetLatestEnqueue", func(client RollupClient) error {
+		var err error
+		tx, err = client.GetLatestEnqueue()
+		return err
+	})
+	return tx, err
+}
+
+// GetLatestEnqueueIndex returns the latest `enqueue()` index
+func (c *MultiClient) GetLatestEnqueueIndex() (*uint64, error) {
+	var index *uint64
+	err := c.failover("GetLatestEnqueueIndex", func(client RollupClient) error {
+		var err error
+		index, err = client.GetLatestEnqueueIndex()
+		return err
+	})
+	return index, err
+}
+
+// GetTransaction returns a transaction by Canonical Transaction Chain index
+func (c *MultiClient) GetTransaction(index uint64, backend Backend) (*types.Transaction, error) {
+	var tx *types.Transaction
+	err := c.failover("GetTransaction", func(client RollupClient) error {
+		var err error
+		tx, err = client.GetTransaction(index, backend)
+		return err
+	})
+	return tx, err
+}
+
+// GetLatestTransaction returns the transaction with the greatest Canonical
+// Transaction Chain index
+func (c *MultiClient) GetLatestTransaction(backend Backend) (*types.Transaction, error) {
+	var tx *types.Transaction
+	err := c.failover("GetLatestTransaction", func(client RollupClient) error {
+		var err error
+		tx, err = client.GetLatestTransaction(backend)
+		return err
+	})
+	return tx, err
+}
+
+// GetLatestTransactionIndex returns the latest CTC index
+func (c *MultiClient) GetLatestTransactionIndex(backend Backend) (*uint64, error) {
+	var index *uint64
+	err := c.failover("GetLatestTransactionIndex", func(client RollupClient) error {
+		var err error
+		index, err = client.GetLatestTransactionIndex(backend)
+		return err
+	})
+	return index, err
+}
+
+// GetEthContext returns the EthContext by block number
+func (c *MultiClient) GetEthContext(blockNumber uint64) (*EthContext, error) {
+	var context *EthContext
+	err := c.failover("GetEthContext", func(client RollupClient) error {
+		var err error
+		context, err = client.GetEthContext(blockNumber)
+		return err
+	})
+	return context, err
+}
+
+// GetLatestEthContext returns the latest EthContext
+func (c *MultiClient) GetLatestEthContext() (*EthContext, error) {
+	var context *EthContext
+	err := c.failover("GetLatestEthContext", func(client RollupClient) error {
+		var err error
+		context, err = client.GetLatestEthContext()
+		return err
+	})
+	return context, err
+}
+
+// GetLastConfirmedEnqueue returns the last `enqueue` transaction that has
+// been batched up
+func (c *MultiClient) GetLastConfirmedEnqueue() (*types.Transaction, error) {
+	var tx *types.Transaction
+	err := c.failover("GetLastConfirmedEnqueue", func(client RollupClient) error {
+		var err error
+		tx, err = client.GetLastConfirmedEnqueue()
+		return err
+	})
+	return tx, err
+}
+
+// GetLatestTransactionBatch returns the latest transaction batch
+func (c *MultiClient) GetLatestTransactionBatch() (*Batch, []*types.Transaction, error) {
+	var (
+		batch *Batch
+		txs   []*types.Transaction
+	)
+	err := c.failover("GetLatestTransactionBatch", func(client RollupClient) error {
+		var err error
+		batch, txs, err = client.GetLatestTransactionBatch()
+		return err
+	})
+	return batch, txs, err
+}
+
+// GetLatestTransactionBatchIndex returns the latest transaction batch index
+func (c *MultiClient) GetLatestTransactionBatchIndex() (*uint64, error) {
+	var index *uint64
+	err := c.failover("GetLatestTransactionBatchIndex", func(client RollupClient) error {
+		var err error
+		index, err = client.GetLatestTransactionBatchIndex()
+		return err
+	})
+	return index, err
+}
+
+// stateRootBatchResponse is a state root batch along with its state roots
+type stateRootBatchResponse struct {
+	batch *Batch
+	roots []*StateRoot
+}
+
+// GetLatestStateRootBatchIndex returns the latest state root batch index
+func (c *MultiClient) GetLatestStateRootBatchIndex() (*uint64, error) {
+	var index *uint64
+	err := c.failover("GetLatestStateRootBatchIndex", func(client RollupClient) error {
+		var err error
+		index, err = client.GetLatestStateRootBatchIndex()
+		return err
+	})
+	return index, err
+}
+
+// GetStateRootBatch returns the state root batch by index. When a quorum is
+// configured, enough endpoints must agree on the batch and its state roots.
+func (c *MultiClient) GetStateRootBatch(index uint64) (*Batch, []*StateRoot, error) {
+	if c.quorum <= 1 {
+		var (
+			batch *Batch
+			roots []*StateRoot
+		)
+		err := c.failover("GetStateRootBatch", func(client RollupClient) error {
+			var err error
+			batch, roots, err = client.GetStateRootBatch(index)
+			return err
+		})
+		return batch, roots, err
+	}
+	value, err := c.agree(fmt.Sprintf("state root batch %d", index), func(client RollupClient) (interface{}, common.Hash, error) {
+		batch, roots, err := client.GetStateRootBatch(index)
+		if err != nil {
+			return nil, common.Hash{}, err
+		}
+		enc, err := rlp.EncodeToBytes([]interface{}{batch, roots})
+		if err != nil {
+			return nil, common.Hash{}, err
+		}
+		return &stateRootBatchResponse{batch: batch, roots: roots}, crypto.Keccak256Hash(enc), nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	res := value.(*stateRootBatchResponse)
+	return res.batch, res.roots, nil
+}
+
+// SyncStatus returns the sync status of the current endpoint
+func (c *MultiClient) SyncStatus(backend Backend) (*SyncStatus, error) {
+	var status *SyncStatus
+	err := c.failover("SyncStatus", func(client RollupClient) error {
+		var err error
+		status, err = client.SyncStatus(backend)
+		return err
+	})
+	return status, err
+}
+
+// GetL1GasPrice returns the current gas price on L1
+func (c *MultiClient) GetL1GasPrice() (*big.Int, error) {
+	var gasPrice *big.Int
+	err := c.failover("GetL1GasPrice", func(client RollupClient) error {
+		var err error
+		gasPrice, err = client.GetL1GasPrice()
+		return err
+	})
+	return gasPrice, err
+}
@@ -0,0 +1,142 @@
+package rollup
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/MetisProtocol/l2geth/core/types"
+)
+
+var errTestEndpointDown = errors.New("endpoint down")
+
+// stubClient is a RollupClient that serves enqueues and batches from a
+// ReplayClient unless it is down
+type stubClient struct {
+	*ReplayClient
+	down  bool
+	calls int
+}
+
+func newStubClient(t *testing.T, blockNumber uint64) *stubClient {
+	store := newElementStore(0)
+	tx, err := enqueueToTransaction(testEnqueue(0, blockNumber))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.addEnqueue(tx); err != nil {
+		t.Fatal(err)
+	}
+	signer := types.NewEIP155Signer(big.NewInt(1))
+	batch, txs, err := parseTransactionBatchResponse(testBatchResponse(0, 0, 0, blockNumber), &signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.addBatch(batch, txs); err != nil {
+		t.Fatal(err)
+	}
+	return &stubClient{ReplayClient: &ReplayClient{store: store}}
+}
+
+func (c *stubClient) GetEnqueue(index uint64) (*types.Transaction, error) {
+	c.calls++
+	if c.down {
+		return nil, errTestEndpointDown
+	}
+	return c.ReplayClient.GetEnqueue(index)
+}
+
+func (c *stubClient) GetTransactionBatch(index uint64) (*Batch, []*types.Transaction, error) {
+	c.calls++
+	if c.down {
+		return nil, nil, errTestEndpointDown
+	}
+	return c.ReplayClient.GetTransactionBatch(index)
+}
+
+func newTestMultiClient(t *testing.T, quorum int, stubs ...*stubClient) *MultiClient {
+	clients := make([]RollupClient, len(stubs))
+	names := make([]string, len(stubs))
+	for i, stub := range stubs {
+		clients[i] = stub
+		names[i] = string(rune('a' + i))
+	}
+	client, err := NewMultiClient(clients, names, quorum)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func TestMultiClientFailover(t *testing.T) {
+	a, b := newStubClient(t, 10), newStubClient(t, 10)
+	a.down = true
+	client := newTestMultiClient(t, 1, a, b)
+
+	tx, err := client.GetEnqueue(0)
+	if err != nil {
+		t.Fatal("cannot get enqueue", err)
+	}
+	if *tx.GetMeta().QueueIndex != 0 {
+		t.Fatalf("unexpected queue index: %d", *tx.GetMeta().QueueIndex)
+	}
+	if a.calls != 1 || b.calls != 1 {
+		t.Fatalf("unexpected calls: %d %d", a.calls, b.calls)
+	}
+	// The healthy endpoint is used first from now on
+	if _, _, err := client.GetTransactionBatch(0); err != nil {
+		t.Fatal("cannot get batch", err)
+	}
+	if a.calls != 1 || b.calls != 2 {
+		t.Fatalf("expected no failover, calls: %d %d", a.calls, b.calls)
+	}
+
+	// A missing element is reported as such when no endpoint has it
+	if _, err := client.GetEnqueue(1); !errors.Is(err, errElementNotFound) {
+		t.Fatalf("expected element not found, got %v", err)
+	}
+	b.down = true
+	if _, err := client.GetEnqueue(0); !errors.Is(err, errTestEndpointDown) {
+		t.Fatalf("expected endpoint down, got %v", err)
+	}
+}
+
+func TestMultiClientQuorum(t *testing.T) {
+	a, b, c := newStubClient(t, 10), newStubClient(t, 20), newStubClient(t, 10)
+	client := newTestMultiClient(t, 2, a, b, c)
+
+	tx, err := client.GetEnqueue(0)
+	if err != nil {
+		t.Fatal("cannot get enqueue", err)
+	}
+	if number := tx.GetMeta().L1BlockNumber.Uint64(); number != 10 {
+		t.Fatalf("unexpected enqueue from block %d", number)
+	}
+	batch, txs, err := client.GetTransactionBatch(0)
+	if err != nil {
+		t.Fatal("cannot get batch", err)
+	}
+	if batch.BlockNumber != 10 || len(txs) != 1 {
+		t.Fatalf("unexpected batch from block %d with %d txs", batch.BlockNumber, len(txs))
+	}
+
+	// Not enough endpoints agree once one of the agreeing endpoints is down
+	a.down = true
+	if _, err := client.GetEnqueue(0); !errors.Is(err, errNoQuorum) {
+		t.Fatalf("expected no quorum, got %v", err)
+	}
+	if _, _, err := client.GetTransactionBatch(0); !errors.Is(err, errNoQuorum) {
+		t.Fatalf("expected no quorum, got %v", err)
+	}
+	// A missing element is not a disagreement
+	if _, err := client.GetEnqueue(1); !errors.Is(err, errElementNotFound) {
+		t.Fatalf("expected element not found, got %v", err)
+	}
+}
+
+func TestMultiClientBadQuorum(t *testing.T) {
+	clients := []RollupClient{newStubClient(t, 10)}
+	if _, err := NewMultiClient(clients, []string{"a"}, 2); !errors.Is(err, errBadConfig) {
+		t.Fatalf("expected bad config, got %v", err)
+	}
+}
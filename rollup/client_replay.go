@@ -0,0 +1,298 @@
+package rollup
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+
+	"github.com/MetisProtocol/l2geth/core/types"
+	"github.com/MetisProtocol/l2geth/rlp"
+)
+
+// maxReplayLineSize is the largest JSONL record that can be replayed, it
+// leaves room for a batch of transactions with maximum sized calldata
+const maxReplayLineSize = 64 * 1024 * 1024
+
+// replayRecord is a record of an RLP encoded replay archive. Enqueues and
+// transactions hold a single transaction while batches hold the batch along
+// with all of its transactions. State root batches hold the batch along with
+// its state roots.
+type replayRecord struct {
+	Type       string
+	Batch      Batch
+	Txs        []*replayTransaction
+	StateRoots []*StateRoot `rlp:"tail"`
+}
+
+// replayTransaction is a transaction along with its encoded
+// types.TransactionMeta
+type replayTransaction struct {
+	Tx   *types.Transaction
+	Meta []byte
+}
+
+// ReplayClient is a RollupClient that replays enqueues, transactions, batches
+// and state root batches from an archive on disk. The archive is either a JSONL file where
+// each line holds the type and JSON data of a record as it is served by the
+// data transport layer, or an RLP file written by a ReplayWriter. Files
+// ending in `.rlp` are read as RLP, all others as JSONL. It allows for
+// deterministic offline re-syncs.
+type ReplayClient struct {
+	store *elementStore
+}
+
+// NewReplayClient reads the archive at path into memory and returns a
+// ReplayClient that serves it
+func NewReplayClient(path string, chainID *big.Int) (*ReplayClient, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot open replay archive: %w", err)
+	}
+	defer file.Close()
+
+	signer := types.NewEIP155Signer(chainID)
+	store := newElementStore(0)
+	if filepath.Ext(path) == ".rlp" {
+		err = readRLPRecords(file, store)
+	} else {
+		err = readJSONRecords(file, store, &signer)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Cannot read replay archive %s: %w", path, err)
+	}
+	return &ReplayClient{store: store}, nil
+}
+
+// readJSONRecords adds every line of a JSONL archive to the store
+func readJSONRecords(r io.Reader, store *elementStore, signer *types.EIP155Signer) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, maxReplayLineSize)
+	for line := 1; scanner.Scan(); line++ {
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+		record := new(clientRecord)
+		if err := json.Unmarshal(data, record); err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		if err := store.applyRecord(record, signer); err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+	return scanner.Err()
+}
+
+// readRLPRecords adds every record of an RLP archive to the store
+func readRLPRecords(r io.Reader, store *elementStore) error {
+	stream := rlp.NewStream(bufio.NewReader(r), 0)
+	for i := 0; ; i++ {
+		record := new(replayRecord)
+		if err := stream.Decode(record); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("record %d: %w", i, err)
+		}
+		txs := make([]*types.Transaction, len(record.Txs))
+		for j, rtx := range record.Txs {
+			meta, err := types.TxMetaDecode(rtx.Meta)
+			if err != nil {
+				return fmt.Errorf("record %d: cannot decode meta: %w", i, err)
+			}
+			rtx.Tx.SetTransactionMeta(meta)
+			txs[j] = rtx.Tx
+		}
+		var err error
+		switch record.Type {
+		case recordEnqueue, recordTransaction:
+			if len(txs) != 1 {
+				return fmt.Errorf("record %d: %s with %d transactions", i, record.Type, len(txs))
+			}
+			if record.Type == recordEnqueue {
+				err = store.addEnqueue(txs[0])
+			} else {
+				err = store.addTransaction(txs[0])
+			}
+		case recordBatch:
+			batch := record.Batch
+			err = store.addBatch(&batch, txs)
+		case recordStateRoots:
+			batch := record.Batch
+			store.addStateRootBatch(&batch, record.StateRoots)
+		default:
+			err = fmt.Errorf("Unknown record type: %s", record.Type)
+		}
+		if err != nil {
+			return fmt.Errorf("record %d: %w", i, err)
+		}
+	}
+}
+
+// GetEnqueue returns the `enqueue` transaction by queue index
+func (c *ReplayClient) GetEnqueue(index uint64) (*types.Transaction, error) {
+	return c.store.enqueue(index)
+}
+
+// GetLatestEnqueue returns the `enqueue` transaction with the greatest queue
+// index in the archive
+func (c *ReplayClient) GetLatestEnqueue() (*types.Transaction, error) {
+	return c.store.latestEnqueueTx()
+}
+
+// GetLatestEnqueueIndex returns the greatest queue index in the archive
+func (c *ReplayClient) GetLatestEnqueueIndex() (*uint64, error) {
+	tx, err := c.GetLatestEnqueue()
+	if err != nil {
+		return nil, err
+	}
+	return tx.GetMeta().QueueIndex, nil
+}
+
+// GetTransaction returns the transaction by Canonical Transaction Chain index
+func (c *ReplayClient) GetTransaction(index uint64, backend Backend) (*types.Transaction, error) {
+	return c.store.transaction(index, backend)
+}
+
+// GetLatestTransaction returns the transaction with the greatest Canonical
+// Transaction Chain index in the archive
+func (c *ReplayClient) GetLatestTransaction(backend Backend) (*types.Transaction, error) {
+	return c.store.latestTransactionTx(backend)
+}
+
+// GetLatestTransactionIndex returns the greatest Canonical Transaction Chain
+// index in the archive
+func (c *ReplayClient) GetLatestTransactionIndex(backend Backend) (*uint64, error) {
+	tx, err := c.GetLatestTransaction(backend)
+	if err != nil {
+		return nil, err
+	}
+	return tx.GetMeta().Index, nil
+}
+
+// GetEthContext returns the L1 context at the block number. The archive only
+// holds the contexts that elements were included at, so the closest context
+// is returned when there is no exact match.
+func (c *ReplayClient) GetEthContext(blockNumber uint64) (*EthContext, error) {
+	return c.store.ethContext(blockNumber)
+}
+
+// GetLatestEthContext returns the latest L1 context in the archive
+func (c *ReplayClient) GetLatestEthContext() (*EthContext, error) {
+	return c.store.latestEthContext()
+}
+
+// GetLastConfirmedEnqueue returns the batched `enqueue` transaction with the
+// greatest queue index
+func (c *ReplayClient) GetLastConfirmedEnqueue() (*types.Transaction, error) {
+	return c.store.lastConfirmedEnqueue()
+}
+
+// GetLatestTransactionBatch returns the latest transaction batch in the
+// archive
+func (c *ReplayClient) GetLatestTransactionBatch() (*Batch, []*types.Transaction, error) {
+	index, err := c.store.latestBatchIndex()
+	if err != nil {
+		return nil, nil, err
+	}
+	return c.store.batch(*index)
+}
+
+// GetLatestTransactionBatchIndex returns the index of the latest transaction
+// batch in the archive
+func (c *ReplayClient) GetLatestTransactionBatchIndex() (*uint64, error) {
+	return c.store.latestBatchIndex()
+}
+
+// GetTransactionBatch returns the transaction batch by index
+func (c *ReplayClient) GetTransactionBatch(index uint64) (*Batch, []*types.Transaction, error) {
+	return c.store.batch(index)
+}
+
+// GetLatestStateRootBatchIndex returns the index of the latest state root
+// batch in the archive
+func (c *ReplayClient) GetLatestStateRootBatchIndex() (*uint64, error) {
+	return c.store.latestStateRootBatchIndex()
+}
+
+// GetStateRootBatch returns the state root batch by index
+func (c *ReplayClient) GetStateRootBatch(index uint64) (*Batch, []*StateRoot, error) {
+	return c.store.stateRootBatch(index)
+}
+
+// SyncStatus always reports that the archive is synced
+func (c *ReplayClient) SyncStatus(backend Backend) (*SyncStatus, error) {
+	status := &SyncStatus{}
+	if index, err := c.GetLatestTransactionIndex(backend); err == nil {
+		status.HighestKnownTransactionIndex = *index
+		status.CurrentTransactionIndex = *index
+	}
+	return status, nil
+}
+
+// GetL1GasPrice returns zero, an archive does not hold L1 gas prices
+func (c *ReplayClient) GetL1GasPrice() (*big.Int, error) {
+	return new(big.Int), nil
+}
+
+// ReplayWriter writes enqueues, transactions, batches and state root batches
+// as an RLP archive that can be replayed by a ReplayClient
+type ReplayWriter struct {
+	w io.Writer
+}
+
+// NewReplayWriter creates a ReplayWriter that writes to w
+func NewReplayWriter(w io.Writer) *ReplayWriter {
+	return &ReplayWriter{w: w}
+}
+
+// WriteEnqueue writes an `enqueue` transaction
+func (w *ReplayWriter) WriteEnqueue(tx *types.Transaction) error {
+	return w.write(recordEnqueue, Batch{}, []*types.Transaction{tx})
+}
+
+// WriteTransaction writes a transaction that is not necessarily batched
+func (w *ReplayWriter) WriteTransaction(tx *types.Transaction) error {
+	return w.write(recordTransaction, Batch{}, []*types.Transaction{tx})
+}
+
+// WriteBatch writes a transaction batch along with its transactions
+func (w *ReplayWriter) WriteBatch(batch *Batch, txs []*types.Transaction) error {
+	if batch == nil {
+		return errors.New("Cannot write nil batch")
+	}
+	return w.write(recordBatch, *batch, txs)
+}
+
+// WriteStateRootBatch writes a state root batch along with its state roots
+func (w *ReplayWriter) WriteStateRootBatch(batch *Batch, roots []*StateRoot) error {
+	if batch == nil {
+		return errors.New("Cannot write nil state root batch")
+	}
+	return rlp.Encode(w.w, &replayRecord{
+		Type:       recordStateRoots,
+		Batch:      *batch,
+		Txs:        []*replayTransaction{},
+		StateRoots: roots,
+	})
+}
+
+func (w *ReplayWriter) write(typ string, batch Batch, txs []*types.Transaction) error {
+	record := &replayRecord{
+		Type:  typ,
+		Batch: batch,
+		Txs:   make([]*replayTransaction, len(txs)),
+	}
+	for i, tx := range txs {
+		record.Txs[i] = &replayTransaction{
+			Tx:   tx,
+			Meta: types.TxMetaEncode(tx.GetMeta()),
+		}
+	}
+	return rlp.Encode(w.w, record)
+}
@@ -0,0 +1,270 @@
+package rollup
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/MetisProtocol/l2geth/common"
+	"github.com/MetisProtocol/l2geth/common/hexutil"
+	"github.com/MetisProtocol/l2geth/core/types"
+)
+
+// testEnqueue creates an Enqueue as it is served by the data transport layer
+func testEnqueue(queueIndex, blockNumber uint64) *Enqueue {
+	target := common.HexToAddress("0x4200000000000000000000000000000000000007")
+	origin := common.HexToAddress("0x0000000000000000000000000000000000000001")
+	data := hexutil.Bytes{0x01, 0x02}
+	gasLimit := uint64(100000)
+	timestamp := blockNumber * 15
+	return &Enqueue{
+		Target:      &target,
+		Data:        &data,
+		GasLimit:    &gasLimit,
+		Origin:      &origin,
+		BlockNumber: &blockNumber,
+		Timestamp:   &timestamp,
+		QueueIndex:  &queueIndex,
+	}
+}
+
+// testBatchResponse creates a batch that includes the enqueue with the queue
+// index at CTC index ctcIndex
+func testBatchResponse(batchIndex, ctcIndex, queueIndex, blockNumber uint64) *TransactionBatchResponse {
+	origin := common.HexToAddress("0x0000000000000000000000000000000000000001")
+	return &TransactionBatchResponse{
+		Batch: &Batch{
+			Index:       batchIndex,
+			Root:        common.Hash{0x01},
+			Size:        1,
+			BlockNumber: blockNumber,
+			Timestamp:   blockNumber * 15,
+			Submitter:   common.HexToAddress("0x0000000000000000000000000000000000000002"),
+		},
+		Transactions: []*transaction{
+			{
+				Index:       ctcIndex,
+				BatchIndex:  batchIndex,
+				BlockNumber: blockNumber,
+				Timestamp:   blockNumber * 15,
+				Value:       (*hexutil.Big)(new(big.Int)),
+				GasLimit:    100000,
+				Target:      common.HexToAddress("0x4200000000000000000000000000000000000007"),
+				Origin:      &origin,
+				Data:        hexutil.Bytes{0x01, 0x02},
+				QueueOrigin: l1,
+				QueueIndex:  &queueIndex,
+			},
+		},
+	}
+}
+
+func writeJSONRecord(t *testing.T, f *os.File, typ string, data interface{}) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	line, err := json.Marshal(&clientRecord{Type: typ, Data: raw})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func checkReplayClient(t *testing.T, client RollupClient) {
+	enqueue, err := client.GetEnqueue(1)
+	if err != nil {
+		t.Fatal("cannot get enqueue", err)
+	}
+	if *enqueue.GetMeta().QueueIndex != 1 {
+		t.Fatalf("unexpected queue index: %d", *enqueue.GetMeta().QueueIndex)
+	}
+	if _, err := client.GetEnqueue(5); err != errElementNotFound {
+		t.Fatalf("expected element not found, got %v", err)
+	}
+	queueIndex, err := client.GetLatestEnqueueIndex()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *queueIndex != 1 {
+		t.Fatalf("unexpected latest queue index: %d", *queueIndex)
+	}
+
+	batchIndex, err := client.GetLatestTransactionBatchIndex()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *batchIndex != 1 {
+		t.Fatalf("unexpected latest batch index: %d", *batchIndex)
+	}
+	batch, txs, err := client.GetTransactionBatch(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if batch.Index != 0 || len(txs) != 1 {
+		t.Fatalf("unexpected batch %d with %d txs", batch.Index, len(txs))
+	}
+
+	tx, err := client.GetTransaction(1, BackendL1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *tx.GetMeta().Index != 1 || *tx.GetMeta().QueueIndex != 1 {
+		t.Fatal("unexpected transaction")
+	}
+	index, err := client.GetLatestTransactionIndex(BackendL1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *index != 1 {
+		t.Fatalf("unexpected latest index: %d", *index)
+	}
+
+	confirmed, err := client.GetLastConfirmedEnqueue()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *confirmed.GetMeta().QueueIndex != 1 {
+		t.Fatalf("unexpected last confirmed enqueue: %d", *confirmed.GetMeta().QueueIndex)
+	}
+
+	// The closest context below the requested block number is used
+	context, err := client.GetEthContext(15)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if context.BlockNumber != 10 || context.Timestamp != 150 {
+		t.Fatalf("unexpected eth context: %d %d", context.BlockNumber, context.Timestamp)
+	}
+	latest, err := client.GetLatestEthContext()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if latest.BlockNumber != 20 {
+		t.Fatalf("unexpected latest eth context: %d", latest.BlockNumber)
+	}
+	status, err := client.SyncStatus(BackendL1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Syncing || status.HighestKnownTransactionIndex != 1 {
+		t.Fatal("unexpected sync status")
+	}
+}
+
+func TestReplayClientJSONL(t *testing.T) {
+	dir, err := ioutil.TempDir("", "replay")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "archive.jsonl")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeJSONRecord(t, f, recordEnqueue, testEnqueue(0, 10))
+	writeJSONRecord(t, f, recordEnqueue, testEnqueue(1, 20))
+	writeJSONRecord(t, f, recordBatch, testBatchResponse(0, 0, 0, 10))
+	writeJSONRecord(t, f, recordBatch, testBatchResponse(1, 1, 1, 20))
+	f.Close()
+
+	client, err := NewReplayClient(path, big.NewInt(1))
+	if err != nil {
+		t.Fatal("cannot create replay client", err)
+	}
+	checkReplayClient(t, client)
+}
+
+func TestReplayClientRLP(t *testing.T) {
+	dir, err := ioutil.TempDir("", "replay")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "archive.rlp")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer := types.NewEIP155Signer(big.NewInt(1))
+	writer := NewReplayWriter(f)
+	for i := uint64(0); i < 2; i++ {
+		tx, err := enqueueToTransaction(testEnqueue(i, (i+1)*10))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := writer.WriteEnqueue(tx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := uint64(0); i < 2; i++ {
+		batch, txs, err := parseTransactionBatchResponse(testBatchResponse(i, i, i, (i+1)*10), &signer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := writer.WriteBatch(batch, txs); err != nil {
+			t.Fatal(err)
+		}
+	}
+	f.Close()
+
+	client, err := NewReplayClient(path, big.NewInt(1))
+	if err != nil {
+		t.Fatal("cannot create replay client", err)
+	}
+	checkReplayClient(t, client)
+}
+
+func TestReplayClientBadRecord(t *testing.T) {
+	dir, err := ioutil.TempDir("", "replay")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "archive.jsonl")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeJSONRecord(t, f, "unknown", struct{}{})
+	f.Close()
+
+	if _, err := NewReplayClient(path, big.NewInt(1)); err == nil {
+		t.Fatal("expected error for unknown record type")
+	}
+}
+
+func TestElementStoreLimit(t *testing.T) {
+	store := newElementStore(2)
+	for i := uint64(0); i < 4; i++ {
+		tx, err := enqueueToTransaction(testEnqueue(i, i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := store.addEnqueue(tx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := uint64(0); i < 2; i++ {
+		if _, err := store.enqueue(i); err != errElementNotFound {
+			t.Fatalf("expected enqueue %d to be evicted", i)
+		}
+	}
+	for i := uint64(2); i < 4; i++ {
+		if _, err := store.enqueue(i); err != nil {
+			t.Fatalf("expected enqueue %d to be stored", i)
+		}
+	}
+	if len(store.contexts) != 2 {
+		t.Fatalf("expected 2 contexts, got %d", len(store.contexts))
+	}
+}
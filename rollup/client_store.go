@@ -0,0 +1,401 @@
+package rollup
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/MetisProtocol/l2geth/core/types"
+)
+
+// Record types that are used by the push and replay based RollupClients to
+// describe the element that a record holds
+const (
+	recordEnqueue     = "enqueue"
+	recordTransaction = "transaction"
+	recordBatch       = "batch"
+	recordStateRoots  = "stateRootBatch"
+)
+
+// clientRecord is a single element as it is pushed by the data transport
+// layer over a websocket or as it is stored as a line of a JSONL archive.
+// The data holds an Enqueue, a TransactionResponse, a
+// TransactionBatchResponse or a StateRootBatchResponse depending on the type.
+type clientRecord struct {
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+// storedBatch is a batch with its transactions
+type storedBatch struct {
+	batch *Batch
+	txs   []*types.Transaction
+}
+
+// storedStateRootBatch is a state root batch with its state roots
+type storedStateRootBatch struct {
+	batch *Batch
+	roots []*StateRoot
+}
+
+// elementStore holds the enqueues, transactions, batches and state root
+// batches that were pushed to or replayed by a RollupClient, indexed the same
+// way that the data transport layer indexes them. When the limit is set, only
+// the latest limit elements of each kind are kept around.
+type elementStore struct {
+	lock  sync.RWMutex
+	limit uint64
+
+	enqueues     map[uint64]*types.Transaction
+	transactions map[uint64]*types.Transaction
+	batched      map[uint64]*types.Transaction
+	batches      map[uint64]*storedBatch
+	stateRoots   map[uint64]*storedStateRootBatch
+	contexts     map[uint64]*EthContext
+
+	latestEnqueue     *uint64
+	latestTransaction *uint64
+	latestBatched     *uint64
+	latestBatch       *uint64
+	latestStateRoots  *uint64
+	latestContext     *uint64
+}
+
+// newElementStore creates an elementStore that keeps the latest limit
+// elements of each kind, a limit of 0 keeps every element
+func newElementStore(limit uint64) *elementStore {
+	return &elementStore{
+		limit:        limit,
+		enqueues:     make(map[uint64]*types.Transaction),
+		transactions: make(map[uint64]*types.Transaction),
+		batched:      make(map[uint64]*types.Transaction),
+		batches:      make(map[uint64]*storedBatch),
+		stateRoots:   make(map[uint64]*storedStateRootBatch),
+		contexts:     make(map[uint64]*EthContext),
+	}
+}
+
+// applyRecord decodes a clientRecord and adds the element that it holds
+func (s *elementStore) applyRecord(record *clientRecord, signer *types.EIP155Signer) error {
+	switch record.Type {
+	case recordEnqueue:
+		enqueue := new(Enqueue)
+		if err := json.Unmarshal(record.Data, enqueue); err != nil {
+			return fmt.Errorf("Cannot decode enqueue: %w", err)
+		}
+		tx, err := enqueueToTransaction(enqueue)
+		if err != nil {
+			return fmt.Errorf("Cannot parse enqueue: %w", err)
+		}
+		return s.addEnqueue(tx)
+	case recordTransaction:
+		res := new(TransactionResponse)
+		if err := json.Unmarshal(record.Data, res); err != nil {
+			return fmt.Errorf("Cannot decode transaction: %w", err)
+		}
+		tx, err := batchedTransactionToTransaction(res.Transaction, signer)
+		if err != nil {
+			return fmt.Errorf("Cannot parse transaction: %w", err)
+		}
+		return s.addTransaction(tx)
+	case recordBatch:
+		res := new(TransactionBatchResponse)
+		if err := json.Unmarshal(record.Data, res); err != nil {
+			return fmt.Errorf("Cannot decode transaction batch: %w", err)
+		}
+		batch, txs, err := parseTransactionBatchResponse(res, signer)
+		if err != nil {
+			return err
+		}
+		return s.addBatch(batch, txs)
+	case recordStateRoots:
+		res := new(StateRootBatchResponse)
+		if err := json.Unmarshal(record.Data, res); err != nil {
+			return fmt.Errorf("Cannot decode state root batch: %w", err)
+		}
+		batch, roots, err := parseStateRootBatchResponse(res)
+		if err != nil {
+			return err
+		}
+		s.addStateRootBatch(batch, roots)
+		return nil
+	default:
+		return fmt.Errorf("Unknown record type: %s", record.Type)
+	}
+}
+
+// addEnqueue adds an `enqueue` transaction by its queue index
+func (s *elementStore) addEnqueue(tx *types.Transaction) error {
+	meta := tx.GetMeta()
+	if meta.QueueIndex == nil {
+		return fmt.Errorf("Cannot add enqueue %s without queue index", tx.Hash().Hex())
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.put(s.enqueues, &s.latestEnqueue, *meta.QueueIndex, tx)
+	s.addContext(meta)
+	return nil
+}
+
+// addTransaction adds a transaction that has not necessarily been batched
+// yet by its Canonical Transaction Chain index
+func (s *elementStore) addTransaction(tx *types.Transaction) error {
+	meta := tx.GetMeta()
+	if meta.Index == nil {
+		return fmt.Errorf("Cannot add transaction %s without index", tx.Hash().Hex())
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.put(s.transactions, &s.latestTransaction, *meta.Index, tx)
+	s.addContext(meta)
+	return nil
+}
+
+// addBatch adds a transaction batch by its index along with each of the
+// batched transactions
+func (s *elementStore) addBatch(batch *Batch, txs []*types.Transaction) error {
+	for _, tx := range txs {
+		if tx.GetMeta().Index == nil {
+			return fmt.Errorf("Cannot add batch %d with unindexed transaction", batch.Index)
+		}
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.latestBatch == nil || batch.Index > *s.latestBatch {
+		index := batch.Index
+		s.latestBatch = &index
+	}
+	s.batches[batch.Index] = &storedBatch{batch: batch, txs: txs}
+	if s.limit != 0 && batch.Index >= s.limit {
+		delete(s.batches, batch.Index-s.limit)
+	}
+	for _, tx := range txs {
+		meta := tx.GetMeta()
+		s.put(s.batched, &s.latestBatched, *meta.Index, tx)
+		s.addContext(meta)
+	}
+	return nil
+}
+
+// addStateRootBatch adds a state root batch by its index
+func (s *elementStore) addStateRootBatch(batch *Batch, roots []*StateRoot) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.latestStateRoots == nil || batch.Index > *s.latestStateRoots {
+		index := batch.Index
+		s.latestStateRoots = &index
+	}
+	s.stateRoots[batch.Index] = &storedStateRootBatch{batch: batch, roots: roots}
+	if s.limit != 0 && batch.Index >= s.limit {
+		delete(s.stateRoots, batch.Index-s.limit)
+	}
+}
+
+// put adds an element to one of the maps and moves the latest index forward,
+// evicting the element that fell out of the limit
+func (s *elementStore) put(elements map[uint64]*types.Transaction, latest **uint64, index uint64, tx *types.Transaction) {
+	elements[index] = tx
+	if *latest == nil || index > **latest {
+		*latest = &index
+	}
+	if s.limit != 0 && index >= s.limit {
+		delete(elements, index-s.limit)
+	}
+}
+
+// addContext records the L1 context of a transaction
+func (s *elementStore) addContext(meta *types.TransactionMeta) {
+	if meta.L1BlockNumber == nil {
+		return
+	}
+	number := meta.L1BlockNumber.Uint64()
+	s.contexts[number] = &EthContext{
+		BlockNumber: number,
+		Timestamp:   meta.L1Timestamp,
+	}
+	if s.latestContext == nil || number > *s.latestContext {
+		s.latestContext = &number
+	}
+	if s.limit != 0 && uint64(len(s.contexts)) > s.limit {
+		oldest := number
+		for n := range s.contexts {
+			if n < oldest {
+				oldest = n
+			}
+		}
+		delete(s.contexts, oldest)
+	}
+}
+
+// enqueue returns the `enqueue` transaction by queue index
+func (s *elementStore) enqueue(index uint64) (*types.Transaction, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	tx, ok := s.enqueues[index]
+	if !ok {
+		return nil, errElementNotFound
+	}
+	return tx, nil
+}
+
+// latestEnqueueTx returns the `enqueue` transaction with the greatest
+// queue index
+func (s *elementStore) latestEnqueueTx() (*types.Transaction, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	if s.latestEnqueue == nil {
+		return nil, errElementNotFound
+	}
+	return s.enqueues[*s.latestEnqueue], nil
+}
+
+// transaction returns a transaction by Canonical Transaction Chain index.
+// Only batched transactions are returned for BackendL1.
+func (s *elementStore) transaction(index uint64, backend Backend) (*types.Transaction, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	if tx, ok := s.batched[index]; ok {
+		return tx, nil
+	}
+	if backend == BackendL2 {
+		if tx, ok := s.transactions[index]; ok {
+			return tx, nil
+		}
+	}
+	return nil, errElementNotFound
+}
+
+// latestTransactionTx returns the transaction with the greatest Canonical
+// Transaction Chain index. Only batched transactions are considered for
+// BackendL1.
+func (s *elementStore) latestTransactionTx(backend Backend) (*types.Transaction, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	latest := s.latestBatched
+	elements := s.batched
+	if backend == BackendL2 && s.latestTransaction != nil {
+		if latest == nil || *s.latestTransaction > *latest {
+			latest = s.latestTransaction
+			elements = s.transactions
+		}
+	}
+	if latest == nil {
+		return nil, errElementNotFound
+	}
+	return elements[*latest], nil
+}
+
+// batch returns a transaction batch by index
+func (s *elementStore) batch(index uint64) (*Batch, []*types.Transaction, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	stored, ok := s.batches[index]
+	if !ok {
+		return nil, nil, errElementNotFound
+	}
+	return stored.batch, stored.txs, nil
+}
+
+// latestBatchIndex returns the index of the latest transaction batch
+func (s *elementStore) latestBatchIndex() (*uint64, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	if s.latestBatch == nil {
+		return nil, errElementNotFound
+	}
+	index := *s.latestBatch
+	return &index, nil
+}
+
+// stateRootBatch returns a state root batch by index
+func (s *elementStore) stateRootBatch(index uint64) (*Batch, []*StateRoot, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	stored, ok := s.stateRoots[index]
+	if !ok {
+		return nil, nil, errElementNotFound
+	}
+	return stored.batch, stored.roots, nil
+}
+
+// latestStateRootBatchIndex returns the index of the latest state root batch
+func (s *elementStore) latestStateRootBatchIndex() (*uint64, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	if s.latestStateRoots == nil {
+		return nil, errElementNotFound
+	}
+	index := *s.latestStateRoots
+	return &index, nil
+}
+
+// lastConfirmedEnqueue returns the batched L1 to L2 transaction with the
+// greatest queue index
+func (s *elementStore) lastConfirmedEnqueue() (*types.Transaction, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	var confirmed *types.Transaction
+	for _, tx := range s.batched {
+		meta := tx.GetMeta()
+		if meta.QueueOrigin != types.QueueOriginL1ToL2 || meta.QueueIndex == nil {
+			continue
+		}
+		if confirmed == nil || *meta.QueueIndex > *confirmed.GetMeta().QueueIndex {
+			confirmed = tx
+		}
+	}
+	if confirmed == nil {
+		return nil, errElementNotFound
+	}
+	return confirmed, nil
+}
+
+// ethContext returns the L1 context at the block number. When no element
+// was included at that block number, the closest context before it is
+// returned, or the earliest known context when there is none before it.
+func (s *elementStore) ethContext(blockNumber uint64) (*EthContext, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	if context, ok := s.contexts[blockNumber]; ok {
+		return context, nil
+	}
+	if len(s.contexts) == 0 {
+		return nil, errElementNotFound
+	}
+	numbers := make([]uint64, 0, len(s.contexts))
+	for n := range s.contexts {
+		numbers = append(numbers, n)
+	}
+	sort.Slice(numbers, func(i, j int) bool { return numbers[i] < numbers[j] })
+	i := sort.Search(len(numbers), func(i int) bool { return numbers[i] > blockNumber })
+	if i == 0 {
+		return s.contexts[numbers[0]], nil
+	}
+	return s.contexts[numbers[i-1]], nil
+}
+
+// latestEthContext returns the L1 context with the greatest block number
+func (s *elementStore) latestEthContext() (*EthContext, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	if s.latestContext == nil {
+		return nil, errElementNotFound
+	}
+	return s.contexts[*s.latestContext], nil
+}
@@ -0,0 +1,301 @@
+package rollup
+
+import (
+	"math/big"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/MetisProtocol/l2geth/core/types"
+	"github.com/MetisProtocol/l2geth/log"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// wsCacheLimit is the number of pushed elements of each kind that the
+	// WSClient keeps in memory
+	wsCacheLimit = 4096
+	// wsMinRetryDelay and wsMaxRetryDelay bound the backoff between attempts
+	// to reconnect to the data transport layer
+	wsMinRetryDelay = time.Second
+	wsMaxRetryDelay = time.Minute
+)
+
+// WSClient is a RollupClient that subscribes to the data transport layer over
+// a websocket. The data transport layer pushes every enqueue, transaction,
+// batch and state root batch as a JSON encoded record as soon as it indexes
+// them. The pushed elements are served from memory while the HTTP Client is
+// used for older elements, for the L1 context, the sync status and the L1 gas
+// price and whenever the websocket is disconnected.
+type WSClient struct {
+	url    string
+	http   RollupClient
+	signer *types.EIP155Signer
+	store  *elementStore
+
+	connected int32
+	connLock  sync.Mutex
+	conn      *websocket.Conn
+
+	notify chan struct{}
+	quit   chan struct{}
+	wg     sync.WaitGroup
+}
+
+// NewWSClient creates a WSClient that subscribes to the websocket url and
+// falls back to the HTTP based RollupClient. It starts connecting in the
+// background and keeps reconnecting until it is closed.
+func NewWSClient(url string, http RollupClient, chainID *big.Int) *WSClient {
+	signer := types.NewEIP155Signer(chainID)
+	c := &WSClient{
+		url:    url,
+		http:   http,
+		signer: &signer,
+		store:  newElementStore(wsCacheLimit),
+		notify: make(chan struct{}, 1),
+		quit:   make(chan struct{}),
+	}
+	c.wg.Add(1)
+	go c.loop()
+	return c
+}
+
+// Notify returns a channel that receives when new elements were pushed. The
+// SyncService uses it to sync without waiting for the next poll.
+func (c *WSClient) Notify() <-chan struct{} {
+	return c.notify
+}
+
+// Close disconnects from the data transport layer
+func (c *WSClient) Close() error {
+	close(c.quit)
+	c.connLock.Lock()
+	if c.conn != nil {
+		c.conn.Close()
+	}
+	c.connLock.Unlock()
+	c.wg.Wait()
+	return nil
+}
+
+// loop keeps the websocket connected and applies the pushed records
+func (c *WSClient) loop() {
+	defer c.wg.Done()
+
+	delay := wsMinRetryDelay
+	for {
+		conn, _, err := websocket.DefaultDialer.Dial(c.url, nil)
+		if err != nil {
+			log.Warn("Cannot connect to data transport layer websocket", "url", c.url, "retry", delay, "msg", err)
+			select {
+			case <-time.After(delay):
+			case <-c.quit:
+				return
+			}
+			if delay *= 2; delay > wsMaxRetryDelay {
+				delay = wsMaxRetryDelay
+			}
+			continue
+		}
+		delay = wsMinRetryDelay
+
+		c.connLock.Lock()
+		select {
+		case <-c.quit:
+			c.connLock.Unlock()
+			conn.Close()
+			return
+		default:
+		}
+		c.conn = conn
+		c.connLock.Unlock()
+
+		log.Info("Connected to data transport layer websocket", "url", c.url)
+		c.seed()
+		atomic.StoreInt32(&c.connected, 1)
+		c.read(conn)
+		atomic.StoreInt32(&c.connected, 0)
+
+		select {
+		case <-c.quit:
+			return
+		default:
+			log.Warn("Disconnected from data transport layer websocket", "url", c.url)
+		}
+	}
+}
+
+// seed adds the latest elements to the store so that the latest indices are
+// correct before any element is pushed
+func (c *WSClient) seed() {
+	if tx, err := c.http.GetLatestEnqueue(); err == nil {
+		c.store.addEnqueue(tx)
+	}
+	if tx, err := c.http.GetLatestTransaction(BackendL2); err == nil {
+		c.store.addTransaction(tx)
+	}
+	if batch, txs, err := c.http.GetLatestTransactionBatch(); err == nil {
+		c.store.addBatch(batch, txs)
+	}
+	if index, err := c.http.GetLatestStateRootBatchIndex(); err == nil {
+		if batch, roots, err := c.http.GetStateRootBatch(*index); err == nil {
+			c.store.addStateRootBatch(batch, roots)
+		}
+	}
+}
+
+// read applies the pushed records until the connection breaks
+func (c *WSClient) read(conn *websocket.Conn) {
+	defer conn.Close()
+	for {
+		record := new(clientRecord)
+		if err := conn.ReadJSON(record); err != nil {
+			log.Debug("Cannot read from data transport layer websocket", "msg", err)
+			return
+		}
+		if err := c.store.applyRecord(record, c.signer); err != nil {
+			log.Error("Cannot apply pushed element", "type", record.Type, "msg", err)
+			continue
+		}
+		select {
+		case c.notify <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// isConnected returns true when pushed elements are being received
+func (c *WSClient) isConnected() bool {
+	return atomic.LoadInt32(&c.connected) == 1
+}
+
+// GetEnqueue returns the `enqueue` transaction by queue index
+func (c *WSClient) GetEnqueue(index uint64) (*types.Transaction, error) {
+	if tx, err := c.store.enqueue(index); err == nil {
+		return tx, nil
+	}
+	return c.http.GetEnqueue(index)
+}
+
+// GetLatestEnqueue returns the `enqueue` transaction with the greatest queue
+// index
+func (c *WSClient) GetLatestEnqueue() (*types.Transaction, error) {
+	if c.isConnected() {
+		if tx, err := c.store.latestEnqueueTx(); err == nil {
+			return tx, nil
+		}
+	}
+	return c.http.GetLatestEnqueue()
+}
+
+// GetLatestEnqueueIndex returns the greatest queue index
+func (c *WSClient) GetLatestEnqueueIndex() (*uint64, error) {
+	if c.isConnected() {
+		if tx, err := c.store.latestEnqueueTx(); err == nil {
+			return tx.GetMeta().QueueIndex, nil
+		}
+	}
+	return c.http.GetLatestEnqueueIndex()
+}
+
+// GetTransaction returns the transaction by Canonical Transaction Chain index
+func (c *WSClient) GetTransaction(index uint64, backend Backend) (*types.Transaction, error) {
+	if tx, err := c.store.transaction(index, backend); err == nil {
+		return tx, nil
+	}
+	return c.http.GetTransaction(index, backend)
+}
+
+// GetLatestTransaction returns the transaction with the greatest Canonical
+// Transaction Chain index
+func (c *WSClient) GetLatestTransaction(backend Backend) (*types.Transaction, error) {
+	if c.isConnected() {
+		if tx, err := c.store.latestTransactionTx(backend); err == nil {
+			return tx, nil
+		}
+	}
+	return c.http.GetLatestTransaction(backend)
+}
+
+// GetLatestTransactionIndex returns the greatest Canonical Transaction Chain
+// index
+func (c *WSClient) GetLatestTransactionIndex(backend Backend) (*uint64, error) {
+	if c.isConnected() {
+		if tx, err := c.store.latestTransactionTx(backend); err == nil {
+			return tx.GetMeta().Index, nil
+		}
+	}
+	return c.http.GetLatestTransactionIndex(backend)
+}
+
+// GetEthContext returns the EthContext by block number
+func (c *WSClient) GetEthContext(blockNumber uint64) (*EthContext, error) {
+	return c.http.GetEthContext(blockNumber)
+}
+
+// GetLatestEthContext returns the latest EthContext
+func (c *WSClient) GetLatestEthContext() (*EthContext, error) {
+	return c.http.GetLatestEthContext()
+}
+
+// GetLastConfirmedEnqueue returns the last `enqueue` transaction that has
+// been batched up
+func (c *WSClient) GetLastConfirmedEnqueue() (*types.Transaction, error) {
+	return c.http.GetLastConfirmedEnqueue()
+}
+
+// GetLatestTransactionBatch returns the latest transaction batch
+func (c *WSClient) GetLatestTransactionBatch() (*Batch, []*types.Transaction, error) {
+	if c.isConnected() {
+		if index, err := c.store.latestBatchIndex(); err == nil {
+			return c.store.batch(*index)
+		}
+	}
+	return c.http.GetLatestTransactionBatch()
+}
+
+// GetLatestTransactionBatchIndex returns the latest transaction batch index
+func (c *WSClient) GetLatestTransactionBatchIndex() (*uint64, error) {
+	if c.isConnected() {
+		if index, err := c.store.latestBatchIndex(); err == nil {
+			return index, nil
+		}
+	}
+	return c.http.GetLatestTransactionBatchIndex()
+}
+
+// GetTransactionBatch returns the transaction batch by batch index
+func (c *WSClient) GetTransactionBatch(index uint64) (*Batch, []*types.Transaction, error) {
+	if batch, txs, err := c.store.batch(index); err == nil {
+		return batch, txs, nil
+	}
+	return c.http.GetTransactionBatch(index)
+}
+
+// GetLatestStateRootBatchIndex returns the latest state root batch index
+func (c *WSClient) GetLatestStateRootBatchIndex() (*uint64, error) {
+	if c.isConnected() {
+		if index, err := c.store.latestStateRootBatchIndex(); err == nil {
+			return index, nil
+		}
+	}
+	return c.http.GetLatestStateRootBatchIndex()
+}
+
+// GetStateRootBatch returns the state root batch by batch index
+func (c *WSClient) GetStateRootBatch(index uint64) (*Batch, []*StateRoot, error) {
+	if batch, roots, err := c.store.stateRootBatch(index); err == nil {
+		return batch, roots, nil
+	}
+	return c.http.GetStateRootBatch(index)
+}
+
+// SyncStatus queries the remote server to determine if it is still syncing
+func (c *WSClient) SyncStatus(backend Backend) (*SyncStatus, error) {
+	return c.http.SyncStatus(backend)
+}
+
+// GetL1GasPrice returns the current gas price on L1
+func (c *WSClient) GetL1GasPrice() (*big.Int, error) {
+	return c.http.GetL1GasPrice()
+}
@@ -0,0 +1,86 @@
+package rollup
+
+import (
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestWSClientPush(t *testing.T) {
+	records := make(chan *clientRecord, 2)
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Everything but the websocket is not found so that the client
+		// has to serve the pushed elements
+		if r.URL.Path != "/ws" {
+			http.NotFound(w, r)
+			return
+		}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for record := range records {
+			if err := conn.WriteJSON(record); err != nil {
+				return
+			}
+		}
+	}))
+	defer server.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws"
+	client := NewWSClient(wsURL, NewClient(server.URL, big.NewInt(1)), big.NewInt(1))
+	defer client.Close()
+	// Keep the connection open until the client is closed
+	defer close(records)
+
+	data, _ := json.Marshal(testEnqueue(0, 10))
+	records <- &clientRecord{Type: recordEnqueue, Data: data}
+	data, _ = json.Marshal(testBatchResponse(0, 0, 0, 10))
+	records <- &clientRecord{Type: recordBatch, Data: data}
+
+	// Both records may be applied before the first notification is read
+	for {
+		if _, _, err := client.store.batch(0); err == nil {
+			break
+		}
+		select {
+		case <-client.Notify():
+		case <-time.After(5 * time.Second):
+			t.Fatal("no element was pushed")
+		}
+	}
+
+	enqueue, err := client.GetEnqueue(0)
+	if err != nil {
+		t.Fatal("cannot get pushed enqueue", err)
+	}
+	if *enqueue.GetMeta().QueueIndex != 0 {
+		t.Fatal("unexpected queue index")
+	}
+	index, err := client.GetLatestTransactionBatchIndex()
+	if err != nil {
+		t.Fatal("cannot get pushed batch", err)
+	}
+	if *index != 0 {
+		t.Fatal("unexpected batch index")
+	}
+	tx, err := client.GetTransaction(0, BackendL1)
+	if err != nil {
+		t.Fatal("cannot get pushed transaction", err)
+	}
+	if *tx.GetMeta().Index != 0 {
+		t.Fatal("unexpected index")
+	}
+	// Elements that were not pushed fall back to HTTP
+	if _, err := client.GetEnqueue(1); err == nil {
+		t.Fatal("expected error for element that was not pushed")
+	}
+}
@@ -12,14 +12,26 @@ type Config struct {
 	MaxCallDataSize int
 	// Verifier mode
 	IsVerifier bool
+	// Apply the OVM rules from the genesis block on
+	UsingOVM bool
+	// Override of the deployer whitelist, nil leaves it to the whitelist
+	EnableArbitraryContractDeployment *bool
 	// Enable the sync service
 	Eth1SyncServiceEnable bool
 	// Ensure that the correct layer 1 chain is being connected to
 	Eth1ChainId uint64
 	// Gas Limit
 	GasLimit uint64
-	// HTTP endpoint of the data transport layer
-	RollupClientHttp              string
+	// Comma separated HTTP endpoints of the data transport layer
+	RollupClientHttp string
+	// Number of endpoints that must agree on batches and enqueues
+	ClientQuorum int
+	// Transport used to fetch data from the data transport layer
+	ClientType ClientType
+	// Websocket endpoint of the data transport layer
+	RollupClientWs string
+	// Path to the archive that is replayed by the replay client
+	RollupClientReplayPath        string
 	L1CrossDomainMessengerAddress common.Address
 	L1FeeWalletAddress            common.Address
 	AddressManagerOwnerAddress    common.Address
@@ -29,10 +41,50 @@ type Config struct {
 	EnableL2GasPolling bool
 	// Deployment Height of the canonical transaction chain
 	CanonicalTransactionChainDeployHeight *big.Int
-	// Path to the state dump
+	// Path to the state dump, either a HTTP URL, a file URL or a file path
 	StateDumpPath string
+	// Expected keccak256 hash of the decompressed state dump, the state dump
+	// is not verified if it is empty
+	StateDumpHash common.Hash
 	// Polling interval for rollup client
 	PollInterval time.Duration
+	// Time to wait for the miner to include a transaction in a block
+	TxCommitTimeout time.Duration
+	// Maximum number of queue origin sequencer transactions in a block, more
+	// than one enables multi transaction blocks on the sequencer
+	MaxTxsPerBlock int
+	// Maximum time to wait for more transactions before sealing a multi
+	// transaction block
+	BlockWindow time.Duration
+	// Maximum time that the sequencer holds a transaction whose nonce is
+	// ahead of the nonce of its sender, zero disables queueing them
+	FutureTxLifetime time.Duration
+	// Maximum number of future nonce transactions that are held per sender
+	MaxFutureTxsPerSender int
+	// Maximum number of future nonce transactions that are held for all
+	// senders, the transactions of the least recently active senders are
+	// evicted above it
+	MaxFutureTxs int
+	// Policy that orders the transactions the sequencer accepted, one of
+	// fifo, fee and roundrobin
+	OrderingPolicy string
+	// Maximum time that a L1 to L2 transaction waits behind sequencer
+	// transactions, zero keeps them in the order of arrival
+	EnqueueMaxDelay time.Duration
+	// Force inclusion period of the canonical transaction chain, after which
+	// anyone can append an enqueue on L1. Zero disables tracking the deadlines
+	ForceInclusionPeriod time.Duration
+	// Time before the force inclusion deadline from which an enqueue is
+	// applied ahead of sequencer transactions
+	ForceInclusionMargin time.Duration
+	// Policy for transactions whose L1 context goes back in time, either clamp
+	// or reject
+	ContextPolicy string
+	// Number of concurrent workers that fetch elements while syncing, a
+	// single worker fetches one element at a time
+	PrefetchWorkers int
+	// Number of elements that are fetched ahead of the element being applied
+	PrefetchDepth int
 	// Interval for updating the timestamp
 	TimestampRefreshThreshold time.Duration
 	// Represents the source of the transactions that is being synced
@@ -48,4 +100,27 @@ type Config struct {
 	// quoted and the transaction being executed
 	FeeThresholdDown *big.Float
 	FeeThresholdUp   *big.Float
+	// Smoothing of the L1 gas price before it is used for fees. A new L1 gas
+	// price is averaged with the previous one by its weight, its change is
+	// limited to a percentage of the previous one and it is bounded by the
+	// floor and ceiling. Zero values and nil bounds disable each step.
+	L1GasPriceEMAWeight float64
+	L1GasPriceMaxChange float64
+	L1GasPriceFloor     *big.Int
+	L1GasPriceCeiling   *big.Int
+	// Number of state diff insertions committed together, state diffs
+	// are only recorded when this is set
+	DiffDbCache uint64
+	// Stop syncing when a state root submitted to L1 does not match the
+	// locally computed state root
+	HaltOnStateRootMismatch bool
+}
+
+// OVMBlock returns the OVM switch block that the config enforces, nil when the
+// chain configuration decides
+func (c *Config) OVMBlock() *big.Int {
+	if !c.UsingOVM {
+		return nil
+	}
+	return new(big.Int)
 }
@@ -0,0 +1,91 @@
+package dump
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/MetisProtocol/l2geth/common"
+	"github.com/MetisProtocol/l2geth/crypto"
+)
+
+// ErrHashMismatch represents the error case of a state dump that does not
+// match the hash that it is expected to have
+var ErrHashMismatch = errors.New("state dump hash mismatch")
+
+// gzipMagic is the header of gzip compressed data
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// Read returns the JSON encoded state dump at path. The path is either a HTTP
+// or HTTPS URL, a file URL or a plain file path. Gzip compressed state dumps
+// are decompressed. When hash is set, the keccak256 hash of the decompressed
+// state dump must match it.
+func Read(path string, hash common.Hash) ([]byte, error) {
+	var (
+		data []byte
+		err  error
+	)
+	switch {
+	case strings.HasPrefix(path, "http://"), strings.HasPrefix(path, "https://"):
+		data, err = fetch(path)
+	case strings.HasPrefix(path, "file://"):
+		data, err = ioutil.ReadFile(strings.TrimPrefix(path, "file://"))
+	default:
+		data, err = ioutil.ReadFile(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Unable to read state dump: %w", err)
+	}
+	if bytes.HasPrefix(data, gzipMagic) {
+		reader, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("Unable to decompress state dump: %w", err)
+		}
+		defer reader.Close()
+		if data, err = ioutil.ReadAll(reader); err != nil {
+			return nil, fmt.Errorf("Unable to decompress state dump: %w", err)
+		}
+	}
+	if hash != (common.Hash{}) {
+		if have := crypto.Keccak256Hash(data); have != hash {
+			return nil, fmt.Errorf("%w: have %s, want %s", ErrHashMismatch, have.Hex(), hash.Hex())
+		}
+	}
+	return data, nil
+}
+
+// Decode parses a JSON encoded state dump
+func Decode(data []byte) (*OvmDump, error) {
+	stateDump := new(OvmDump)
+	if err := json.Unmarshal(data, stateDump); err != nil {
+		return nil, fmt.Errorf("Unable to unmarshal state dump: %w", err)
+	}
+	return stateDump, nil
+}
+
+// Load reads the state dump at path with Read and decodes it
+func Load(path string, hash common.Hash) (*OvmDump, error) {
+	data, err := Read(path, hash)
+	if err != nil {
+		return nil, err
+	}
+	return Decode(data)
+}
+
+// fetch returns the body of a HTTP GET request to url
+func fetch(url string) ([]byte, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to GET state dump: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("State dump not found: %s", resp.Status)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
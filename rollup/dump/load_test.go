@@ -0,0 +1,95 @@
+package dump
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/MetisProtocol/l2geth/common"
+	"github.com/MetisProtocol/l2geth/crypto"
+)
+
+var testStateDump = []byte(`{"accounts":{"OVM_StateManager":{"address":"0x4200000000000000000000000000000000000001","code":"0x00","nonce":1}}}`)
+
+func TestRead(t *testing.T) {
+	dir, err := ioutil.TempDir("", "state-dump-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	plain := filepath.Join(dir, "dump.json")
+	if err := ioutil.WriteFile(plain, testStateDump, 0644); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	w.Write(testStateDump)
+	w.Close()
+	compressed := filepath.Join(dir, "dump.json.gz")
+	if err := ioutil.WriteFile(compressed, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(testStateDump)
+	}))
+	defer server.Close()
+
+	hash := crypto.Keccak256Hash(testStateDump)
+	for _, path := range []string{plain, "file://" + plain, compressed, server.URL} {
+		data, err := Read(path, hash)
+		if err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		if !bytes.Equal(data, testStateDump) {
+			t.Fatalf("%s: unexpected state dump %s", path, data)
+		}
+	}
+	if _, err := Read(plain, common.Hash{}); err != nil {
+		t.Fatalf("unverified state dump: %v", err)
+	}
+}
+
+func TestReadTampered(t *testing.T) {
+	dir, err := ioutil.TempDir("", "state-dump-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "dump.json")
+	tampered := bytes.Replace(testStateDump, []byte(`"nonce":1`), []byte(`"nonce":2`), 1)
+	if err := ioutil.WriteFile(path, tampered, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Read(path, crypto.Keccak256Hash(testStateDump)); !errors.Is(err, ErrHashMismatch) {
+		t.Fatalf("expected hash mismatch, got %v", err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "state-dump-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "dump.json")
+	if err := ioutil.WriteFile(path, testStateDump, 0644); err != nil {
+		t.Fatal(err)
+	}
+	stateDump, err := Load(path, common.Hash{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	account, ok := stateDump.Accounts["OVM_StateManager"]
+	if !ok || account.Nonce != 1 || account.Address != common.HexToAddress("0x4200000000000000000000000000000000000001") {
+		t.Fatalf("unexpected state dump: %v", stateDump)
+	}
+}
@@ -0,0 +1,125 @@
+package dump
+
+import (
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/MetisProtocol/l2geth/common"
+	"github.com/MetisProtocol/l2geth/crypto"
+)
+
+// RequiredAccounts are the accounts that the genesis state and the OVM
+// execution context of the EVM are built from
+var RequiredAccounts = []string{
+	"Lib_AddressManager",
+	"OVM_ExecutionManager",
+	"OVM_StateManager",
+	"OVM_SafetyChecker",
+	"OVM_SequencerEntrypoint",
+	"OVM_L2CrossDomainMessenger",
+	"OVM_ETH",
+	"OVM_L2StandardBridge",
+}
+
+// StateManagerMethods are the methods of the OVM_StateManager that are
+// implemented natively by the EVM
+var StateManagerMethods = []string{
+	"owner",
+	"setAccountNonce",
+	"getAccountNonce",
+	"getAccountEthAddress",
+	"getContractStorage",
+	"putContractStorage",
+	"isAuthenticated",
+	"hasAccount",
+	"hasEmptyAccount",
+	"hasContractStorage",
+	"testAndSetAccountLoaded",
+	"testAndSetAccountChanged",
+	"testAndSetContractStorageLoaded",
+	"testAndSetContractStorageChanged",
+	"incrementTotalUncommittedAccounts",
+	"incrementTotalUncommittedContractStorage",
+	"initPendingAccount",
+	"commitPendingAccount",
+}
+
+// RequiredMethods are the ABI methods of the accounts that are packed or
+// dispatched by the node
+var RequiredMethods = map[string][]string{
+	"OVM_ExecutionManager": {"run", "simulateMessage"},
+	"OVM_StateManager":     StateManagerMethods,
+}
+
+// ValidationError lists every problem that was found in a state dump
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid state dump: %s", strings.Join(e.Problems, "; "))
+}
+
+// Validate checks that the state dump has every required account with the
+// methods that the node depends on in its ABI, that the code hash of every
+// account matches its code and that all storage values are words. Storage
+// keys are already checked to be words when the state dump is decoded.
+func Validate(stateDump *OvmDump) error {
+	var problems []string
+	for _, name := range RequiredAccounts {
+		if _, ok := stateDump.Accounts[name]; !ok {
+			problems = append(problems, fmt.Sprintf("%s not in state dump", name))
+		}
+	}
+	// Iterate in a stable order so that the problems are reproducible
+	names := make([]string, 0, len(stateDump.Accounts))
+	for name := range stateDump.Accounts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		account := stateDump.Accounts[name]
+		for _, method := range RequiredMethods[name] {
+			if _, ok := account.ABI.Methods[method]; !ok {
+				problems = append(problems, fmt.Sprintf("%s: method %s not in ABI", name, method))
+			}
+		}
+		code, err := decodeHex(account.Code)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: invalid code: %v", name, err))
+		} else if account.CodeHash != "" {
+			if have, want := crypto.Keccak256Hash(code), common.HexToHash(account.CodeHash); have != want {
+				problems = append(problems, fmt.Sprintf("%s: code hash mismatch: have %s, want %s", name, have.Hex(), want.Hex()))
+			}
+		}
+		keys := make([]common.Hash, 0, len(account.Storage))
+		for key := range account.Storage {
+			keys = append(keys, key)
+		}
+		sort.Slice(keys, func(i, j int) bool { return keys[i].Big().Cmp(keys[j].Big()) < 0 })
+		for _, key := range keys {
+			value, err := decodeHex(account.Storage[key])
+			if err != nil || len(value) > common.HashLength {
+				problems = append(problems, fmt.Sprintf("%s: invalid storage value at %s: %q", name, key.Hex(), account.Storage[key]))
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// decodeHex decodes hex strings the way that common.FromHex does when the
+// genesis state is created but reports invalid characters
+func decodeHex(s string) ([]byte, error) {
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		s = s[2:]
+	}
+	if len(s)%2 == 1 {
+		s = "0" + s
+	}
+	return hex.DecodeString(s)
+}
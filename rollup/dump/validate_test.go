@@ -0,0 +1,82 @@
+package dump
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/MetisProtocol/l2geth/accounts/abi"
+	"github.com/MetisProtocol/l2geth/common"
+	"github.com/MetisProtocol/l2geth/common/hexutil"
+	"github.com/MetisProtocol/l2geth/crypto"
+)
+
+func validStateDump() *OvmDump {
+	code := []byte{0x60, 0x00}
+	stateDump := &OvmDump{Accounts: make(map[string]OvmDumpAccount)}
+	for _, name := range RequiredAccounts {
+		methods := make(map[string]abi.Method)
+		for _, method := range RequiredMethods[name] {
+			methods[method] = abi.Method{Name: method, RawName: method}
+		}
+		stateDump.Accounts[name] = OvmDumpAccount{
+			Code:     hexutil.Encode(code),
+			CodeHash: crypto.Keccak256Hash(code).Hex(),
+			Storage:  map[common.Hash]string{{}: "0x01"},
+			ABI:      abi.ABI{Methods: methods},
+		}
+	}
+	return stateDump
+}
+
+func TestValidate(t *testing.T) {
+	tests := map[string]struct {
+		modify  func(*OvmDump)
+		problem string
+	}{
+		"valid": {
+			modify: func(*OvmDump) {},
+		},
+		"missing-account": {
+			modify:  func(d *OvmDump) { delete(d.Accounts, "OVM_ETH") },
+			problem: "OVM_ETH not in state dump",
+		},
+		"missing-method": {
+			modify: func(d *OvmDump) {
+				delete(d.Accounts["OVM_ExecutionManager"].ABI.Methods, "simulateMessage")
+			},
+			problem: "OVM_ExecutionManager: method simulateMessage not in ABI",
+		},
+		"code-hash": {
+			modify: func(d *OvmDump) {
+				account := d.Accounts["OVM_ETH"]
+				account.Code = "0x6001"
+				d.Accounts["OVM_ETH"] = account
+			},
+			problem: "OVM_ETH: code hash mismatch",
+		},
+		"storage-value": {
+			modify: func(d *OvmDump) {
+				d.Accounts["OVM_ETH"].Storage[common.Hash{1}] = "0x" + strings.Repeat("00", 33)
+			},
+			problem: "OVM_ETH: invalid storage value",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			stateDump := validStateDump()
+			tt.modify(stateDump)
+			err := Validate(stateDump)
+			if tt.problem == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			var verr *ValidationError
+			if !errors.As(err, &verr) || len(verr.Problems) != 1 || !strings.HasPrefix(verr.Problems[0], tt.problem) {
+				t.Fatalf("expected problem %q, got %v", tt.problem, err)
+			}
+		})
+	}
+}
@@ -70,6 +70,40 @@ var BigTenThousand = new(big.Int).SetUint64(tenThousand)
 // additional cost is added to the overhead constant to prevent the need to RLP
 // encode transactions during calls to `eth_estimateGas`
 func EncodeTxGasLimit(data []byte, l1GasPrice, l2GasLimit, l2GasPrice *big.Int) *big.Int {
+	return CalculateFeeBreakdown(data, l1GasPrice, l2GasLimit, l2GasPrice).TxGasLimit
+}
+
+// CalculateL1GasUsed returns the L1 gas that is used to submit the calldata
+// of a transaction to L1, including the fixed overhead
+func CalculateL1GasUsed(data []byte) uint64 {
+	return calculateL1GasLimit(data, overhead).Uint64()
+}
+
+// FeeBreakdown holds each of the intermediate values of EncodeTxGasLimit so
+// that the resulting `tx.gasLimit` can be explained
+type FeeBreakdown struct {
+	// Calldata byte counts and the fixed overhead that make up the L1 gas
+	ZeroBytes    uint64
+	NonZeroBytes uint64
+	Overhead     uint64
+	L1GasLimit   *big.Int
+	L1GasPrice   *big.Int
+	L1Fee        *big.Int
+	// The L2 gas limit rounded up to a multiple of ten thousand
+	L2GasLimit        *big.Int
+	RoundedL2GasLimit *big.Int
+	L2GasPrice        *big.Int
+	L2Fee             *big.Int
+	// The encoded `tx.gasLimit` and the fee that is paid with it at the
+	// constant `tx.gasPrice`
+	TxGasLimit *big.Int
+	Fee        *big.Int
+}
+
+// CalculateFeeBreakdown computes the `tx.gasLimit` in the same way as
+// EncodeTxGasLimit while keeping all of the intermediate values
+func CalculateFeeBreakdown(data []byte, l1GasPrice, l2GasLimit, l2GasPrice *big.Int) *FeeBreakdown {
+	zeroes, ones := zeroesAndOnes(data)
 	l1GasLimit := calculateL1GasLimit(data, overhead)
 	roundedL2GasLimit := Ceilmod(l2GasLimit, BigTenThousand)
 	l1Fee := new(big.Int).Mul(l1GasPrice, l1GasLimit)
@@ -79,7 +113,20 @@ func EncodeTxGasLimit(data []byte, l1GasPrice, l2GasLimit, l2GasPrice *big.Int)
 	rounded := Ceilmod(scaled, BigTenThousand)
 	roundedScaledL2GasLimit := new(big.Int).Div(roundedL2GasLimit, BigTenThousand)
 	result := new(big.Int).Add(rounded, roundedScaledL2GasLimit)
-	return result
+	return &FeeBreakdown{
+		ZeroBytes:         zeroes,
+		NonZeroBytes:      ones,
+		Overhead:          overhead,
+		L1GasLimit:        l1GasLimit,
+		L1GasPrice:        l1GasPrice,
+		L1Fee:             l1Fee,
+		L2GasLimit:        l2GasLimit,
+		RoundedL2GasLimit: roundedL2GasLimit,
+		L2GasPrice:        l2GasPrice,
+		L2Fee:             l2Fee,
+		TxGasLimit:        result,
+		Fee:               new(big.Int).Mul(result, BigTxGasPrice),
+	}
 }
 
 func Ceilmod(a, b *big.Int) *big.Int {
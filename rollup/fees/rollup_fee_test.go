@@ -105,6 +105,35 @@ func TestCalculateRollupFee(t *testing.T) {
 	}
 }
 
+func TestFeeBreakdown(t *testing.T) {
+	data := []byte{0, 0, 1, 2, 0, 3}
+	l1GasPrice := big.NewInt(100)
+	l2GasLimit := big.NewInt(21000)
+	l2GasPrice := big.NewInt(10)
+
+	breakdown := CalculateFeeBreakdown(data, l1GasPrice, l2GasLimit, l2GasPrice)
+	if breakdown.ZeroBytes != 3 || breakdown.NonZeroBytes != 3 {
+		t.Fatalf("unexpected byte counts: %d zero, %d non zero", breakdown.ZeroBytes, breakdown.NonZeroBytes)
+	}
+	l1GasLimit := 3*params.TxDataZeroGas + 3*params.TxDataNonZeroGasEIP2028 + breakdown.Overhead
+	if breakdown.L1GasLimit.Uint64() != l1GasLimit {
+		t.Fatalf("expected L1 gas limit %d, got %d", l1GasLimit, breakdown.L1GasLimit)
+	}
+	if breakdown.L1Fee.Uint64() != l1GasLimit*100 {
+		t.Fatalf("unexpected L1 fee %d", breakdown.L1Fee)
+	}
+	if breakdown.RoundedL2GasLimit.Uint64() != 30000 || breakdown.L2Fee.Uint64() != 300000 {
+		t.Fatalf("unexpected L2 gas limit %d and fee %d", breakdown.RoundedL2GasLimit, breakdown.L2Fee)
+	}
+	expect := EncodeTxGasLimit(data, l1GasPrice, l2GasLimit, l2GasPrice)
+	if breakdown.TxGasLimit.Cmp(expect) != 0 {
+		t.Fatalf("expected tx gas limit %d, got %d", expect, breakdown.TxGasLimit)
+	}
+	if fee := new(big.Int).Mul(expect, BigTxGasPrice); breakdown.Fee.Cmp(fee) != 0 {
+		t.Fatalf("expected fee %d, got %d", fee, breakdown.Fee)
+	}
+}
+
 func TestPaysEnough(t *testing.T) {
 	tests := map[string]struct {
 		opts *PaysEnoughOpts
@@ -0,0 +1,109 @@
+package fees
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+)
+
+// errBadSmoothingConfig represents the error case of a smoothing config with
+// parameters out of range
+var errBadSmoothingConfig = errors.New("bad L1 gas price smoothing config")
+
+// SmoothingConfig is the policy that is applied to the L1 gas price before it
+// is used for fee calculation. Spikes in the L1 gas price would otherwise
+// cause every transaction that was quoted before the spike to be rejected.
+type SmoothingConfig struct {
+	// Weight of a new L1 gas price in the exponential moving average, in the
+	// range (0, 1]. Zero disables the moving average.
+	EMAWeight float64
+	// Largest change in percent of the previous L1 gas price per update. Zero
+	// disables the limit.
+	MaxChangePercent float64
+	// Lower and upper bounds of the L1 gas price, nil disables the bound
+	Floor   *big.Int
+	Ceiling *big.Int
+}
+
+// Validate returns an error if the smoothing parameters are out of range
+func (c *SmoothingConfig) Validate() error {
+	if c.EMAWeight < 0 || c.EMAWeight > 1 {
+		return fmt.Errorf("%w: EMA weight not in range [0, 1]: %f", errBadSmoothingConfig, c.EMAWeight)
+	}
+	if c.MaxChangePercent < 0 {
+		return fmt.Errorf("%w: negative max change: %f", errBadSmoothingConfig, c.MaxChangePercent)
+	}
+	if c.Floor != nil && c.Ceiling != nil && c.Floor.Cmp(c.Ceiling) > 0 {
+		return fmt.Errorf("%w: floor %d above ceiling %d", errBadSmoothingConfig, c.Floor, c.Ceiling)
+	}
+	return nil
+}
+
+// L1GasPriceSmoother applies a SmoothingConfig to a series of L1 gas prices.
+// The moving average and the change limit are relative to the previously
+// smoothed L1 gas price, the floor and ceiling are applied last.
+type L1GasPriceSmoother struct {
+	config SmoothingConfig
+	last   *big.Int
+	lock   sync.Mutex
+}
+
+// NewL1GasPriceSmoother returns an initialized L1GasPriceSmoother
+func NewL1GasPriceSmoother(config SmoothingConfig) (*L1GasPriceSmoother, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return &L1GasPriceSmoother{config: config}, nil
+}
+
+// Smooth returns the smoothed L1 gas price for the next L1 gas price that was
+// observed. The first L1 gas price is only bounded by the floor and ceiling.
+func (s *L1GasPriceSmoother) Smooth(gasPrice *big.Int) *big.Int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	result := new(big.Int).Set(gasPrice)
+	if s.last != nil {
+		if weight := s.config.EMAWeight; weight > 0 && weight < 1 {
+			result = ema(s.last, result, weight)
+		}
+		if s.config.MaxChangePercent > 0 {
+			result = limitChange(s.last, result, s.config.MaxChangePercent)
+		}
+	}
+	if s.config.Floor != nil && result.Cmp(s.config.Floor) < 0 {
+		result.Set(s.config.Floor)
+	}
+	if s.config.Ceiling != nil && result.Cmp(s.config.Ceiling) > 0 {
+		result.Set(s.config.Ceiling)
+	}
+	s.last = result
+	return new(big.Int).Set(result)
+}
+
+// ema returns weight * next + (1 - weight) * prev
+func ema(prev, next *big.Int, weight float64) *big.Int {
+	w := new(big.Float).SetFloat64(weight)
+	a := new(big.Float).Mul(new(big.Float).SetInt(next), w)
+	b := new(big.Float).Mul(new(big.Float).SetInt(prev), new(big.Float).Sub(big.NewFloat(1), w))
+	result, _ := new(big.Float).Add(a, b).Int(nil)
+	return result
+}
+
+// limitChange bounds next to prev plus or minus percent of prev
+func limitChange(prev, next *big.Int, percent float64) *big.Int {
+	delta, _ := new(big.Float).Mul(new(big.Float).SetInt(prev), big.NewFloat(percent/100)).Int(nil)
+	upper := new(big.Int).Add(prev, delta)
+	lower := new(big.Int).Sub(prev, delta)
+	if lower.Sign() < 0 {
+		lower.SetUint64(0)
+	}
+	if next.Cmp(upper) > 0 {
+		return upper
+	}
+	if next.Cmp(lower) < 0 {
+		return lower
+	}
+	return next
+}
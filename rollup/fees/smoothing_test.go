@@ -0,0 +1,70 @@
+package fees
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestL1GasPriceSmoothing(t *testing.T) {
+	tests := map[string]struct {
+		config SmoothingConfig
+		prices []int64
+		expect []int64
+	}{
+		"disabled": {
+			config: SmoothingConfig{},
+			prices: []int64{100, 1000, 10},
+			expect: []int64{100, 1000, 10},
+		},
+		"ema": {
+			config: SmoothingConfig{EMAWeight: 0.5},
+			prices: []int64{100, 300, 300, 100},
+			expect: []int64{100, 200, 250, 175},
+		},
+		"max-change": {
+			config: SmoothingConfig{MaxChangePercent: 10},
+			prices: []int64{100, 1000, 1000, 0},
+			expect: []int64{100, 110, 121, 109},
+		},
+		"floor-ceiling": {
+			config: SmoothingConfig{Floor: big.NewInt(50), Ceiling: big.NewInt(500)},
+			prices: []int64{10, 100, 1000},
+			expect: []int64{50, 100, 500},
+		},
+		"spike": {
+			config: SmoothingConfig{EMAWeight: 0.5, MaxChangePercent: 20, Ceiling: big.NewInt(1000)},
+			prices: []int64{100, 10000, 100},
+			expect: []int64{100, 120, 110},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			smoother, err := NewL1GasPriceSmoother(tt.config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, price := range tt.prices {
+				got := smoother.Smooth(big.NewInt(price))
+				if got.Int64() != tt.expect[i] {
+					t.Fatalf("price %d: expected %d, got %d", i, tt.expect[i], got)
+				}
+			}
+		})
+	}
+}
+
+func TestSmoothingConfigValidate(t *testing.T) {
+	configs := []SmoothingConfig{
+		{EMAWeight: -0.1},
+		{EMAWeight: 1.5},
+		{MaxChangePercent: -1},
+		{Floor: big.NewInt(10), Ceiling: big.NewInt(5)},
+	}
+	for i, config := range configs {
+		if _, err := NewL1GasPriceSmoother(config); !errors.Is(err, errBadSmoothingConfig) {
+			t.Fatalf("config %d: expected bad config error, got %v", i, err)
+		}
+	}
+}
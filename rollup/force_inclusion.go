@@ -0,0 +1,137 @@
+package rollup
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/MetisProtocol/l2geth/log"
+	"github.com/MetisProtocol/l2geth/metrics"
+)
+
+// enqueueDeadlineCheckInterval is the interval that the force inclusion
+// deadlines of the pending enqueue transactions are checked at
+const enqueueDeadlineCheckInterval = 5 * time.Second
+
+var (
+	// errEnqueueOverdue is returned for sequencer transactions while an
+	// enqueue transaction is past its force inclusion deadline
+	errEnqueueOverdue = errors.New("enqueue transaction past its force inclusion deadline")
+
+	// enqueueDeadlineGauge tracks the number of seconds until the earliest
+	// force inclusion deadline of the pending enqueue transactions
+	enqueueDeadlineGauge = metrics.NewRegisteredGauge("rollup/enqueue/deadline/remaining", nil)
+	// enqueuePendingGauge tracks the number of enqueue transactions that were
+	// fetched but not applied yet
+	enqueuePendingGauge = metrics.NewRegisteredGauge("rollup/enqueue/deadline/pending", nil)
+	// enqueueNearingMeter tracks the checks that found an enqueue transaction
+	// within the margin of its deadline
+	enqueueNearingMeter = metrics.NewRegisteredMeter("rollup/enqueue/deadline/nearing", nil)
+	// enqueueOverdueMeter tracks the checks that found an enqueue transaction
+	// past its deadline
+	enqueueOverdueMeter = metrics.NewRegisteredMeter("rollup/enqueue/deadline/overdue", nil)
+)
+
+// enqueueDeadlines tracks the force inclusion deadlines of the enqueue
+// transactions that the sequencer fetched but did not apply yet. Once the
+// force inclusion period of the canonical transaction chain passed since an
+// enqueue was included on L1, anyone can append it to the chain on L1, which
+// the sequencer would have to reorg to. Enqueues within the margin of their
+// deadline are urgent and applied ahead of the sequencer transactions. A
+// tracker with a zero period is disabled.
+type enqueueDeadlines struct {
+	lock      sync.Mutex
+	period    time.Duration
+	margin    time.Duration
+	deadlines map[uint64]time.Time // Deadlines by queue index
+}
+
+// newEnqueueDeadlines creates a tracker for the force inclusion deadlines
+func newEnqueueDeadlines(period, margin time.Duration) *enqueueDeadlines {
+	return &enqueueDeadlines{
+		period:    period,
+		margin:    margin,
+		deadlines: make(map[uint64]time.Time),
+	}
+}
+
+// enabled returns whether the deadlines are tracked at all
+func (d *enqueueDeadlines) enabled() bool {
+	return d.period > 0
+}
+
+// deadline returns the force inclusion deadline of an enqueue that was
+// included on L1 at the timestamp
+func (d *enqueueDeadlines) deadline(l1Timestamp uint64) time.Time {
+	return time.Unix(int64(l1Timestamp), 0).Add(d.period)
+}
+
+// urgent returns the time from which an enqueue that was included on L1 at
+// the timestamp is applied ahead of sequencer transactions
+func (d *enqueueDeadlines) urgent(l1Timestamp uint64) time.Time {
+	return d.deadline(l1Timestamp).Add(-d.margin)
+}
+
+// track starts tracking the deadline of a pending enqueue
+func (d *enqueueDeadlines) track(queueIndex uint64, l1Timestamp uint64) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	d.deadlines[queueIndex] = d.deadline(l1Timestamp)
+	enqueuePendingGauge.Update(int64(len(d.deadlines)))
+}
+
+// done stops tracking the deadline of an enqueue once it is applied
+func (d *enqueueDeadlines) done(queueIndex uint64) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	delete(d.deadlines, queueIndex)
+	enqueuePendingGauge.Update(int64(len(d.deadlines)))
+}
+
+// earliest returns the queue index and deadline of the pending enqueue with
+// the earliest deadline, false if no enqueue is pending
+func (d *enqueueDeadlines) earliest() (uint64, time.Time, bool) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	var (
+		index    uint64
+		deadline time.Time
+		found    bool
+	)
+	for i, t := range d.deadlines {
+		if !found || t.Before(deadline) || (t.Equal(deadline) && i < index) {
+			index, deadline, found = i, t, true
+		}
+	}
+	return index, deadline, found
+}
+
+// overdue returns whether a pending enqueue is past its deadline
+func (d *enqueueDeadlines) overdue(now time.Time) bool {
+	_, deadline, ok := d.earliest()
+	return ok && !now.Before(deadline)
+}
+
+// check updates the deadline metrics and warns about pending enqueues that
+// are close to or past their deadline
+func (d *enqueueDeadlines) check(now time.Time) {
+	index, deadline, ok := d.earliest()
+	if !ok {
+		enqueueDeadlineGauge.Update(0)
+		return
+	}
+	remaining := deadline.Sub(now)
+	enqueueDeadlineGauge.Update(int64(remaining / time.Second))
+
+	switch {
+	case remaining <= 0:
+		enqueueOverdueMeter.Mark(1)
+		log.Error("Enqueue transaction past its force inclusion deadline", "queue-index", index, "deadline", deadline, "overdue", -remaining)
+	case remaining <= d.margin:
+		enqueueNearingMeter.Mark(1)
+		log.Warn("Enqueue transaction nearing its force inclusion deadline", "queue-index", index, "deadline", deadline, "remaining", remaining)
+	}
+}
@@ -0,0 +1,86 @@
+package rollup
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/MetisProtocol/l2geth/common"
+	"github.com/MetisProtocol/l2geth/core/types"
+)
+
+func TestEnqueueDeadlines(t *testing.T) {
+	d := newEnqueueDeadlines(time.Hour, 10*time.Minute)
+	if !d.enabled() {
+		t.Fatal("deadlines not enabled")
+	}
+	if newEnqueueDeadlines(0, 0).enabled() {
+		t.Fatal("deadlines enabled without a period")
+	}
+	if _, _, ok := d.earliest(); ok {
+		t.Fatal("unexpected pending enqueue")
+	}
+	included := time.Unix(1000, 0)
+	d.track(2, uint64(included.Unix())+60)
+	d.track(1, uint64(included.Unix()))
+
+	index, deadline, ok := d.earliest()
+	if !ok || index != 1 || !deadline.Equal(included.Add(time.Hour)) {
+		t.Fatalf("earliest deadline mismatch: have %d %v, want 1 %v", index, deadline, included.Add(time.Hour))
+	}
+	if urgent := d.urgent(uint64(included.Unix())); !urgent.Equal(included.Add(50 * time.Minute)) {
+		t.Fatalf("urgent time mismatch: have %v, want %v", urgent, included.Add(50*time.Minute))
+	}
+	if d.overdue(included.Add(time.Hour - time.Second)) {
+		t.Fatal("overdue before the deadline")
+	}
+	if !d.overdue(included.Add(time.Hour)) {
+		t.Fatal("not overdue at the deadline")
+	}
+	// Applying the enqueue moves the deadline to the next pending one
+	d.done(1)
+	if d.overdue(included.Add(time.Hour)) {
+		t.Fatal("overdue after the enqueue was applied")
+	}
+	if index, _, _ := d.earliest(); index != 2 {
+		t.Fatalf("earliest index mismatch: have %d, want 2", index)
+	}
+	d.done(2)
+	if d.overdue(included.Add(24 * time.Hour)) {
+		t.Fatal("overdue without pending enqueues")
+	}
+}
+
+func TestSequencerRefusesTransactionsWhileEnqueueOverdue(t *testing.T) {
+	service, _, err := newTestSyncService(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	service.enqueueDeadlines = newEnqueueDeadlines(time.Hour, time.Minute)
+	service.enqueueDeadlines.track(0, uint64(time.Now().Add(-2*time.Hour).Unix()))
+
+	tx := types.NewTransaction(0, common.Address{0x01}, big.NewInt(0), 21000, big.NewInt(0), nil)
+	tx.SetTransactionMeta(types.NewTransactionMeta(big.NewInt(0), 0, nil, types.QueueOriginSequencer, nil, nil, nil))
+	if err := service.ValidateAndApplySequencerTransaction(tx); !errors.Is(err, errEnqueueOverdue) {
+		t.Fatalf("expected overdue enqueue error, got %v", err)
+	}
+}
+
+// An enqueue that fails to apply keeps its deadline until it is applied
+func TestEnqueueDeadlineKeptOnFailure(t *testing.T) {
+	service, _, err := newTestSyncService(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	service.enqueueDeadlines = newEnqueueDeadlines(time.Hour, time.Minute)
+
+	// The transaction is ahead of the next index and can't be applied
+	tx := setMockTxIndex(mockTx(), service.GetNextIndex()+1)
+	if err := service.applyFetchedEnqueue(0, tx); err == nil {
+		t.Fatal("expected apply error")
+	}
+	if index, _, ok := service.enqueueDeadlines.earliest(); !ok || index != 0 {
+		t.Fatalf("deadline of failed enqueue not tracked: have %d %v", index, ok)
+	}
+}
@@ -0,0 +1,223 @@
+package rollup
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/MetisProtocol/l2geth/common"
+	"github.com/MetisProtocol/l2geth/core/types"
+	"github.com/MetisProtocol/l2geth/metrics"
+)
+
+// futureQueueExpiryInterval is the interval that expired future nonce
+// transactions are dropped at
+const futureQueueExpiryInterval = 10 * time.Second
+
+var (
+	// errFutureQueueFull is returned when a sender already has the maximum
+	// number of future nonce transactions queued
+	errFutureQueueFull = errors.New("Too many future nonce transactions queued for sender")
+
+	// errFutureQueueGlobalFull is returned when the queue is full and only
+	// holds transactions of the sender itself
+	errFutureQueueGlobalFull = errors.New("Too many future nonce transactions queued")
+
+	// futureTxGauge tracks the number of future nonce transactions that are
+	// queued by the sequencer
+	futureTxGauge = metrics.NewRegisteredGauge("rollup/sequencer/futuretxs", nil)
+	// futureTxExpiredMeter tracks the future nonce transactions that expired
+	// before they became executable
+	futureTxExpiredMeter = metrics.NewRegisteredMeter("rollup/sequencer/futuretxs/expired", nil)
+	// futureTxEvictedMeter tracks the future nonce transactions that were
+	// evicted to make room while the queue was full
+	futureTxEvictedMeter = metrics.NewRegisteredMeter("rollup/sequencer/futuretxs/evicted", nil)
+)
+
+// futureTx is a queued transaction along with the time that it was queued at
+type futureTx struct {
+	tx    *types.Transaction
+	added time.Time
+}
+
+// futureQueue holds the sequencer transactions whose nonce is ahead of the
+// nonce of their sender until the transactions before them are applied. The
+// transactions are kept per sender by nonce and dropped once they are older
+// than the lifetime. Above the global limit the transactions of the least
+// recently active senders are evicted, highest nonce first, like the
+// transaction pool truncates its queue. A queue with a zero lifetime, sender
+// limit or global limit is disabled.
+type futureQueue struct {
+	lock         sync.Mutex
+	lifetime     time.Duration
+	maxPerSender int
+	maxGlobal    int
+	txs          map[common.Address]map[uint64]*futureTx
+	beats        map[common.Address]time.Time // last time that a transaction of a sender was queued
+	count        int
+}
+
+// newFutureQueue creates a queue for future nonce transactions
+func newFutureQueue(lifetime time.Duration, maxPerSender int, maxGlobal int) *futureQueue {
+	return &futureQueue{
+		lifetime:     lifetime,
+		maxPerSender: maxPerSender,
+		maxGlobal:    maxGlobal,
+		txs:          make(map[common.Address]map[uint64]*futureTx),
+		beats:        make(map[common.Address]time.Time),
+	}
+}
+
+// enabled returns whether future nonce transactions are queued at all
+func (q *futureQueue) enabled() bool {
+	return q.lifetime > 0 && q.maxPerSender > 0 && q.maxGlobal > 0
+}
+
+// add queues the transaction of the sender. A transaction with the same nonce
+// as a queued one replaces it.
+func (q *futureQueue) add(from common.Address, tx *types.Transaction, now time.Time) error {
+	return q.put(from, &futureTx{tx: tx, added: now})
+}
+
+// put queues a transaction while keeping the time that it was first queued at
+func (q *futureQueue) put(from common.Address, ftx *futureTx) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	txs := q.txs[from]
+	if _, ok := txs[ftx.tx.Nonce()]; !ok {
+		if len(txs) >= q.maxPerSender {
+			return errFutureQueueFull
+		}
+		if q.count >= q.maxGlobal && !q.evict(from) {
+			return errFutureQueueGlobalFull
+		}
+		if txs == nil {
+			txs = make(map[uint64]*futureTx)
+			q.txs[from] = txs
+		}
+		q.count++
+	}
+	txs[ftx.tx.Nonce()] = ftx
+	if ftx.added.After(q.beats[from]) {
+		q.beats[from] = ftx.added
+	}
+	futureTxGauge.Update(int64(q.count))
+	return nil
+}
+
+// evict drops the highest nonce transaction of the least recently active
+// sender other than the one that is queueing a transaction. It returns
+// false if there is no such sender. The lock must be held.
+func (q *futureQueue) evict(except common.Address) bool {
+	var (
+		victim common.Address
+		found  bool
+	)
+	for from := range q.txs {
+		if from == except {
+			continue
+		}
+		if !found || q.beats[from].Before(q.beats[victim]) || (q.beats[from].Equal(q.beats[victim]) && bytes.Compare(from.Bytes(), victim.Bytes()) < 0) {
+			victim, found = from, true
+		}
+	}
+	if !found {
+		return false
+	}
+	txs := q.txs[victim]
+	var highest uint64
+	for nonce := range txs {
+		if nonce > highest {
+			highest = nonce
+		}
+	}
+	delete(txs, highest)
+	q.count--
+	if len(txs) == 0 {
+		q.remove(victim)
+	}
+	futureTxEvictedMeter.Mark(1)
+	return true
+}
+
+// remove forgets a sender without queued transactions. The lock must be held.
+func (q *futureQueue) remove(from common.Address) {
+	delete(q.txs, from)
+	delete(q.beats, from)
+}
+
+// pop removes the transaction of the sender with the nonce from the queue
+// and returns it. Transactions with a lower nonce can never be executed
+// anymore and are dropped.
+func (q *futureQueue) pop(from common.Address, nonce uint64) *futureTx {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	txs := q.txs[from]
+	for n := range txs {
+		if n < nonce {
+			delete(txs, n)
+			q.count--
+		}
+	}
+	ftx := txs[nonce]
+	if ftx != nil {
+		delete(txs, nonce)
+		q.count--
+	}
+	if len(txs) == 0 {
+		q.remove(from)
+	}
+	futureTxGauge.Update(int64(q.count))
+	return ftx
+}
+
+// expire drops the transactions that were queued for longer than the
+// lifetime and returns how many were dropped
+func (q *futureQueue) expire(now time.Time) int {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	dropped := 0
+	for from, txs := range q.txs {
+		for nonce, ftx := range txs {
+			if now.Sub(ftx.added) > q.lifetime {
+				delete(txs, nonce)
+				dropped++
+			}
+		}
+		if len(txs) == 0 {
+			q.remove(from)
+		}
+	}
+	q.count -= dropped
+	futureTxExpiredMeter.Mark(int64(dropped))
+	futureTxGauge.Update(int64(q.count))
+	return dropped
+}
+
+// senders returns the senders with queued transactions in a stable order
+func (q *futureQueue) senders() []common.Address {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	senders := make([]common.Address, 0, len(q.txs))
+	for from := range q.txs {
+		senders = append(senders, from)
+	}
+	sort.Slice(senders, func(i, j int) bool {
+		return bytes.Compare(senders[i].Bytes(), senders[j].Bytes()) < 0
+	})
+	return senders
+}
+
+// len returns the number of queued transactions
+func (q *futureQueue) len() int {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return q.count
+}
@@ -0,0 +1,150 @@
+package rollup
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/MetisProtocol/l2geth/common"
+	"github.com/MetisProtocol/l2geth/core/types"
+)
+
+func newFutureTestTx(nonce uint64) *types.Transaction {
+	return types.NewTransaction(nonce, common.Address{0x01}, big.NewInt(0), 21000, big.NewInt(0), nil)
+}
+
+func TestFutureQueueRelease(t *testing.T) {
+	var (
+		alice = common.HexToAddress("0x01")
+		bob   = common.HexToAddress("0x02")
+		now   = time.Unix(1000, 0)
+	)
+	q := newFutureQueue(time.Minute, 2, 16)
+	if !q.enabled() {
+		t.Fatal("queue not enabled")
+	}
+	for _, nonce := range []uint64{3, 2} {
+		if err := q.add(alice, newFutureTestTx(nonce), now); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := q.add(alice, newFutureTestTx(4), now); !errors.Is(err, errFutureQueueFull) {
+		t.Fatalf("expected full queue error, got %v", err)
+	}
+	// Replacing a queued nonce does not count against the limit
+	replacement := newFutureTestTx(3)
+	if err := q.add(alice, replacement, now); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.add(bob, newFutureTestTx(1), now); err != nil {
+		t.Fatal(err)
+	}
+	if q.len() != 3 {
+		t.Fatalf("queue length mismatch: have %d, want 3", q.len())
+	}
+	if senders := q.senders(); len(senders) != 2 || senders[0] != alice || senders[1] != bob {
+		t.Fatalf("senders mismatch: %v", senders)
+	}
+
+	// Nothing is released while the sender is behind the queued nonces
+	if ftx := q.pop(alice, 1); ftx != nil {
+		t.Fatalf("unexpected transaction with nonce %d", ftx.tx.Nonce())
+	}
+	if ftx := q.pop(alice, 2); ftx == nil || ftx.tx.Nonce() != 2 {
+		t.Fatal("nonce 2 not released")
+	}
+	if ftx := q.pop(alice, 3); ftx == nil || ftx.tx != replacement {
+		t.Fatal("replaced nonce 3 not released")
+	}
+	// Transactions that can't be executed anymore are dropped
+	if ftx := q.pop(bob, 2); ftx != nil {
+		t.Fatal("stale transaction released")
+	}
+	if q.len() != 0 || len(q.senders()) != 0 {
+		t.Fatalf("queue not empty: %d", q.len())
+	}
+}
+
+func TestFutureQueueExpire(t *testing.T) {
+	var (
+		from = common.HexToAddress("0x01")
+		now  = time.Unix(1000, 0)
+	)
+	q := newFutureQueue(time.Minute, 16, 16)
+	if err := q.add(from, newFutureTestTx(1), now); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.add(from, newFutureTestTx(2), now.Add(30*time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if dropped := q.expire(now.Add(time.Minute)); dropped != 0 {
+		t.Fatalf("dropped %d transactions before their lifetime", dropped)
+	}
+	if dropped := q.expire(now.Add(time.Minute + time.Second)); dropped != 1 {
+		t.Fatalf("dropped %d transactions, want 1", dropped)
+	}
+	if ftx := q.pop(from, 2); ftx == nil {
+		t.Fatal("unexpired transaction dropped")
+	}
+	if dropped := q.expire(now.Add(time.Hour)); dropped != 0 || q.len() != 0 {
+		t.Fatalf("queue not empty: dropped %d, left %d", dropped, q.len())
+	}
+}
+
+// Above the global limit the highest nonce transactions of the least recently
+// active senders are evicted, but never those of the queueing sender
+func TestFutureQueueEvict(t *testing.T) {
+	var (
+		alice = common.HexToAddress("0x01")
+		bob   = common.HexToAddress("0x02")
+		carol = common.HexToAddress("0x03")
+		now   = time.Unix(1000, 0)
+	)
+	q := newFutureQueue(time.Minute, 16, 3)
+	for i, nonce := range []uint64{2, 3} {
+		if err := q.add(alice, newFutureTestTx(nonce), now.Add(time.Duration(i)*time.Second)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := q.add(bob, newFutureTestTx(5), now.Add(10*time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	// Alice was active least recently and loses her highest nonce
+	if err := q.add(carol, newFutureTestTx(7), now.Add(20*time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if q.len() != 3 {
+		t.Fatalf("queue length mismatch: have %d, want 3", q.len())
+	}
+	if ftx := q.pop(alice, 2); ftx == nil || ftx.tx.Nonce() != 2 {
+		t.Fatal("lowest nonce of alice evicted")
+	}
+	if ftx := q.pop(alice, 3); ftx != nil {
+		t.Fatal("highest nonce of alice not evicted")
+	}
+	// Replacing a queued nonce does not evict anything
+	if err := q.add(carol, newFutureTestTx(7), now.Add(30*time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	for _, nonce := range []uint64{8, 9} {
+		if err := q.add(carol, newFutureTestTx(nonce), now.Add(30*time.Second)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if senders := q.senders(); len(senders) != 1 || senders[0] != carol {
+		t.Fatalf("senders mismatch: %v", senders)
+	}
+	// A sender that holds the whole queue is refused instead
+	if err := q.add(carol, newFutureTestTx(10), now.Add(40*time.Second)); !errors.Is(err, errFutureQueueGlobalFull) {
+		t.Fatalf("expected global full queue error, got %v", err)
+	}
+}
+
+func TestFutureQueueDisabled(t *testing.T) {
+	for _, q := range []*futureQueue{newFutureQueue(0, 16, 16), newFutureQueue(time.Minute, 0, 16), newFutureQueue(time.Minute, 16, 0)} {
+		if q.enabled() {
+			t.Fatalf("queue with lifetime %v and limits %d, %d enabled", q.lifetime, q.maxPerSender, q.maxGlobal)
+		}
+	}
+}
@@ -0,0 +1,286 @@
+package rollup
+
+import (
+	"container/heap"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/MetisProtocol/l2geth/common"
+	"github.com/MetisProtocol/l2geth/core/types"
+	"github.com/MetisProtocol/l2geth/metrics"
+)
+
+const (
+	// OrderingFIFO applies the transactions in the order they arrived in
+	OrderingFIFO = "fifo"
+	// OrderingFee applies the transactions with the highest encoded fee first
+	OrderingFee = "fee"
+	// OrderingRoundRobin applies the transactions of the senders in turns
+	OrderingRoundRobin = "roundrobin"
+)
+
+var (
+	// orderingPendingGauge tracks the number of transactions that wait to be
+	// applied by the sequencer
+	orderingPendingGauge = metrics.NewRegisteredGauge("rollup/sequencer/ordering/pending", nil)
+	// orderingWaitTimer tracks the time that a transaction waits to be applied
+	orderingWaitTimer = metrics.NewRegisteredTimer("rollup/sequencer/ordering/wait", nil)
+)
+
+// OrderedTx is a transaction that waits to be applied by the sequencer
+type OrderedTx struct {
+	Tx      *types.Transaction
+	From    common.Address // Sender of a sequencer transaction, L1 origin of an enqueue
+	Enqueue bool           // Whether the transaction is a L1 to L2 transaction
+	Time    time.Time      // Time that the transaction started waiting at
+	Seq     uint64         // Order that the transactions started waiting in
+	Urgent  time.Time      // Time from which an enqueue is applied first, zero if never
+
+	result chan error
+}
+
+// fee returns the fee that the transaction pays, the L1 and L2 fees are
+// encoded in the gas limit of the transaction
+func (otx *OrderedTx) fee() *big.Int {
+	return new(big.Int).Mul(otx.Tx.GasPrice(), new(big.Int).SetUint64(otx.Tx.Gas()))
+}
+
+// OrderingPolicy decides the order that the sequencer applies the transactions
+// that wait to be applied in. Policies are not safe for concurrent use.
+type OrderingPolicy interface {
+	// Push adds a transaction that waits to be applied
+	Push(otx *OrderedTx)
+	// Pop removes the transaction to apply next and returns it, nil if no
+	// transaction is waiting
+	Pop(now time.Time) *OrderedTx
+	// Peek returns the transaction to apply next without removing it, nil if
+	// no transaction is waiting
+	Peek(now time.Time) *OrderedTx
+	// Len returns the number of waiting transactions
+	Len() int
+}
+
+// NewOrderingPolicy creates the ordering policy with the name. A non zero
+// enqueue delay guarantees L1 to L2 transactions a slot once they waited for
+// that long, otherwise they keep their place in the order of arrival. Urgent
+// L1 to L2 transactions are always applied first.
+func NewOrderingPolicy(name string, enqueueMaxDelay time.Duration) (OrderingPolicy, error) {
+	var policy OrderingPolicy
+	switch name {
+	case OrderingFIFO, "":
+		policy = new(fifoPolicy)
+	case OrderingFee:
+		policy = new(feePolicy)
+	case OrderingRoundRobin:
+		policy = newRoundRobinPolicy()
+	default:
+		return nil, fmt.Errorf("unknown ordering policy %q", name)
+	}
+	return newEnqueueSlotPolicy(policy, enqueueMaxDelay), nil
+}
+
+// fifoPolicy applies the transactions in the order they arrived in
+type fifoPolicy struct {
+	txs []*OrderedTx
+}
+
+func (p *fifoPolicy) Push(otx *OrderedTx) {
+	p.txs = append(p.txs, otx)
+}
+
+func (p *fifoPolicy) Pop(now time.Time) *OrderedTx {
+	if len(p.txs) == 0 {
+		return nil
+	}
+	otx := p.txs[0]
+	p.txs[0] = nil
+	p.txs = p.txs[1:]
+	return otx
+}
+
+func (p *fifoPolicy) Peek(now time.Time) *OrderedTx {
+	if len(p.txs) == 0 {
+		return nil
+	}
+	return p.txs[0]
+}
+
+func (p *fifoPolicy) Len() int {
+	return len(p.txs)
+}
+
+// feeHeap is a heap of transactions by descending fee, transactions that pay
+// the same fee are kept in the order they arrived in
+type feeHeap []*OrderedTx
+
+func (h feeHeap) Len() int      { return len(h) }
+func (h feeHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h feeHeap) Less(i, j int) bool {
+	if cmp := h[i].fee().Cmp(h[j].fee()); cmp != 0 {
+		return cmp > 0
+	}
+	return h[i].Seq < h[j].Seq
+}
+
+func (h *feeHeap) Push(x interface{}) {
+	*h = append(*h, x.(*OrderedTx))
+}
+
+func (h *feeHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return x
+}
+
+// feePolicy applies the transactions with the highest encoded fee first. A
+// transaction that is applied ahead of a lower nonce of its sender fails
+// validation and is queued until its nonce becomes executable.
+type feePolicy struct {
+	txs feeHeap
+}
+
+func (p *feePolicy) Push(otx *OrderedTx) {
+	heap.Push(&p.txs, otx)
+}
+
+func (p *feePolicy) Pop(now time.Time) *OrderedTx {
+	if len(p.txs) == 0 {
+		return nil
+	}
+	return heap.Pop(&p.txs).(*OrderedTx)
+}
+
+func (p *feePolicy) Peek(now time.Time) *OrderedTx {
+	if len(p.txs) == 0 {
+		return nil
+	}
+	return p.txs[0]
+}
+
+func (p *feePolicy) Len() int {
+	return len(p.txs)
+}
+
+// roundRobinPolicy applies a transaction of every sender in turns so that a
+// single sender cannot starve the others. The transactions of a sender are
+// applied in the order they arrived in.
+type roundRobinPolicy struct {
+	senders []common.Address // Senders with waiting transactions in turn order
+	txs     map[common.Address][]*OrderedTx
+	count   int
+}
+
+func newRoundRobinPolicy() *roundRobinPolicy {
+	return &roundRobinPolicy{
+		txs: make(map[common.Address][]*OrderedTx),
+	}
+}
+
+func (p *roundRobinPolicy) Push(otx *OrderedTx) {
+	if len(p.txs[otx.From]) == 0 {
+		p.senders = append(p.senders, otx.From)
+	}
+	p.txs[otx.From] = append(p.txs[otx.From], otx)
+	p.count++
+}
+
+func (p *roundRobinPolicy) Pop(now time.Time) *OrderedTx {
+	if len(p.senders) == 0 {
+		return nil
+	}
+	from := p.senders[0]
+	p.senders = p.senders[1:]
+
+	txs := p.txs[from]
+	otx := txs[0]
+	if len(txs) == 1 {
+		delete(p.txs, from)
+	} else {
+		p.txs[from] = txs[1:]
+		// The sender takes its next turn after every other waiting sender
+		p.senders = append(p.senders, from)
+	}
+	p.count--
+	return otx
+}
+
+func (p *roundRobinPolicy) Peek(now time.Time) *OrderedTx {
+	if len(p.senders) == 0 {
+		return nil
+	}
+	return p.txs[p.senders[0]][0]
+}
+
+func (p *roundRobinPolicy) Len() int {
+	return p.count
+}
+
+// enqueueSlotPolicy orders the sequencer transactions with another policy
+// while guaranteeing the L1 to L2 transactions a slot. The L1 to L2
+// transactions are applied in the order they arrived in, either when no
+// sequencer transaction is waiting, once they are urgent or once they waited
+// for the max delay. Without a max delay they are applied once they arrived
+// before the sequencer transaction that the other policy applies next.
+type enqueueSlotPolicy struct {
+	policy   OrderingPolicy
+	enqueues fifoPolicy
+	maxDelay time.Duration
+}
+
+func newEnqueueSlotPolicy(policy OrderingPolicy, maxDelay time.Duration) *enqueueSlotPolicy {
+	return &enqueueSlotPolicy{
+		policy:   policy,
+		maxDelay: maxDelay,
+	}
+}
+
+func (p *enqueueSlotPolicy) Push(otx *OrderedTx) {
+	if otx.Enqueue {
+		p.enqueues.Push(otx)
+		return
+	}
+	p.policy.Push(otx)
+}
+
+func (p *enqueueSlotPolicy) Pop(now time.Time) *OrderedTx {
+	if p.enqueueDue(now) {
+		return p.enqueues.Pop(now)
+	}
+	return p.policy.Pop(now)
+}
+
+func (p *enqueueSlotPolicy) Peek(now time.Time) *OrderedTx {
+	if p.enqueueDue(now) {
+		return p.enqueues.Peek(now)
+	}
+	return p.policy.Peek(now)
+}
+
+// enqueueDue returns whether the L1 to L2 transaction that waits the longest
+// is applied next
+func (p *enqueueSlotPolicy) enqueueDue(now time.Time) bool {
+	otx := p.enqueues.Peek(now)
+	if otx == nil {
+		return false
+	}
+	next := p.policy.Peek(now)
+	switch {
+	case next == nil:
+		return true
+	case !otx.Urgent.IsZero() && !now.Before(otx.Urgent):
+		return true
+	case p.maxDelay > 0:
+		return now.Sub(otx.Time) >= p.maxDelay
+	default:
+		return otx.Seq < next.Seq
+	}
+}
+
+func (p *enqueueSlotPolicy) Len() int {
+	return p.policy.Len() + p.enqueues.Len()
+}
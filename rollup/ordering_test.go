@@ -0,0 +1,202 @@
+package rollup
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/MetisProtocol/l2geth/common"
+	"github.com/MetisProtocol/l2geth/core/types"
+)
+
+// orderingEvent is a step of a deterministic ordering scenario. An event with
+// a name is a transaction that starts waiting, an event without one pops the
+// next transaction.
+type orderingEvent struct {
+	at      time.Duration // Offset of the event from the start of the scenario
+	name    string
+	from    byte
+	gas     uint64
+	enqueue bool
+	urgent  time.Duration // Offset from which an enqueue is urgent, zero if never
+}
+
+// pushEvent returns an event of a sequencer transaction that starts waiting
+func pushEvent(at time.Duration, name string, from byte, gas uint64) orderingEvent {
+	return orderingEvent{at: at, name: name, from: from, gas: gas}
+}
+
+// enqueueEvent returns an event of a L1 to L2 transaction that starts waiting
+func enqueueEvent(at time.Duration, name string) orderingEvent {
+	return orderingEvent{at: at, name: name, enqueue: true}
+}
+
+// urgentEnqueueEvent returns an event of a L1 to L2 transaction that starts
+// waiting and becomes urgent at the offset
+func urgentEnqueueEvent(at time.Duration, name string, urgent time.Duration) orderingEvent {
+	return orderingEvent{at: at, name: name, enqueue: true, urgent: urgent}
+}
+
+// popEvent returns an event that pops the next transaction
+func popEvent(at time.Duration) orderingEvent {
+	return orderingEvent{at: at}
+}
+
+// replayOrdering replays the events against the policy and returns the names
+// of the popped transactions in order, "-" for pops without a transaction.
+// The transactions that wait once the events are replayed are popped at the
+// time of the last event.
+func replayOrdering(t *testing.T, policy OrderingPolicy, events []orderingEvent) []string {
+	var (
+		start  = time.Unix(1000, 0)
+		now    = start
+		names  = make(map[*types.Transaction]string)
+		popped []string
+		seq    uint64
+	)
+	take := func() {
+		otx := policy.Pop(now)
+		if otx == nil {
+			popped = append(popped, "-")
+			return
+		}
+		popped = append(popped, names[otx.Tx])
+	}
+	for _, event := range events {
+		if at := start.Add(event.at); at.Before(now) {
+			t.Fatalf("event %q goes back in time", event.name)
+		} else {
+			now = at
+		}
+		if event.name == "" {
+			take()
+			continue
+		}
+		tx := types.NewTransaction(seq, common.Address{}, new(big.Int), event.gas, big.NewInt(1), nil)
+		names[tx] = event.name
+		otx := &OrderedTx{
+			Tx:      tx,
+			From:    common.Address{event.from},
+			Enqueue: event.enqueue,
+			Time:    now,
+			Seq:     seq,
+		}
+		if event.urgent != 0 {
+			otx.Urgent = start.Add(event.urgent)
+		}
+		policy.Push(otx)
+		seq++
+	}
+	for policy.Len() > 0 {
+		take()
+	}
+	return popped
+}
+
+func TestOrderingPolicies(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   string
+		delay    time.Duration
+		events   []orderingEvent
+		expected []string
+	}{
+		{
+			name:   "fifo",
+			policy: OrderingFIFO,
+			events: []orderingEvent{
+				pushEvent(0, "a1", 1, 100), pushEvent(0, "a2", 1, 300), enqueueEvent(0, "e1"), pushEvent(0, "b1", 2, 200),
+				popEvent(time.Second), pushEvent(time.Second, "b2", 2, 500),
+			},
+			expected: []string{"a1", "a2", "e1", "b1", "b2"},
+		},
+		{
+			name:   "fee",
+			policy: OrderingFee,
+			events: []orderingEvent{
+				pushEvent(0, "low", 1, 100), pushEvent(0, "high", 2, 300), pushEvent(0, "mid", 3, 200), pushEvent(0, "mid2", 4, 200),
+				popEvent(time.Second), pushEvent(time.Second, "top", 5, 500),
+			},
+			expected: []string{"high", "top", "mid", "mid2", "low"},
+		},
+		{
+			name:   "round robin",
+			policy: OrderingRoundRobin,
+			events: []orderingEvent{
+				pushEvent(0, "a1", 1, 100), pushEvent(0, "a2", 1, 100), pushEvent(0, "a3", 1, 100), pushEvent(0, "b1", 2, 100),
+				popEvent(time.Second), pushEvent(time.Second, "c1", 3, 100), pushEvent(time.Second, "b2", 2, 100),
+			},
+			expected: []string{"a1", "b1", "a2", "c1", "b2", "a3"},
+		},
+		{
+			name:   "empty",
+			policy: OrderingRoundRobin,
+			events: []orderingEvent{
+				popEvent(0), pushEvent(0, "a1", 1, 100), popEvent(0), popEvent(0),
+			},
+			expected: []string{"-", "a1", "-"},
+		},
+		{
+			// The spammer can't keep the enqueue waiting for longer than
+			// the max delay
+			name:   "enqueue slot",
+			policy: OrderingFee,
+			delay:  time.Minute,
+			events: []orderingEvent{
+				pushEvent(0, "s1", 1, 500), enqueueEvent(0, "e1"), pushEvent(0, "s2", 1, 500), pushEvent(0, "s3", 1, 500), pushEvent(0, "s4", 1, 500),
+				popEvent(10 * time.Second), popEvent(59 * time.Second), popEvent(time.Minute), popEvent(time.Minute),
+				enqueueEvent(time.Minute, "e2"), popEvent(90 * time.Second),
+			},
+			expected: []string{"s1", "s2", "e1", "s3", "s4", "e2"},
+		},
+		{
+			// Enqueues don't wait when no sequencer transaction is waiting
+			name:   "enqueue slot idle",
+			policy: OrderingRoundRobin,
+			delay:  time.Minute,
+			events: []orderingEvent{
+				enqueueEvent(0, "e1"), enqueueEvent(0, "e2"), popEvent(0), pushEvent(0, "a1", 1, 100), popEvent(0), popEvent(0),
+			},
+			expected: []string{"e1", "a1", "e2"},
+		},
+		{
+			// Without a max delay sequencer transactions that arrived after
+			// an enqueue can't pass it
+			name:   "enqueue arrival order",
+			policy: OrderingFee,
+			events: []orderingEvent{
+				pushEvent(0, "low", 1, 100), enqueueEvent(0, "e1"), pushEvent(0, "high", 2, 300),
+			},
+			expected: []string{"e1", "high", "low"},
+		},
+		{
+			// Urgent enqueues are applied first regardless of the delay
+			name:   "urgent enqueue",
+			policy: OrderingRoundRobin,
+			delay:  time.Hour,
+			events: []orderingEvent{
+				pushEvent(0, "a1", 1, 100), urgentEnqueueEvent(0, "e1", 30*time.Second), pushEvent(0, "b1", 2, 100), pushEvent(0, "c1", 3, 100),
+				popEvent(10 * time.Second), popEvent(30 * time.Second),
+			},
+			expected: []string{"a1", "e1", "b1", "c1"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			policy, err := NewOrderingPolicy(test.policy, test.delay)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if have := replayOrdering(t, policy, test.events); !reflect.DeepEqual(have, test.expected) {
+				t.Fatalf("order mismatch: have %v, want %v", have, test.expected)
+			}
+		})
+	}
+}
+
+func TestOrderingPolicyUnknown(t *testing.T) {
+	if _, err := NewOrderingPolicy("lifo", 0); err == nil {
+		t.Fatal("expected unknown policy error")
+	}
+}
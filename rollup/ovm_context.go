@@ -0,0 +1,155 @@
+package rollup
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/MetisProtocol/l2geth/core/types"
+	"github.com/MetisProtocol/l2geth/log"
+	"github.com/MetisProtocol/l2geth/metrics"
+)
+
+// The L1 context of a L2 transaction is the L1 block number and timestamp
+// that it is executed with. The ordering rules for the contexts are:
+//
+//   1. Neither the L1 block number nor the L1 timestamp of a sequencer
+//      transaction is lower than that of the latest context.
+//   2. A sequencer transaction without a context is executed with the latest
+//      context.
+//   3. A L1 to L2 transaction is executed with the context of the L1 block
+//      that enqueued it, which is never changed. It only moves the latest
+//      context forward.
+//   4. The context of a transaction that was read from the canonical
+//      transaction chain is final, it only moves the latest context forward.
+//
+// The context policy decides what happens to a sequencer transaction that
+// breaks the first rule.
+
+// ContextPolicy decides how a transaction whose L1 context goes back in time
+// is handled
+type ContextPolicy uint8
+
+const (
+	// ContextPolicyClamp executes a sequencer transaction whose context goes
+	// back in time with the latest context instead
+	ContextPolicyClamp ContextPolicy = iota
+	// ContextPolicyReject refuses sequencer transactions whose context went
+	// back in time
+	ContextPolicyReject
+)
+
+var (
+	// ErrContextMonotonicity is returned for a sequencer transaction whose L1
+	// context goes back in time with the reject policy
+	ErrContextMonotonicity = errors.New("L1 context goes back in time")
+
+	// contextViolationCounter counts the sequencer transactions whose L1 context
+	// went back in time
+	contextViolationCounter = metrics.NewRegisteredCounter("rollup/context/violation", nil)
+	// contextClampCounter counts the sequencer transactions whose L1 context
+	// was clamped to the latest context
+	contextClampCounter = metrics.NewRegisteredCounter("rollup/context/clamped", nil)
+)
+
+// NewContextPolicy returns the context policy with the name
+func NewContextPolicy(name string) (ContextPolicy, error) {
+	switch name {
+	case "clamp", "":
+		return ContextPolicyClamp, nil
+	case "reject":
+		return ContextPolicyReject, nil
+	default:
+		return ContextPolicyClamp, fmt.Errorf("unknown context policy %q", name)
+	}
+}
+
+func (p ContextPolicy) String() string {
+	switch p {
+	case ContextPolicyClamp:
+		return "clamp"
+	case ContextPolicyReject:
+		return "reject"
+	default:
+		return ""
+	}
+}
+
+// txOVMContext returns the L1 context of the transaction
+func txOVMContext(tx *types.Transaction) OVMContext {
+	context := OVMContext{timestamp: tx.L1Timestamp()}
+	if bn := tx.L1BlockNumber(); bn != nil {
+		context.blockNumber = bn.Uint64()
+	}
+	return context
+}
+
+// setTxOVMContext sets the L1 context of the transaction
+func setTxOVMContext(tx *types.Transaction, context OVMContext) {
+	tx.SetL1Timestamp(context.timestamp)
+	tx.SetL1BlockNumber(context.blockNumber)
+}
+
+// before returns whether the context goes back in time compared to prev
+func (c OVMContext) before(prev OVMContext) bool {
+	return c.blockNumber < prev.blockNumber || c.timestamp < prev.timestamp
+}
+
+// follow returns the latest context once a transaction whose context must not
+// be changed is executed. The latest context never goes back in time.
+func follow(latest OVMContext, tx *types.Transaction) OVMContext {
+	if next := txOVMContext(tx); !next.before(latest) {
+		return next
+	}
+	return latest
+}
+
+// enforce applies the ordering rules to a transaction that follows the latest
+// context, setting the context of the transaction where the rules demand it.
+// It returns the latest context once the transaction is executed.
+func (p ContextPolicy) enforce(latest OVMContext, tx *types.Transaction) (OVMContext, error) {
+	if tx.QueueOrigin() != types.QueueOriginSequencer {
+		return follow(latest, tx), nil
+	}
+	if tx.L1Timestamp() == 0 {
+		setTxOVMContext(tx, latest)
+		return latest, nil
+	}
+	next := txOVMContext(tx)
+	if !next.before(latest) {
+		return next, nil
+	}
+	contextViolationCounter.Inc(1)
+	if p == ContextPolicyReject {
+		return latest, fmt.Errorf("%w: transaction %s with block number %d and timestamp %d follows block number %d and timestamp %d",
+			ErrContextMonotonicity, tx.Hash().Hex(), next.blockNumber, next.timestamp, latest.blockNumber, latest.timestamp)
+	}
+	log.Warn("Clamping L1 context of sequencer transaction", "hash", tx.Hash().Hex(), "blocknumber", next.blockNumber,
+		"timestamp", next.timestamp, "latest-blocknumber", latest.blockNumber, "latest-timestamp", latest.timestamp)
+	contextClampCounter.Inc(1)
+	setTxOVMContext(tx, latest)
+	return latest, nil
+}
+
+// EnforceBlock applies the ordering rules to the transactions of a block that
+// follows the parent. The context of the parent is its timestamp and the L1
+// block number of its last transaction. The context of confirmed requests is
+// final, they only move the latest context forward.
+func (p ContextPolicy) EnforceBlock(parent *types.Block, reqs []TxCommitRequest) error {
+	latest := OVMContext{timestamp: parent.Time()}
+	if prev := parent.Transactions(); len(prev) != 0 {
+		if bn := prev[len(prev)-1].L1BlockNumber(); bn != nil {
+			latest.blockNumber = bn.Uint64()
+		}
+	}
+	for _, req := range reqs {
+		if req.Confirmed {
+			latest = follow(latest, req.Tx)
+			continue
+		}
+		var err error
+		if latest, err = p.enforce(latest, req.Tx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
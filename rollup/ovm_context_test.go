@@ -0,0 +1,214 @@
+package rollup
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"reflect"
+	"testing"
+	"testing/quick"
+
+	"github.com/MetisProtocol/l2geth/common"
+	"github.com/MetisProtocol/l2geth/core/rawdb"
+	"github.com/MetisProtocol/l2geth/core/types"
+)
+
+// contextTestTx is a transaction with a random L1 context for the property
+// based tests, small values make violations and equal contexts likely
+type contextTestTx struct {
+	BlockNumber uint8
+	Timestamp   uint8
+	Enqueue     bool
+}
+
+func (c contextTestTx) tx() *types.Transaction {
+	origin := types.QueueOriginSequencer
+	if c.Enqueue {
+		origin = types.QueueOriginL1ToL2
+	}
+	tx := types.NewTransaction(0, common.Address{0x01}, big.NewInt(0), 21000, big.NewInt(0), nil)
+	meta := types.NewTransactionMeta(new(big.Int).SetUint64(uint64(c.BlockNumber)), uint64(c.Timestamp), nil, origin, nil, nil, nil)
+	tx.SetTransactionMeta(meta)
+	return tx
+}
+
+// The latest context never goes back in time with the clamp policy, and every
+// sequencer transaction is executed with a context that is not behind it
+func TestContextPolicyClampMonotonic(t *testing.T) {
+	property := func(start contextTestTx, txs []contextTestTx) bool {
+		latest := OVMContext{blockNumber: uint64(start.BlockNumber), timestamp: uint64(start.Timestamp)}
+		for _, c := range txs {
+			tx := c.tx()
+			next, err := ContextPolicyClamp.enforce(latest, tx)
+			if err != nil || next.before(latest) {
+				return false
+			}
+			if !c.Enqueue && txOVMContext(tx).before(latest) {
+				return false
+			}
+			latest = next
+		}
+		return true
+	}
+	if err := quick.Check(property, nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// The context of a L1 to L2 transaction is never changed
+func TestContextPolicyEnqueueUnchanged(t *testing.T) {
+	property := func(latestBlockNumber, latestTimestamp uint8, c contextTestTx, reject bool) bool {
+		c.Enqueue = true
+		policy := ContextPolicyClamp
+		if reject {
+			policy = ContextPolicyReject
+		}
+		tx := c.tx()
+		want := txOVMContext(tx)
+		policy.enforce(OVMContext{blockNumber: uint64(latestBlockNumber), timestamp: uint64(latestTimestamp)}, tx)
+		return txOVMContext(tx) == want
+	}
+	if err := quick.Check(property, nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// The reject policy refuses a sequencer transaction exactly when its context
+// goes back in time and never changes the latest context when it does
+func TestContextPolicyReject(t *testing.T) {
+	property := func(latestBlockNumber, latestTimestamp uint8, c contextTestTx) bool {
+		latest := OVMContext{blockNumber: uint64(latestBlockNumber), timestamp: uint64(latestTimestamp)}
+		tx := c.tx()
+		// L1 to L2 transactions are never refused and sequencer
+		// transactions without a context take the latest one
+		violation := !c.Enqueue && c.Timestamp != 0 && txOVMContext(tx).before(latest)
+		next, err := ContextPolicyReject.enforce(latest, tx)
+		if violation {
+			return errors.Is(err, ErrContextMonotonicity) && next == latest
+		}
+		return err == nil && !next.before(latest)
+	}
+	if err := quick.Check(property, nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestContextPolicyAssignsLatest(t *testing.T) {
+	latest := OVMContext{blockNumber: 10, timestamp: 100}
+	tx := contextTestTx{}.tx()
+	next, err := ContextPolicyReject.enforce(latest, tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next != latest || txOVMContext(tx) != latest {
+		t.Fatalf("context mismatch: have %v, want %v", txOVMContext(tx), latest)
+	}
+}
+
+// Transactions confirmed in the canonical transaction chain keep their context
+// even when it goes back in time, only sequencer transactions are enforced
+func TestContextPolicyEnforceBlockConfirmed(t *testing.T) {
+	parent := types.NewBlock(&types.Header{Number: big.NewInt(1), Time: 100}, []*types.Transaction{contextTestTx{BlockNumber: 10, Timestamp: 100}.tx()}, nil, nil)
+	confirmed := contextTestTx{BlockNumber: 5, Timestamp: 50}.tx()
+	reqs := []TxCommitRequest{{Tx: confirmed, Confirmed: true}}
+	for _, policy := range []ContextPolicy{ContextPolicyClamp, ContextPolicyReject} {
+		if err := policy.EnforceBlock(parent, reqs); err != nil {
+			t.Fatalf("%s: %v", policy, err)
+		}
+		if have, want := txOVMContext(confirmed), (OVMContext{blockNumber: 5, timestamp: 50}); have != want {
+			t.Fatalf("%s: context mismatch: have %v, want %v", policy, have, want)
+		}
+	}
+	pending := append(reqs, TxCommitRequest{Tx: contextTestTx{BlockNumber: 5, Timestamp: 50}.tx()})
+	if err := ContextPolicyReject.EnforceBlock(parent, pending); !errors.Is(err, ErrContextMonotonicity) {
+		t.Fatalf("error mismatch: have %v, want %v", err, ErrContextMonotonicity)
+	}
+}
+
+func TestNewContextPolicy(t *testing.T) {
+	for _, name := range []string{"clamp", "reject"} {
+		policy, err := NewContextPolicy(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if policy.String() != name {
+			t.Fatalf("policy mismatch: have %s, want %s", policy, name)
+		}
+	}
+	if _, err := NewContextPolicy("ignore"); err == nil {
+		t.Fatal("expected unknown policy error")
+	}
+}
+
+// The latest context is restored after a restart instead of starting at zero
+func TestSyncServiceOVMContextRestart(t *testing.T) {
+	cfg, txPool, chain, db, err := newTestSyncServiceDeps(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	service, err := NewSyncService(context.Background(), cfg, txPool, chain, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	service.SetLatestL1Context(10, 100)
+
+	restarted, err := NewSyncService(context.Background(), cfg, txPool, chain, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bn, ts := restarted.GetLatestL1BlockNumber(), restarted.GetLatestL1Timestamp(); bn != 10 || ts != 100 {
+		t.Fatalf("context mismatch: have block number %d and timestamp %d", bn, ts)
+	}
+}
+
+func TestSyncServiceBlockOVMContext(t *testing.T) {
+	service, _, err := newTestSyncService(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The hash of the latest L1 block is known from updating the context,
+	// the hash of an older L1 block is looked up in the background
+	setupMockClient(service, map[string]interface{}{
+		"GetLatestEthContext": &EthContext{BlockNumber: 5, BlockHash: common.HexToHash("0x05"), Timestamp: 500},
+		"GetEthContext":       []*EthContext{{BlockNumber: 3, BlockHash: common.HexToHash("0x03"), Timestamp: 300}},
+	})
+	if err := service.updateContext(); err != nil {
+		t.Fatal(err)
+	}
+	check := func(number uint64, c contextTestTx, known bool) {
+		t.Helper()
+		want := &rawdb.BlockOVMContext{BlockNumber: uint64(c.BlockNumber), Timestamp: uint64(c.Timestamp)}
+		if known {
+			hash := common.BigToHash(big.NewInt(int64(c.BlockNumber)))
+			want.BlockHash = &hash
+		}
+		if have := rawdb.ReadBlockOVMContext(service.db, number); !reflect.DeepEqual(have, want) {
+			t.Fatalf("block %d context mismatch: have %+v, want %+v", number, have, want)
+		}
+	}
+	txs := []contextTestTx{{BlockNumber: 5, Timestamp: 200}, {BlockNumber: 3, Timestamp: 100, Enqueue: true}, {BlockNumber: 3, Timestamp: 100}}
+	for i, c := range txs {
+		block := types.NewBlock(&types.Header{Number: big.NewInt(int64(i + 1))}, []*types.Transaction{c.tx()}, nil, nil)
+		service.writeBlockOVMContext(block)
+		if i != 1 {
+			check(uint64(i+1), c, true)
+			continue
+		}
+		// The hash stays unknown until it is looked up
+		check(2, c, false)
+		if number := <-service.blockContextResolve; number != 2 {
+			t.Fatalf("lookup mismatch: have block %d, want 2", number)
+		}
+		service.resolveBlockOVMContext(2)
+		check(2, c, true)
+	}
+
+	// The contexts of the blocks after the head are removed
+	service.deleteBlockOVMContexts(1)
+	check(1, txs[0], true)
+	for number := uint64(2); number <= 3; number++ {
+		if rawdb.ReadBlockOVMContext(service.db, number) != nil {
+			t.Fatalf("block %d context not deleted", number)
+		}
+	}
+}
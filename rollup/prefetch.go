@@ -0,0 +1,149 @@
+package rollup
+
+import (
+	"sync"
+	"time"
+
+	"github.com/MetisProtocol/l2geth/metrics"
+)
+
+var (
+	// prefetchTimer tracks the time that it takes to fetch a single element
+	prefetchTimer = metrics.NewRegisteredTimer("rollup/prefetch/fetch", nil)
+	// prefetchWaitTimer tracks the time that the applier waits for the next
+	// element to be fetched
+	prefetchWaitTimer = metrics.NewRegisteredTimer("rollup/prefetch/wait", nil)
+)
+
+// fetchFn fetches and decodes the element at an index
+type fetchFn func(index uint64) (interface{}, error)
+
+// applyFn applies an element that was fetched by a fetchFn
+type applyFn func(index uint64, element interface{}) error
+
+// prefetchResult is the outcome of fetching a single element
+type prefetchResult struct {
+	element interface{}
+	err     error
+}
+
+// prefetchTask is a single element to fetch, the result is buffered so that
+// workers never block on a consumer that has gone away
+type prefetchTask struct {
+	index  uint64
+	result chan prefetchResult
+}
+
+// prefetcher fetches the elements of a range with a bounded number of
+// concurrent workers ahead of the applier while handing them to the applier
+// strictly in order. No more than depth elements are queued ahead of the
+// applier, so a slow applier applies back-pressure to the workers. A
+// prefetcher with a single worker fetches and applies one element at a time.
+type prefetcher struct {
+	workers int
+	depth   int
+}
+
+// newPrefetcher creates a prefetcher, the depth is raised to the number of
+// workers so that every worker can be kept busy
+func newPrefetcher(workers, depth int) *prefetcher {
+	if workers < 1 {
+		workers = 1
+	}
+	if depth < workers {
+		depth = workers
+	}
+	return &prefetcher{
+		workers: workers,
+		depth:   depth,
+	}
+}
+
+// run fetches the elements from start to end (inclusive) and applies them in
+// order. It stops at the first error, elements after the failed one are never
+// applied. An empty range, when the remote tip is behind the local one, is
+// not fetched at all.
+func (p *prefetcher) run(start, end uint64, fetch fetchFn, apply applyFn) error {
+	if start > end {
+		return nil
+	}
+	if p.workers == 1 || start == end {
+		for i := start; ; i++ {
+			element, err := p.fetch(fetch, i)
+			if err != nil {
+				return err
+			}
+			if err := apply(i, element); err != nil {
+				return err
+			}
+			if i == end {
+				return nil
+			}
+		}
+	}
+
+	var (
+		quit    = make(chan struct{})
+		tasks   = make(chan *prefetchTask)
+		pending = make(chan *prefetchTask, p.depth)
+		wg      sync.WaitGroup
+	)
+	defer func() {
+		close(quit)
+		wg.Wait()
+	}()
+
+	for w := 0; w < p.workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for task := range tasks {
+				element, err := p.fetch(fetch, task.index)
+				task.result <- prefetchResult{element: element, err: err}
+			}
+		}()
+	}
+	// Queue the tasks in order before handing them to the workers, the
+	// applier waits on the queued tasks in that same order
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(tasks)
+		defer close(pending)
+		for i := start; ; i++ {
+			task := &prefetchTask{index: i, result: make(chan prefetchResult, 1)}
+			select {
+			case pending <- task:
+			case <-quit:
+				return
+			}
+			select {
+			case tasks <- task:
+			case <-quit:
+				return
+			}
+			if i == end {
+				return
+			}
+		}
+	}()
+
+	for task := range pending {
+		waited := time.Now()
+		res := <-task.result
+		prefetchWaitTimer.UpdateSince(waited)
+		if res.err != nil {
+			return res.err
+		}
+		if err := apply(task.index, res.element); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fetch fetches a single element and tracks the time that it takes
+func (p *prefetcher) fetch(fetch fetchFn, index uint64) (interface{}, error) {
+	defer prefetchTimer.UpdateSince(time.Now())
+	return fetch(index)
+}
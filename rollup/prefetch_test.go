@@ -0,0 +1,126 @@
+package rollup
+
+import (
+	"errors"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPrefetcherOrder(t *testing.T) {
+	for _, p := range []*prefetcher{newPrefetcher(1, 1), newPrefetcher(4, 8), newPrefetcher(16, 4)} {
+		fetch := func(i uint64) (interface{}, error) {
+			time.Sleep(time.Duration(rand.Intn(1000)) * time.Microsecond)
+			return i * 2, nil
+		}
+		var applied []uint64
+		apply := func(i uint64, element interface{}) error {
+			if element.(uint64) != i*2 {
+				t.Fatalf("unexpected element %d at index %d", element, i)
+			}
+			applied = append(applied, i)
+			return nil
+		}
+		if err := p.run(10, 109, fetch, apply); err != nil {
+			t.Fatal(err)
+		}
+		if len(applied) != 100 {
+			t.Fatalf("workers %d: expected 100 applied elements, got %d", p.workers, len(applied))
+		}
+		for j, i := range applied {
+			if i != uint64(10+j) {
+				t.Fatalf("workers %d: element %d applied out of order", p.workers, i)
+			}
+		}
+	}
+}
+
+func TestPrefetcherEmptyRange(t *testing.T) {
+	for _, p := range []*prefetcher{newPrefetcher(1, 1), newPrefetcher(4, 8)} {
+		fetch := func(i uint64) (interface{}, error) {
+			t.Fatalf("workers %d: unexpected fetch of element %d", p.workers, i)
+			return nil, nil
+		}
+		apply := func(i uint64, element interface{}) error {
+			t.Fatalf("workers %d: unexpected apply of element %d", p.workers, i)
+			return nil
+		}
+		if err := p.run(10, 9, fetch, apply); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestPrefetcherBackPressure(t *testing.T) {
+	p := newPrefetcher(4, 8)
+	var (
+		lock    sync.Mutex
+		fetched int
+		applied int
+		ahead   int
+	)
+	fetch := func(i uint64) (interface{}, error) {
+		lock.Lock()
+		defer lock.Unlock()
+		fetched++
+		if fetched-applied > ahead {
+			ahead = fetched - applied
+		}
+		return i, nil
+	}
+	apply := func(i uint64, element interface{}) error {
+		// A slow applier lets the workers run ahead as far as they can
+		time.Sleep(time.Millisecond)
+		lock.Lock()
+		defer lock.Unlock()
+		applied++
+		return nil
+	}
+	if err := p.run(0, 99, fetch, apply); err != nil {
+		t.Fatal(err)
+	}
+	// The element being applied is no longer queued
+	if ahead > p.depth+1 {
+		t.Fatalf("fetched %d elements ahead with depth %d", ahead, p.depth)
+	}
+}
+
+func TestPrefetcherError(t *testing.T) {
+	errFetch := errors.New("fetch failed")
+	p := newPrefetcher(4, 8)
+	fetch := func(i uint64) (interface{}, error) {
+		if i == 5 {
+			return nil, errFetch
+		}
+		return i, nil
+	}
+	var last uint64
+	apply := func(i uint64, element interface{}) error {
+		last = i
+		return nil
+	}
+	if err := p.run(0, 99, fetch, apply); !errors.Is(err, errFetch) {
+		t.Fatalf("expected fetch error, got %v", err)
+	}
+	if last != 4 {
+		t.Fatalf("expected elements up to 4 to be applied, got %d", last)
+	}
+
+	// An error while applying stops the range
+	errApply := errors.New("apply failed")
+	applied := 0
+	apply = func(i uint64, element interface{}) error {
+		if i == 2 {
+			return errApply
+		}
+		applied++
+		return nil
+	}
+	if err := p.run(0, 4, fetch, apply); !errors.Is(err, errApply) {
+		t.Fatalf("expected apply error, got %v", err)
+	}
+	if applied != 2 {
+		t.Fatalf("expected 2 applied elements, got %d", applied)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"math/big"
 	"strconv"
 	"sync"
@@ -13,10 +14,10 @@ import (
 	"github.com/MetisProtocol/l2geth/common"
 	"github.com/MetisProtocol/l2geth/core"
 	"github.com/MetisProtocol/l2geth/core/state"
-	"github.com/MetisProtocol/l2geth/core/vm"
 	"github.com/MetisProtocol/l2geth/ethdb"
 	"github.com/MetisProtocol/l2geth/event"
 	"github.com/MetisProtocol/l2geth/log"
+	"github.com/MetisProtocol/l2geth/metrics"
 
 	"github.com/MetisProtocol/l2geth/core/rawdb"
 	"github.com/MetisProtocol/l2geth/core/types"
@@ -35,9 +36,17 @@ var (
 	// errZeroGasPriceTx is the error for when a user submits a transaction
 	// with gas price zero and fees are currently enforced
 	errZeroGasPriceTx = errors.New("cannot accept 0 gas price transaction")
-	float1            = big.NewFloat(1)
+	// errStateRootMismatch is the error for when a state root that was
+	// submitted to L1 does not match the locally computed state root
+	errStateRootMismatch = errors.New("state root mismatch")
+	float1               = big.NewFloat(1)
 )
 
+// blockContextResolveSize is the number of L2 blocks that can wait for the
+// hash of their L1 block to be looked up, the lookups of further blocks are
+// skipped
+const blockContextResolveSize = 1024
+
 var (
 	// l2GasPriceSlot refers to the storage slot that the L2 gas price is stored
 	// in in the OVM_GasPriceOracle predeploy
@@ -50,6 +59,23 @@ var (
 	l2GasPriceOracleAddress = common.HexToAddress("0x420000000000000000000000000000000000000F")
 )
 
+var (
+	// reorgCounter counts the number of times that the local chain was
+	// rewound due to a transaction that does not match L1
+	reorgCounter = metrics.NewRegisteredCounter("rollup/reorg/count", nil)
+	// reorgDepthHistogram tracks the number of blocks removed by each reorg
+	reorgDepthHistogram = metrics.NewRegisteredHistogram("rollup/reorg/depth", nil, metrics.NewExpDecaySample(1028, 0.015))
+	// reorgOrphanedCounter counts the sequencer transactions removed by
+	// reorgs after the mismatched transaction
+	reorgOrphanedCounter = metrics.NewRegisteredCounter("rollup/reorg/orphaned", nil)
+	// stateRootMismatchCounter counts the number of state roots submitted to
+	// L1 that do not match the locally computed state root
+	stateRootMismatchCounter = metrics.NewRegisteredCounter("rollup/verifier/staterootmismatch", nil)
+	// stateRootIndexGauge tracks the CTC index of the latest verified state
+	// root
+	stateRootIndexGauge = metrics.NewRegisteredGauge("rollup/verifier/staterootindex", nil)
+)
+
 // SyncService implements the main functionality around pulling in transactions
 // and executing them. It can be configured to run in both sequencer mode and in
 // verifier mode.
@@ -59,7 +85,7 @@ type SyncService struct {
 	verifier                       bool
 	db                             ethdb.Database
 	scope                          event.SubscriptionScope
-	txFeed                         event.Feed
+	reorgFeed                      event.Feed
 	txLock                         sync.Mutex
 	loopLock                       sync.Mutex
 	enable                         bool
@@ -68,12 +94,25 @@ type SyncService struct {
 	txpool                         *core.TxPool
 	RollupGpo                      *gasprice.RollupOracle
 	client                         RollupClient
+	clientNotify                   <-chan struct{}
 	syncing                        atomic.Value
-	chainHeadSub                   event.Subscription
 	OVMContext                     OVMContext
+	contextLock                    sync.Mutex
+	contextPolicy                  ContextPolicy
+	latestEthContext               *EthContext // Latest L1 block fetched, guarded by contextLock
+	resolvedEthContext             *EthContext // Latest L1 block looked up by number, guarded by contextLock
+	blockContextLock               sync.Mutex  // Guards the L1 contexts of the L2 blocks
+	blockContextResolve            chan uint64 // L2 blocks with an unknown L1 block hash
 	pollInterval                   time.Duration
 	timestampRefreshThreshold      time.Duration
-	chainHeadCh                    chan core.ChainHeadEvent
+	commitCh                       chan TxCommitRequest
+	txCommitTimeout                time.Duration
+	maxTxsPerBlock                 int
+	pendingCommits                 int  // Transactions handed to the miner that are not answered yet, guarded by txLock
+	pendingFailed                  bool // Whether a transaction in flight failed, guarded by txLock
+	draining                       bool // Whether no transactions are taken until the miner is idle, guarded by txLock
+	commitsDrained                 *sync.Cond
+	blockWindow                    time.Duration
 	backend                        Backend
 	gasPriceOracleOwnerAddress     common.Address
 	gasPriceOracleOwnerAddressLock *sync.RWMutex
@@ -82,6 +121,18 @@ type SyncService struct {
 	minL2GasLimit                  *big.Int
 	feeThresholdUp                 *big.Float
 	feeThresholdDown               *big.Float
+	l1GasPriceSmoother             *fees.L1GasPriceSmoother
+	haltOnStateRootMismatch        bool
+	halted                         int32
+	prefetcher                     *prefetcher
+	futureQueue                    *futureQueue
+	futureRelease                  chan struct{}
+	enqueueDeadlines               *enqueueDeadlines
+	ordering                       OrderingPolicy
+	orderingLock                   sync.Mutex
+	orderingSeq                    uint64
+	orderingNotify                 chan struct{}
+	orderingActive                 int32
 }
 
 // NewSyncService returns an initialized sync service
@@ -107,20 +158,78 @@ func NewSyncService(ctx context.Context, cfg Config, txpool *core.TxPool, bc *co
 		log.Info("Sanitizing poll interval to 15 seconds")
 		pollInterval = time.Second * 15
 	}
+	txCommitTimeout := cfg.TxCommitTimeout
+	if txCommitTimeout == 0 {
+		log.Info("Sanitizing transaction commit timeout to 1 minute")
+		txCommitTimeout = time.Minute
+	}
+	// Multi transaction blocks are only built by the sequencer, the verifier
+	// applies the transactions from layer one one at a time
+	maxTxsPerBlock := cfg.MaxTxsPerBlock
+	if maxTxsPerBlock < 1 || cfg.IsVerifier {
+		maxTxsPerBlock = 1
+	}
+	blockWindow := cfg.BlockWindow
+	if maxTxsPerBlock > 1 {
+		if blockWindow == 0 {
+			log.Info("Sanitizing block window to 1 second")
+			blockWindow = time.Second
+		}
+		log.Info("Enabled multi transaction blocks", "max-txs", maxTxsPerBlock, "window", blockWindow)
+	}
 	timestampRefreshThreshold := cfg.TimestampRefreshThreshold
 	if timestampRefreshThreshold == 0 {
 		log.Info("Sanitizing timestamp refresh threshold to 3 minutes")
 		timestampRefreshThreshold = time.Minute * 3
 	}
 
+	prefetcher := newPrefetcher(cfg.PrefetchWorkers, cfg.PrefetchDepth)
+	log.Info("Configured prefetching", "workers", prefetcher.workers, "depth", prefetcher.depth)
+
+	// Only the sequencer accepts transactions over RPC that may arrive ahead
+	// of the transactions before them
+	futureQueue := newFutureQueue(cfg.FutureTxLifetime, cfg.MaxFutureTxsPerSender, cfg.MaxFutureTxs)
+	if cfg.IsVerifier {
+		futureQueue = newFutureQueue(0, 0, 0)
+	}
+	if futureQueue.enabled() {
+		log.Info("Queueing future nonce transactions", "lifetime", futureQueue.lifetime, "max-per-sender", futureQueue.maxPerSender, "max", futureQueue.maxGlobal)
+	}
+	forceInclusionMargin := cfg.ForceInclusionMargin
+	if cfg.ForceInclusionPeriod > 0 {
+		if forceInclusionMargin <= 0 || forceInclusionMargin >= cfg.ForceInclusionPeriod {
+			forceInclusionMargin = cfg.ForceInclusionPeriod / 10
+			log.Info("Sanitizing force inclusion margin", "margin", forceInclusionMargin)
+		}
+		if !cfg.IsVerifier {
+			log.Info("Tracking enqueue force inclusion deadlines", "period", cfg.ForceInclusionPeriod, "margin", forceInclusionMargin)
+		}
+	}
+	contextPolicy, err := NewContextPolicy(cfg.ContextPolicy)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errBadConfig, err)
+	}
+	log.Info("Configured L1 context policy", "policy", contextPolicy)
+
+	ordering, err := NewOrderingPolicy(cfg.OrderingPolicy, cfg.EnqueueMaxDelay)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errBadConfig, err)
+	}
+	if !cfg.IsVerifier {
+		log.Info("Configured sequencer ordering", "policy", cfg.OrderingPolicy, "enqueue-max-delay", cfg.EnqueueMaxDelay)
+	}
+
 	// Layer 2 chainid
 	chainID := bc.Config().ChainID
 	if chainID == nil {
 		return nil, errors.New("Must configure with chain id")
 	}
 	// Initialize the rollup client
-	client := NewClient(cfg.RollupClientHttp, chainID)
-	log.Info("Configured rollup client", "url", cfg.RollupClientHttp, "chain-id", chainID.Uint64(), "ctc-deploy-height", cfg.CanonicalTransactionChainDeployHeight)
+	client, err := NewRollupClient(cfg, chainID)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot initialize rollup client: %w", err)
+	}
+	log.Info("Configured rollup client", "type", cfg.ClientType.String(), "url", cfg.RollupClientHttp, "chain-id", chainID.Uint64(), "ctc-deploy-height", cfg.CanonicalTransactionChainDeployHeight)
 
 	// Ensure sane values for the fee thresholds
 	if cfg.FeeThresholdDown != nil {
@@ -137,14 +246,32 @@ func NewSyncService(ctx context.Context, cfg Config, txpool *core.TxPool, bc *co
 				cfg.FeeThresholdUp)
 		}
 	}
+	l1GasPriceSmoother, err := fees.NewL1GasPriceSmoother(fees.SmoothingConfig{
+		EMAWeight:        cfg.L1GasPriceEMAWeight,
+		MaxChangePercent: cfg.L1GasPriceMaxChange,
+		Floor:            cfg.L1GasPriceFloor,
+		Ceiling:          cfg.L1GasPriceCeiling,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errBadConfig, err)
+	}
+	log.Info("L1 gas price smoothing", "ema-weight", cfg.L1GasPriceEMAWeight, "max-change", cfg.L1GasPriceMaxChange,
+		"floor", cfg.L1GasPriceFloor, "ceiling", cfg.L1GasPriceCeiling)
 	if cfg.MinL2GasLimit == nil {
 		value := new(big.Int)
 		log.Info("Sanitizing minimum L2 gas limit", "value", value)
 		cfg.MinL2GasLimit = value
 	}
 
-	if vm.EnableArbitraryContractDeployment != nil {
-		log.Info("Setting arbitrary contract deployment", "value", *vm.EnableArbitraryContractDeployment)
+	// A nil channel never receives, so clients that are not pushed elements
+	// only sync on the poll interval
+	var clientNotify <-chan struct{}
+	if n, ok := client.(notifier); ok {
+		clientNotify = n.Notify()
+	}
+
+	if cfg.EnableArbitraryContractDeployment != nil {
+		log.Info("Setting arbitrary contract deployment", "value", *cfg.EnableArbitraryContractDeployment)
 	}
 
 	service := SyncService{
@@ -155,9 +282,13 @@ func NewSyncService(ctx context.Context, cfg Config, txpool *core.TxPool, bc *co
 		syncing:                        atomic.Value{},
 		bc:                             bc,
 		txpool:                         txpool,
-		chainHeadCh:                    make(chan core.ChainHeadEvent, 1),
+		commitCh:                       make(chan TxCommitRequest),
+		txCommitTimeout:                txCommitTimeout,
+		maxTxsPerBlock:                 maxTxsPerBlock,
+		blockWindow:                    blockWindow,
 		eth1ChainId:                    cfg.Eth1ChainId,
 		client:                         client,
+		clientNotify:                   clientNotify,
 		db:                             db,
 		pollInterval:                   pollInterval,
 		timestampRefreshThreshold:      timestampRefreshThreshold,
@@ -169,15 +300,34 @@ func NewSyncService(ctx context.Context, cfg Config, txpool *core.TxPool, bc *co
 		minL2GasLimit:                  cfg.MinL2GasLimit,
 		feeThresholdDown:               cfg.FeeThresholdDown,
 		feeThresholdUp:                 cfg.FeeThresholdUp,
+		l1GasPriceSmoother:             l1GasPriceSmoother,
+		haltOnStateRootMismatch:        cfg.HaltOnStateRootMismatch,
+		prefetcher:                     prefetcher,
+		futureQueue:                    futureQueue,
+		futureRelease:                  make(chan struct{}, 1),
+		contextPolicy:                  contextPolicy,
+		enqueueDeadlines:               newEnqueueDeadlines(cfg.ForceInclusionPeriod, forceInclusionMargin),
+		ordering:                       ordering,
+		orderingNotify:                 make(chan struct{}, 1),
+		blockContextResolve:            make(chan uint64, blockContextResolveSize),
+	}
+	service.commitsDrained = sync.NewCond(&service.txLock)
+
+	// Stay halted across restarts until the mismatching state roots are
+	// resolved
+	if cfg.HaltOnStateRootMismatch {
+		if mismatches := rawdb.ReadStateRootMismatches(db, 0, 1); len(mismatches) != 0 {
+			log.Error("Halting on persisted state root mismatch", "index", mismatches[0].Index,
+				"local", mismatches[0].LocalRoot.Hex(), "remote", mismatches[0].RemoteRoot.Hex())
+			service.halted = 1
+		}
 	}
 
-	// The chainHeadSub is used to synchronize the SyncService with the chain.
-	// As the SyncService processes transactions, it waits until the transaction
-	// is added to the chain. This synchronization is required for handling
-	// reorgs and also favors safety over liveliness. If a transaction breaks
-	// things downstream, it is expected that this channel will halt ingestion
-	// of additional transactions by the SyncService.
-	service.chainHeadSub = service.bc.SubscribeChainHeadEvent(service.chainHeadCh)
+	// Restore the L1 context so that it does not start at zero when the
+	// sync service is not enabled
+	if context := rawdb.ReadHeadOVMContext(db); context != nil {
+		service.OVMContext = OVMContext{blockNumber: context.BlockNumber, timestamp: context.Timestamp}
+	}
 
 	// Initial sync service setup if it is enabled. This code depends on
 	// a remote server that indexes the layer one contracts. Place this
@@ -270,6 +420,7 @@ func (s *SyncService) Start() error {
 	if err := s.updateL1GasPrice(); err != nil {
 		return err
 	}
+	go s.blockContextLoop()
 
 	if s.verifier {
 		go s.VerifierLoop()
@@ -283,8 +434,19 @@ func (s *SyncService) Start() error {
 		if err := s.syncQueueToTip(); err != nil {
 			return fmt.Errorf("Sequencer cannot sync queue to tip: %w", err)
 		}
+		// From now on the sequencer and L1 to L2 transactions are applied in
+		// the order of the ordering policy
+		atomic.StoreInt32(&s.orderingActive, 1)
+		go s.orderingLoop()
 		s.setSyncStatus(false)
 		go s.SequencerLoop()
+		if s.futureQueue.enabled() {
+			go s.futureQueueLoop()
+			go s.futureReleaseLoop()
+		}
+		if s.enqueueDeadlines.enabled() {
+			go s.enqueueDeadlineLoop()
+		}
 	}
 	return nil
 }
@@ -305,11 +467,10 @@ func (s *SyncService) initializeLatestL1(ctcDeployHeight *big.Int) error {
 		if err != nil {
 			return fmt.Errorf("Cannot fetch ctc deploy block at height %d: %w", ctcDeployHeight.Uint64(), err)
 		}
-		s.SetLatestL1Timestamp(context.Timestamp)
-		s.SetLatestL1BlockNumber(context.BlockNumber)
+		s.initializeOVMContext(OVMContext{blockNumber: context.BlockNumber, timestamp: context.Timestamp})
 	} else {
 		log.Info("Found latest index", "index", *index)
-		block := s.bc.GetBlockByNumber(*index + 1)
+		block, txIndex := s.blockByIndex(*index)
 		if block == nil {
 			block = s.bc.CurrentBlock()
 			blockNum := block.Number().Uint64()
@@ -317,22 +478,17 @@ func (s *SyncService) initializeLatestL1(ctcDeployHeight *big.Int) error {
 				// This is recoverable with a reorg but should never happen
 				return fmt.Errorf("Current block height greater than index")
 			}
-			var idx *uint64
-			if blockNum > 0 {
-				num := blockNum - 1
-				idx = &num
-			}
+			idx := lastIndexInBlock(block)
 			s.SetLatestIndex(idx)
 			log.Info("Block not found, resetting index", "new", stringify(idx), "old", *index)
+			txIndex = len(block.Transactions()) - 1
 		}
 		txs := block.Transactions()
-		if len(txs) != 1 {
+		if txIndex < 0 || txIndex >= len(txs) {
 			log.Error("Unexpected number of transactions in block", "count", len(txs))
 			panic("Cannot recover OVM Context")
 		}
-		tx := txs[0]
-		s.SetLatestL1Timestamp(tx.L1Timestamp())
-		s.SetLatestL1BlockNumber(tx.L1BlockNumber().Uint64())
+		s.initializeOVMContext(txOVMContext(txs[txIndex]))
 	}
 	queueIndex := s.GetLatestEnqueueIndex()
 	if queueIndex == nil {
@@ -375,8 +531,12 @@ func (s *SyncService) IsSyncing() bool {
 // started by this service.
 func (s *SyncService) Stop() error {
 	s.scope.Close()
-	s.chainHeadSub.Unsubscribe()
-	close(s.chainHeadCh)
+
+	if closer, ok := s.client.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			log.Error("Cannot close rollup client", "msg", err)
+		}
+	}
 
 	if s.cancel != nil {
 		defer s.cancel()
@@ -388,7 +548,7 @@ func (s *SyncService) Stop() error {
 func (s *SyncService) VerifierLoop() {
 	log.Info("Starting Verifier Loop", "poll-interval", s.pollInterval, "timestamp-refresh-threshold", s.timestampRefreshThreshold)
 	t := time.NewTicker(s.pollInterval)
-	for ; true; <-t.C {
+	for ; true; s.waitForNextPoll(t) {
 		if err := s.updateL1GasPrice(); err != nil {
 			log.Error("Cannot update L1 gas price", "msg", err)
 		}
@@ -401,14 +561,29 @@ func (s *SyncService) VerifierLoop() {
 	}
 }
 
+// waitForNextPoll blocks until the next tick of the poll interval or until
+// the rollup client is pushed new elements
+func (s *SyncService) waitForNextPoll(t *time.Ticker) {
+	select {
+	case <-t.C:
+	case <-s.clientNotify:
+	}
+}
+
 // verify is the main logic for the Verifier. The verifier logic is different
 // depending on the Backend
 func (s *SyncService) verify() error {
+	if s.IsHalted() {
+		return fmt.Errorf("Verifier is halted: %w", errStateRootMismatch)
+	}
 	switch s.backend {
 	case BackendL1:
 		if err := s.syncBatchesToTip(); err != nil {
 			return fmt.Errorf("Verifier cannot sync transaction batches to tip: %w", err)
 		}
+		if err := s.syncStateRootBatchesToTip(); err != nil {
+			return fmt.Errorf("Verifier cannot verify state root batches: %w", err)
+		}
 	case BackendL2:
 		if err := s.syncTransactionsToTip(); err != nil {
 			return fmt.Errorf("Verifier cannot sync transactions with BackendL2: %w", err)
@@ -422,15 +597,13 @@ func (s *SyncService) verify() error {
 func (s *SyncService) SequencerLoop() {
 	log.Info("Starting Sequencer Loop", "poll-interval", s.pollInterval, "timestamp-refresh-threshold", s.timestampRefreshThreshold)
 	t := time.NewTicker(s.pollInterval)
-	for ; true; <-t.C {
+	for ; true; s.waitForNextPoll(t) {
 		if err := s.updateL1GasPrice(); err != nil {
 			log.Error("Cannot update L1 gas price", "msg", err)
 		}
-		s.txLock.Lock()
 		if err := s.sequence(); err != nil {
 			log.Error("Could not sequence", "error", err)
 		}
-		s.txLock.Unlock()
 
 		if err := s.updateGasPriceOracleCache(nil); err != nil {
 			log.Error("Cannot update L2 gas price", "msg", err)
@@ -448,9 +621,19 @@ func (s *SyncService) SequencerLoop() {
 // L1 is the source of truth. The sequencer concurrently accepts user
 // transactions via the RPC.
 func (s *SyncService) sequence() error {
+	// The enqueue transactions are applied by the ordering loop, which holds
+	// the txLock while applying them
+	if !s.isOrderingActive() {
+		s.txLock.Lock()
+		defer s.txLock.Unlock()
+	}
 	if err := s.syncQueueToTip(); err != nil {
 		return fmt.Errorf("Sequencer cannot sequence queue: %w", err)
 	}
+	if s.isOrderingActive() {
+		s.txLock.Lock()
+		defer s.txLock.Unlock()
+	}
 	if err := s.syncBatchesToTip(); err != nil {
 		return fmt.Errorf("Sequencer cannot sync transaction batches: %w", err)
 	}
@@ -471,6 +654,13 @@ func (s *SyncService) syncBatchesToTip() error {
 	return nil
 }
 
+func (s *SyncService) syncStateRootBatchesToTip() error {
+	if err := s.syncToTip(s.syncStateRootBatches, s.client.GetLatestStateRootBatchIndex); err != nil {
+		return fmt.Errorf("Cannot sync state root batches to tip: %w", err)
+	}
+	return nil
+}
+
 func (s *SyncService) syncTransactionsToTip() error {
 	sync := func() (*uint64, error) {
 		return s.syncTransactions(s.backend)
@@ -486,13 +676,19 @@ func (s *SyncService) syncTransactionsToTip() error {
 
 // updateL1GasPrice queries for the current L1 gas price and then stores it
 // in the L1 Gas Price Oracle. This must be called over time to properly
-// estimate the transaction fees that the sequencer should charge.
+// estimate the transaction fees that the sequencer should charge. The L1 gas
+// price is smoothed first so that a spike on L1 does not cause every quoted
+// transaction to be rejected.
 func (s *SyncService) updateL1GasPrice() error {
 	l1GasPrice, err := s.client.GetL1GasPrice()
 	if err != nil {
 		return fmt.Errorf("cannot fetch L1 gas price: %w", err)
 	}
-	s.RollupGpo.SetL1GasPrice(l1GasPrice)
+	smoothed := s.l1GasPriceSmoother.Smooth(l1GasPrice)
+	if smoothed.Cmp(l1GasPrice) != 0 {
+		log.Debug("Smoothed L1 gas price", "remote", l1GasPrice, "smoothed", smoothed)
+	}
+	s.RollupGpo.SetL1GasPrice(smoothed)
 	return nil
 }
 
@@ -565,18 +761,19 @@ func (s *SyncService) updateContext() error {
 	if err != nil {
 		return err
 	}
+	s.setLatestEthContext(context)
 	current := time.Unix(int64(s.GetLatestL1Timestamp()), 0)
 	next := time.Unix(int64(context.Timestamp), 0)
 	if next.Sub(current) > s.timestampRefreshThreshold {
 		log.Info("Updating Eth Context", "timetamp", context.Timestamp, "blocknumber", context.BlockNumber)
-		s.SetLatestL1BlockNumber(context.BlockNumber)
-		s.SetLatestL1Timestamp(context.Timestamp)
+		s.SetLatestL1Context(context.BlockNumber, context.Timestamp)
 	}
 	return nil
 }
 
 // Methods for safely accessing and storing the latest
-// L1 blocknumber and timestamp. These are held in memory.
+// L1 blocknumber and timestamp. These are held in memory and persisted so
+// that they survive a restart.
 
 // GetLatestL1Timestamp returns the OVMContext timestamp
 func (s *SyncService) GetLatestL1Timestamp() uint64 {
@@ -590,12 +787,103 @@ func (s *SyncService) GetLatestL1BlockNumber() uint64 {
 
 // SetLatestL1Timestamp will set the OVMContext timestamp
 func (s *SyncService) SetLatestL1Timestamp(ts uint64) {
-	atomic.StoreUint64(&s.OVMContext.timestamp, ts)
+	s.contextLock.Lock()
+	defer s.contextLock.Unlock()
+
+	s.storeOVMContext(OVMContext{blockNumber: s.GetLatestL1BlockNumber(), timestamp: ts})
 }
 
 // SetLatestL1BlockNumber will set the OVMContext blocknumber
 func (s *SyncService) SetLatestL1BlockNumber(bn uint64) {
-	atomic.StoreUint64(&s.OVMContext.blockNumber, bn)
+	s.contextLock.Lock()
+	defer s.contextLock.Unlock()
+
+	s.storeOVMContext(OVMContext{blockNumber: bn, timestamp: s.GetLatestL1Timestamp()})
+}
+
+// SetLatestL1Context will set the OVMContext blocknumber and timestamp at once
+func (s *SyncService) SetLatestL1Context(bn, ts uint64) {
+	s.contextLock.Lock()
+	defer s.contextLock.Unlock()
+
+	s.storeOVMContext(OVMContext{blockNumber: bn, timestamp: ts})
+}
+
+// ContextPolicy returns the policy for transactions whose L1 context goes
+// back in time
+func (s *SyncService) ContextPolicy() ContextPolicy {
+	return s.contextPolicy
+}
+
+// storeOVMContext sets and persists the OVMContext, the contextLock must be
+// held
+func (s *SyncService) storeOVMContext(context OVMContext) {
+	atomic.StoreUint64(&s.OVMContext.blockNumber, context.blockNumber)
+	atomic.StoreUint64(&s.OVMContext.timestamp, context.timestamp)
+	rawdb.WriteHeadOVMContext(s.db, &rawdb.OVMContext{BlockNumber: context.blockNumber, Timestamp: context.timestamp})
+}
+
+// initializeOVMContext sets the OVMContext on startup. A persisted context
+// that is ahead of the context derived from the chain was taken from L1
+// after the last transaction and is kept.
+func (s *SyncService) initializeOVMContext(context OVMContext) {
+	s.contextLock.Lock()
+	defer s.contextLock.Unlock()
+
+	if persisted := rawdb.ReadHeadOVMContext(s.db); persisted != nil {
+		restored := OVMContext{blockNumber: persisted.BlockNumber, timestamp: persisted.Timestamp}
+		if !restored.before(context) {
+			log.Info("Restored OVM Context", "blocknumber", restored.blockNumber, "timestamp", restored.timestamp)
+			context = restored
+		}
+	}
+	s.storeOVMContext(context)
+}
+
+// advanceOVMContext applies the ordering rules to a transaction that is
+// applied to the tip and advances the OVMContext past it. The context of a
+// confirmed transaction is final and only moves the OVMContext forward.
+func (s *SyncService) advanceOVMContext(tx *types.Transaction, confirmed bool) error {
+	s.contextLock.Lock()
+	defer s.contextLock.Unlock()
+
+	latest := OVMContext{blockNumber: s.GetLatestL1BlockNumber(), timestamp: s.GetLatestL1Timestamp()}
+	next := follow(latest, tx)
+	if !confirmed {
+		var err error
+		if next, err = s.contextPolicy.enforce(latest, tx); err != nil {
+			return err
+		}
+	}
+	if next != latest {
+		log.Debug("Updating OVM context based on new transaction", "timestamp", next.timestamp, "blocknumber", next.blockNumber, "queue-origin", tx.QueueOrigin())
+	}
+	s.storeOVMContext(next)
+	return nil
+}
+
+// setLatestEthContext remembers the latest L1 block fetched so that its hash
+// does not have to be looked up again
+func (s *SyncService) setLatestEthContext(context *EthContext) {
+	s.contextLock.Lock()
+	defer s.contextLock.Unlock()
+
+	s.latestEthContext = context
+}
+
+// cachedL1BlockHash returns the hash of the L1 block with the number when the
+// block was fetched already
+func (s *SyncService) cachedL1BlockHash(number uint64) *common.Hash {
+	s.contextLock.Lock()
+	defer s.contextLock.Unlock()
+
+	for _, context := range []*EthContext{s.latestEthContext, s.resolvedEthContext} {
+		if context != nil && context.BlockNumber == number {
+			hash := context.BlockHash
+			return &hash
+		}
+	}
+	return nil
 }
 
 // GetLatestEnqueueIndex reads the last queue index processed
@@ -684,6 +972,35 @@ func (s *SyncService) SetLatestBatchIndex(index *uint64) {
 	}
 }
 
+// GetLatestStateRootBatchIndex reads the last verified state root batch
+func (s *SyncService) GetLatestStateRootBatchIndex() *uint64 {
+	return rawdb.ReadHeadStateRootBatchIndex(s.db)
+}
+
+// GetNextStateRootBatchIndex reads the index of the next state root batch to
+// verify
+func (s *SyncService) GetNextStateRootBatchIndex() uint64 {
+	index := s.GetLatestStateRootBatchIndex()
+	if index == nil {
+		return 0
+	}
+	return *index + 1
+}
+
+// SetLatestStateRootBatchIndex writes the index of the last state root batch
+// that was verified
+func (s *SyncService) SetLatestStateRootBatchIndex(index *uint64) {
+	if index != nil {
+		rawdb.WriteHeadStateRootBatchIndex(s.db, *index)
+	}
+}
+
+// IsHalted returns true when the verifier stopped syncing due to a state root
+// mismatch
+func (s *SyncService) IsHalted() bool {
+	return atomic.LoadInt32(&s.halted) == 1
+}
+
 // applyTransaction is a higher level API for applying a transaction
 func (s *SyncService) applyTransaction(tx *types.Transaction) error {
 	if tx.GetMeta().Index != nil {
@@ -715,7 +1032,9 @@ func (s *SyncService) applyIndexedTransaction(tx *types.Transaction) error {
 }
 
 // applyHistoricalTransaction will compare a historical transaction against what
-// is locally indexed. This will trigger a reorg in the future
+// is locally indexed. When the transactions do not match, the local chain is
+// rewound to the block before the mismatch and the transaction is applied to
+// the new tip. The transactions that follow it are then applied as usual.
 func (s *SyncService) applyHistoricalTransaction(tx *types.Transaction) error {
 	if tx == nil {
 		return errors.New("Transaction is nil in applyHistoricalTransaction")
@@ -724,19 +1043,274 @@ func (s *SyncService) applyHistoricalTransaction(tx *types.Transaction) error {
 	if index == nil {
 		return errors.New("No index is found in applyHistoricalTransaction")
 	}
-	// Handle the off by one
-	block := s.bc.GetBlockByNumber(*index + 1)
+	block, txIndex := s.blockByIndex(*index)
+	if block == nil {
+		return fmt.Errorf("Block for index %d is not found", *index)
+	}
+	local := block.Transactions()[txIndex]
+	if isCtcTxEqual(tx, local) {
+		log.Debug("Historical transaction matches", "index", *index, "hash", tx.Hash().Hex())
+		return nil
+	}
+	log.Error("Mismatched transaction", "index", *index, "local", local.Hash().Hex(), "remote", tx.Hash().Hex())
+	if err := s.reorg(*index); err != nil {
+		return fmt.Errorf("Cannot reorg to index %d: %w", *index, err)
+	}
+	// The transactions before the mismatched index that shared its block
+	// were removed as well and must be synced again first
+	if next := s.GetNextIndex(); next != *index {
+		return fmt.Errorf("Rewound to index %d while applying index %d", next, *index)
+	}
+	// The transaction at the mismatched index is now the next transaction to
+	// be applied to the tip of the chain
+	return s.applyTransactionToTip(tx)
+}
+
+// reorg rewinds the chain so that the transaction with the CTC index `index`
+// becomes the next transaction to apply. The block holding the transaction at
+// `index` is removed along with every block after it. The rollup indices and
+// the OVMContext are reset to match the new tip. When the block holds more
+// than one transaction, the transactions before `index` in it are removed too.
+// The miner must not build on the chain while it is rewound, so the
+// transactions in flight are drained first. The sequencer transactions after
+// `index` that are removed are reported in the ReorgEvent.
+func (s *SyncService) reorg(index uint64) error {
+	s.drainCommits()
+
+	oldHead := s.bc.CurrentBlock().NumberU64()
+	block, _ := s.blockByIndex(index)
 	if block == nil {
-		return fmt.Errorf("Block %d is not found", *index+1)
+		return fmt.Errorf("Block for index %d is not found", index)
+	}
+	newHead := block.NumberU64() - 1
+	if newHead >= oldHead {
+		return fmt.Errorf("Cannot reorg to %d with tip %d", newHead, oldHead)
 	}
+	orphaned, err := s.orphanedTransactions(index, block.NumberU64(), oldHead)
+	if err != nil {
+		return err
+	}
+	log.Warn("Reorganizing chain", "index", index, "old-head", oldHead, "new-head", newHead)
+	if err := s.bc.SetHead(newHead); err != nil {
+		return fmt.Errorf("Cannot set head to %d: %w", newHead, err)
+	}
+	block = s.bc.CurrentBlock()
+	if block.NumberU64() != newHead {
+		return fmt.Errorf("Unexpected head after reorg: got %d, expected %d", block.NumberU64(), newHead)
+	}
+	if err := s.resetToBlock(block); err != nil {
+		return err
+	}
+
+	depth := oldHead - newHead
+	reorgCounter.Inc(1)
+	reorgDepthHistogram.Update(int64(depth))
+	log.Info("Reorganized chain", "index", index, "depth", depth, "hash", block.Hash().Hex())
+	for _, tx := range orphaned {
+		log.Warn("Removed sequencer transaction", "index", stringify(tx.GetMeta().Index), "hash", tx.Hash().Hex())
+	}
+	reorgOrphanedCounter.Inc(int64(len(orphaned)))
+
+	s.reorgFeed.Send(ReorgEvent{
+		Index:    index,
+		OldHead:  oldHead,
+		NewHead:  newHead,
+		Orphaned: orphaned,
+	})
+	return nil
+}
+
+// orphanedTransactions returns the sequencer transactions after the CTC index
+// `index` in the blocks from `start` to `end` (inclusive)
+func (s *SyncService) orphanedTransactions(index, start, end uint64) ([]*types.Transaction, error) {
+	var orphaned []*types.Transaction
+	for number := start; number <= end; number++ {
+		block := s.bc.GetBlockByNumber(number)
+		if block == nil {
+			return nil, fmt.Errorf("Block %d is not found", number)
+		}
+		for _, tx := range block.Transactions() {
+			if tx.QueueOrigin() != types.QueueOriginSequencer {
+				continue
+			}
+			if i := tx.GetMeta().Index; i != nil && *i > index {
+				orphaned = append(orphaned, tx)
+			}
+		}
+	}
+	return orphaned, nil
+}
+
+// drainCommits blocks until the miner answered every transaction in flight,
+// no transactions are taken meanwhile. The miner is idle once it returns and
+// stays idle for as long as the txLock is held, which must be held when it is
+// called.
+func (s *SyncService) drainCommits() {
+	s.draining = true
+	for s.pendingCommits > 0 {
+		s.commitsDrained.Wait()
+	}
+	s.draining = false
+}
+
+// resetToBlock resets the locally tracked CTC index, queue index, verified
+// index and OVMContext so that they reflect the transactions up to and
+// including the given block. It is used after the chain has been rewound.
+func (s *SyncService) resetToBlock(block *types.Block) error {
+	number := block.NumberU64()
+	s.deleteBlockOVMContexts(number)
+	s.deleteRewoundTransactions(block)
+	s.rewindBatchIndices(block)
+	if number == 0 {
+		rawdb.DeleteHeadIndex(s.db)
+		rawdb.DeleteHeadVerifiedIndex(s.db)
+		rawdb.DeleteHeadQueueIndex(s.db)
+		s.SetLatestL1Context(0, 0)
+		return nil
+	}
+
 	txs := block.Transactions()
-	if len(txs) != 1 {
-		return fmt.Errorf("More than one transaction found in block %d", *index+1)
+	if len(txs) == 0 {
+		return fmt.Errorf("No transactions in block %d", number)
+	}
+	tx := txs[len(txs)-1]
+	index := *lastIndexInBlock(block)
+	s.SetLatestIndex(&index)
+	if verified := s.GetLatestVerifiedIndex(); verified != nil && *verified > index {
+		s.SetLatestVerifiedIndex(&index)
+	}
+	context := txOVMContext(tx)
+	s.SetLatestL1Context(context.blockNumber, context.timestamp)
+
+	// Walk backwards to find the last applied queue index as not every
+	// transaction is an L1 to L2 transaction
+	queueIndex := lastQueueIndexInBlock(block)
+	for queueIndex == nil {
+		number--
+		if number == 0 {
+			break
+		}
+		block = s.bc.GetBlockByNumber(number)
+		if block == nil {
+			return fmt.Errorf("Block %d is not found", number)
+		}
+		queueIndex = lastQueueIndexInBlock(block)
 	}
-	if !isCtcTxEqual(tx, txs[0]) {
-		log.Error("Mismatched transaction", "index", *index)
+	if queueIndex != nil {
+		s.SetLatestEnqueueIndex(queueIndex)
 	} else {
-		log.Debug("Historical transaction matches", "index", *index, "hash", tx.Hash().Hex())
+		rawdb.DeleteHeadQueueIndex(s.db)
+	}
+	log.Info("Reset rollup indices", "index", stringify(s.GetLatestIndex()), "queue-index", stringify(s.GetLatestEnqueueIndex()),
+		"verified-index", stringify(s.GetLatestVerifiedIndex()))
+	return nil
+}
+
+// rewindBatchIndices moves the latest transaction batch and state root batch
+// back to before the batches that hold the transactions after the block, so
+// that the batches are synced and verified again from the first rewound
+// transaction on. Batches that were processed before they were recorded
+// locally are not rewound.
+func (s *SyncService) rewindBatchIndices(block *types.Block) {
+	next := uint64(0)
+	if index := lastIndexInBlock(block); index != nil {
+		next = *index + 1
+	}
+	for index := s.GetLatestBatchIndex(); index != nil; index = s.GetLatestBatchIndex() {
+		batch := rawdb.ReadTransactionBatch(s.db, *index)
+		if batch == nil || batch.PrevTotalElements+batch.Size <= next {
+			break
+		}
+		if *index == 0 {
+			rawdb.DeleteHeadBatchIndex(s.db)
+			break
+		}
+		prev := *index - 1
+		s.SetLatestBatchIndex(&prev)
+	}
+	for index := s.GetLatestStateRootBatchIndex(); index != nil; index = s.GetLatestStateRootBatchIndex() {
+		batch := rawdb.ReadStateRootBatch(s.db, *index)
+		if batch == nil || batch.PrevTotalElements+batch.Size <= next {
+			break
+		}
+		if *index == 0 {
+			rawdb.DeleteHeadStateRootBatchIndex(s.db)
+			break
+		}
+		prev := *index - 1
+		s.SetLatestStateRootBatchIndex(&prev)
+	}
+}
+
+// deleteRewoundTransactions removes the batch membership and the metadata of
+// the transactions after the block, which were removed from the chain
+func (s *SyncService) deleteRewoundTransactions(block *types.Block) {
+	next := uint64(0)
+	if index := lastIndexInBlock(block); index != nil {
+		next = *index + 1
+	}
+	for index := next; rawdb.ReadTxBatchEntry(s.db, index) != nil; index++ {
+		rawdb.DeleteTxBatchEntry(s.db, index)
+	}
+	// Blocks that are canonical again were added after the block was read
+	for number := block.NumberU64() + 1; ; number++ {
+		if rawdb.ReadCanonicalHash(s.db, number) != (common.Hash{}) || rawdb.ReadTransactionMetaRaw(s.db, number) == nil {
+			break
+		}
+		for txIndex := uint64(0); rawdb.ReadTransactionMetaRawAt(s.db, number, txIndex) != nil; txIndex++ {
+			rawdb.DeleteTransactionMetaAt(s.db, number, txIndex)
+		}
+	}
+}
+
+// blockByIndex returns the block that holds the transaction with the CTC
+// index and the position of the transaction in the block. Blocks that hold a
+// single transaction and were written before the CTC lookup index existed are
+// found by their block number, which is the index plus one.
+func (s *SyncService) blockByIndex(index uint64) (*types.Block, int) {
+	if entry := rawdb.ReadCTCLookupEntry(s.db, index); entry != nil {
+		block := s.bc.GetBlockByNumber(entry.BlockNumber)
+		if block != nil && block.Hash() == entry.BlockHash && entry.TxIndex < uint64(len(block.Transactions())) {
+			return block, int(entry.TxIndex)
+		}
+	}
+	// Handle the off by one
+	block := s.bc.GetBlockByNumber(index + 1)
+	if block == nil || len(block.Transactions()) == 0 {
+		return nil, 0
+	}
+	if meta := block.Transactions()[0].GetMeta(); meta.Index != nil && *meta.Index != index {
+		return nil, 0
+	}
+	return block, 0
+}
+
+// lastIndexInBlock returns the CTC index of the last transaction in the block
+// or nil for the genesis block
+func lastIndexInBlock(block *types.Block) *uint64 {
+	if block.NumberU64() == 0 {
+		return nil
+	}
+	if txs := block.Transactions(); len(txs) != 0 {
+		if index := txs[len(txs)-1].GetMeta().Index; index != nil {
+			last := *index
+			return &last
+		}
+	}
+	// Transactions without an index are in blocks that hold a single
+	// transaction, handle the off by one
+	index := block.NumberU64() - 1
+	return &index
+}
+
+// lastQueueIndexInBlock returns the queue index of the last L1 to L2
+// transaction in the block or nil if there is none
+func lastQueueIndexInBlock(block *types.Block) *uint64 {
+	txs := block.Transactions()
+	for i := len(txs) - 1; i >= 0; i-- {
+		if queueIndex := txs[i].GetMeta().QueueIndex; queueIndex != nil {
+			return queueIndex
+		}
 	}
 	return nil
 }
@@ -746,42 +1320,48 @@ func (s *SyncService) applyHistoricalTransaction(tx *types.Transaction) error {
 // the chain. It is assumed that validation around the index has already
 // happened.
 func (s *SyncService) applyTransactionToTip(tx *types.Transaction) error {
+	req, err := s.submitTransactionToTip(tx)
+	if err != nil {
+		return err
+	}
+	err = s.waitForCommit(req)
+	s.commitDone(err)
+	return err
+}
+
+// submitTransactionToTip does the sanity checks of applyTransactionToTip and
+// hands the transaction to the miner. It returns as soon as the miner took the
+// transaction, the outcome of including it is sent on the Result channel of the
+// returned request.
+func (s *SyncService) submitTransactionToTip(tx *types.Transaction) (*TxCommitRequest, error) {
 	if tx == nil {
-		return errors.New("nil transaction passed to applyTransactionToTip")
+		return nil, errors.New("nil transaction passed to applyTransactionToTip")
+	}
+	// The indices of the transactions in flight follow each other, when one
+	// of them failed no transactions are taken until the indices are reset
+	if s.pendingFailed {
+		return nil, fmt.Errorf("%w: pending block failed", ErrTxCommitFailed)
+	}
+	if s.draining {
+		return nil, fmt.Errorf("%w: chain is being reorganized", ErrTxCommitFailed)
 	}
 	// Queue Origin L1 to L2 transactions must have a timestamp that is set by
 	// the L1 block that holds the transaction. This should never happen but is
 	// a sanity check to prevent fraudulent execution.
 	if tx.QueueOrigin() == types.QueueOriginL1ToL2 {
 		if tx.L1Timestamp() == 0 {
-			return fmt.Errorf("Queue origin L1 to L2 transaction without a timestamp: %s", tx.Hash().Hex())
-		}
-	}
-	// If there is no OVM timestamp assigned to the transaction, then assign a
-	// timestamp and blocknumber to it. This should only be the case for queue
-	// origin sequencer transactions that come in via RPC. The L1 to L2
-	// transactions that come in via `enqueue` should have a timestamp set based
-	// on the L1 block that it was included in.
-	// Note that Ethereum Layer one consensus rules dictate that the timestamp
-	// must be strictly increasing between blocks, so no need to check both the
-	// timestamp and the blocknumber.
-	if tx.L1Timestamp() == 0 {
-		ts := s.GetLatestL1Timestamp()
-		bn := s.GetLatestL1BlockNumber()
-		tx.SetL1Timestamp(ts)
-		tx.SetL1BlockNumber(bn)
-	} else if tx.L1Timestamp() > s.GetLatestL1Timestamp() {
-		// If the timestamp of the transaction is greater than the sync
-		// service's locally maintained timestamp, update the timestamp and
-		// blocknumber to equal that of the transaction's. This should happen
-		// with `enqueue` transactions.
-		ts := tx.L1Timestamp()
-		bn := tx.L1BlockNumber()
-		s.SetLatestL1Timestamp(ts)
-		s.SetLatestL1BlockNumber(bn.Uint64())
-		log.Debug("Updating OVM context based on new transaction", "timestamp", ts, "blocknumber", bn.Uint64(), "queue-origin", tx.QueueOrigin())
-	} else if tx.L1Timestamp() < s.GetLatestL1Timestamp() {
-		log.Error("Timestamp monotonicity violation", "hash", tx.Hash().Hex())
+			return nil, fmt.Errorf("Queue origin L1 to L2 transaction without a timestamp: %s", tx.Hash().Hex())
+		}
+	}
+	// Queue origin sequencer transactions that come in via RPC are assigned
+	// the latest L1 context, the L1 to L2 transactions that come in via
+	// `enqueue` have the context of the L1 block that they were included in.
+	// Contexts that go back in time are handled by the context policy,
+	// transactions that already have an index were read from a batch and
+	// are executed with the context that they were confirmed with.
+	confirmed := tx.GetMeta().Index != nil
+	if err := s.advanceOVMContext(tx, confirmed); err != nil {
+		return nil, err
 	}
 
 	if tx.GetMeta().Index == nil {
@@ -799,20 +1379,209 @@ func (s *SyncService) applyTransactionToTip(tx *types.Transaction) error {
 	// The index was set above so it is safe to dereference
 	log.Debug("Applying transaction to tip", "index", *tx.GetMeta().Index, "hash", tx.Hash().Hex())
 
-	txs := types.Transactions{tx}
-	s.txFeed.Send(core.NewTxsEvent{Txs: txs})
-	// Block until the transaction has been added to the chain
+	s.pendingCommits++
+	req, err := s.sendCommitRequest(tx, confirmed)
+	if err != nil {
+		s.commitDone(err)
+		return nil, fmt.Errorf("Cannot commit transaction %s: %w", tx.Hash().Hex(), err)
+	}
+	return req, nil
+}
+
+// commitDone records the outcome of a transaction that was handed to the
+// miner. When a transaction fails, the transactions in flight fail with it and
+// the indices are reset to the tip once none of them is in flight anymore.
+// It must be called with the txLock held.
+func (s *SyncService) commitDone(err error) {
+	s.pendingCommits--
+	if err != nil {
+		s.pendingFailed = true
+	}
+	if s.pendingCommits != 0 {
+		return
+	}
+	if s.pendingFailed {
+		s.resetToTip()
+		s.pendingFailed = false
+	}
+	s.commitsDrained.Broadcast()
+}
+
+// sendCommitRequest hands the transaction to the miner. It gives up with
+// ErrTxCommitTimeout when the miner does not take the transaction within the
+// commit timeout, so that callers never hang. The deadline of the request is
+// set before waitForCommit starts its own timeout, so the miner always gives
+// up first.
+func (s *SyncService) sendCommitRequest(tx *types.Transaction, confirmed bool) (*TxCommitRequest, error) {
+	timeout := time.NewTimer(s.txCommitTimeout)
+	defer timeout.Stop()
+
+	req := TxCommitRequest{
+		Tx:        tx,
+		Result:    make(chan TxCommitResult, 1),
+		Confirmed: confirmed,
+		Deadline:  time.Now().Add(s.txCommitTimeout),
+	}
+	// The fee of a sequencer transaction is recorded with the prices of the
+	// oracle at the time that it is submitted
+	if !s.verifier && s.RollupGpo != nil && tx.QueueOrigin() == types.QueueOriginSequencer {
+		req.L1GasPrice, _ = s.RollupGpo.SuggestL1GasPrice(context.Background())
+		req.L2GasPrice, _ = s.RollupGpo.SuggestL2GasPrice(context.Background())
+	}
+	select {
+	case s.commitCh <- req:
+		return &req, nil
+	case <-timeout.C:
+		return nil, fmt.Errorf("%w: miner did not accept transaction", ErrTxCommitTimeout)
+	case <-s.ctx.Done():
+		return nil, s.ctx.Err()
+	}
+}
+
+// waitForCommit blocks until the miner answers with the block that includes
+// the transaction or with an error. It gives up with ErrTxCommitTimeout when
+// the miner does not answer within the commit timeout.
+func (s *SyncService) waitForCommit(req *TxCommitRequest) error {
+	timeout := time.NewTimer(s.txCommitTimeout)
+	defer timeout.Stop()
+
+	tx := req.Tx
 	log.Trace("Waiting for transaction to be added to chain", "hash", tx.Hash().Hex())
-	<-s.chainHeadCh
+	var err error
+	select {
+	case res := <-req.Result:
+		if res.Err != nil {
+			err = res.Err
+		} else if res.Block == nil {
+			err = fmt.Errorf("%w: no block returned", ErrTxCommitFailed)
+		} else {
+			log.Trace("Transaction added to chain", "hash", tx.Hash().Hex(), "block", res.Block.NumberU64())
+			s.writeTransactionFee(req)
+			s.writeBlockOVMContext(res.Block)
+			return nil
+		}
+	case <-timeout.C:
+		err = fmt.Errorf("%w: miner did not include transaction", ErrTxCommitTimeout)
+	case <-s.ctx.Done():
+		err = s.ctx.Err()
+	}
+	return fmt.Errorf("Cannot commit transaction %s: %w", tx.Hash().Hex(), err)
+}
 
-	return nil
+// writeBlockOVMContext stores the L1 context that the block was executed with.
+// The hash of the L1 block that anchors it is taken from the L1 blocks that
+// were fetched already, otherwise it is looked up in the background.
+func (s *SyncService) writeBlockOVMContext(block *types.Block) {
+	txs := block.Transactions()
+	if len(txs) == 0 {
+		return
+	}
+	// The txs of a block share their L1 context
+	context := txOVMContext(txs[0])
+	hash := s.cachedL1BlockHash(context.blockNumber)
+
+	s.blockContextLock.Lock()
+	rawdb.WriteBlockOVMContext(s.db, block.NumberU64(), &rawdb.BlockOVMContext{
+		BlockNumber: context.blockNumber,
+		Timestamp:   context.timestamp,
+		BlockHash:   hash,
+	})
+	s.blockContextLock.Unlock()
+	if hash != nil {
+		return
+	}
+	select {
+	case s.blockContextResolve <- block.NumberU64():
+	default:
+		log.Debug("Skipping L1 block hash lookup", "number", block.NumberU64(), "l1-blocknumber", context.blockNumber)
+	}
+}
+
+// blockContextLoop looks up the hashes of the L1 blocks that anchor the L2
+// blocks, away from the path that applies transactions
+func (s *SyncService) blockContextLoop() {
+	for {
+		select {
+		case number := <-s.blockContextResolve:
+			s.resolveBlockOVMContext(number)
+		case <-s.ctx.Done():
+			return
+		}
+	}
+}
+
+// resolveBlockOVMContext looks up the hash of the L1 block that anchors the L2
+// block with the number. The hash stays unknown when the lookup fails.
+func (s *SyncService) resolveBlockOVMContext(number uint64) {
+	context := rawdb.ReadBlockOVMContext(s.db, number)
+	if context == nil || context.BlockHash != nil {
+		return
+	}
+	hash := s.cachedL1BlockHash(context.BlockNumber)
+	if hash == nil {
+		resolved, err := s.client.GetEthContext(context.BlockNumber)
+		if err != nil {
+			log.Error("Cannot get L1 block hash", "blocknumber", context.BlockNumber, "err", err)
+			return
+		}
+		s.contextLock.Lock()
+		s.resolvedEthContext = resolved
+		s.contextLock.Unlock()
+		hash = &resolved.BlockHash
+	}
+	// The block may have been removed from the chain meanwhile
+	s.blockContextLock.Lock()
+	defer s.blockContextLock.Unlock()
+	if current := rawdb.ReadBlockOVMContext(s.db, number); current != nil && current.BlockNumber == context.BlockNumber {
+		current.BlockHash = hash
+		rawdb.WriteBlockOVMContext(s.db, number, current)
+	}
+}
+
+// deleteBlockOVMContexts removes the L1 contexts of the blocks after the head
+// that were removed from the chain
+func (s *SyncService) deleteBlockOVMContexts(head uint64) {
+	s.blockContextLock.Lock()
+	defer s.blockContextLock.Unlock()
+
+	for number := head + 1; rawdb.ReadBlockOVMContext(s.db, number) != nil; number++ {
+		rawdb.DeleteBlockOVMContext(s.db, number)
+	}
+}
+
+// writeTransactionFee stores the L1 and L2 fee components that the sequencer
+// charged for a queue origin sequencer transaction, using the gas prices of
+// the oracle that were captured when the transaction was submitted
+func (s *SyncService) writeTransactionFee(req *TxCommitRequest) {
+	if req.L1GasPrice == nil || req.L2GasPrice == nil {
+		return
+	}
+	tx := req.Tx
+	l2GasLimit := fees.DecodeL2GasLimit(new(big.Int).SetUint64(tx.Gas()))
+	breakdown := fees.CalculateFeeBreakdown(tx.Data(), req.L1GasPrice, l2GasLimit, req.L2GasPrice)
+	rawdb.WriteTransactionFee(s.db, tx.Hash(), &rawdb.TransactionFee{
+		L1GasUsed:  breakdown.L1GasLimit.Uint64(),
+		L1GasPrice: breakdown.L1GasPrice,
+		L1Fee:      breakdown.L1Fee,
+		L2GasPrice: breakdown.L2GasPrice,
+		L2Fee:      breakdown.L2Fee,
+	})
+}
+
+// resetToTip resets the rollup indices and the OVMContext to the tip of the
+// chain after a transaction could not be added to it
+func (s *SyncService) resetToTip() {
+	if err := s.resetToBlock(s.bc.CurrentBlock()); err != nil {
+		log.Error("Cannot reset rollup indices", "msg", err)
+	}
 }
 
 // applyBatchedTransaction applies transactions that were batched to layer one.
 // The sequencer checks for batches over time to make sure that it does not
 // deviate from the L1 state and this is the main method of transaction
-// ingestion for the verifier.
-func (s *SyncService) applyBatchedTransaction(tx *types.Transaction) error {
+// ingestion for the verifier. The batch that the transaction was submitted in
+// is recorded so that its status can be reported over RPC.
+func (s *SyncService) applyBatchedTransaction(tx *types.Transaction, batch *Batch) error {
 	if tx == nil {
 		return errors.New("nil transaction passed into applyBatchedTransaction")
 	}
@@ -825,6 +1594,13 @@ func (s *SyncService) applyBatchedTransaction(tx *types.Transaction) error {
 	if err != nil {
 		return fmt.Errorf("Cannot apply batched transaction: %w", err)
 	}
+	if batch != nil {
+		rawdb.WriteTxBatchEntry(s.db, *index, &rawdb.TxBatchEntry{
+			BatchIndex:    batch.Index,
+			L1BlockNumber: batch.BlockNumber,
+			Submitter:     batch.Submitter,
+		})
+	}
 	s.SetLatestVerifiedIndex(index)
 	return nil
 }
@@ -915,21 +1691,295 @@ func (s *SyncService) ValidateAndApplySequencerTransaction(tx *types.Transaction
 	if tx == nil {
 		return errors.New("nil transaction passed to ValidateAndApplySequencerTransaction")
 	}
+	// Sequencer transactions would only delay an enqueue that anyone can
+	// already force onto the chain on L1
+	if s.enqueueDeadlines.overdue(time.Now()) {
+		return errEnqueueOverdue
+	}
 	if err := s.verifyFee(tx); err != nil {
 		return err
 	}
 	s.txLock.Lock()
-	defer s.txLock.Unlock()
 	log.Trace("Sequencer transaction validation", "hash", tx.Hash().Hex())
 
 	qo := tx.QueueOrigin()
 	if qo != types.QueueOriginSequencer {
+		s.txLock.Unlock()
 		return fmt.Errorf("invalid transaction with queue origin %d", qo)
 	}
 	if err := s.txpool.ValidateTx(tx); err != nil {
+		s.txLock.Unlock()
+		if errors.Is(err, core.ErrNonceTooHigh) && s.futureQueue.enabled() {
+			return s.queueFutureTransaction(tx)
+		}
 		return fmt.Errorf("invalid transaction: %w", err)
 	}
-	return s.applyTransaction(tx)
+	if !s.isOrderingActive() {
+		return s.applySequencerTransaction(tx)
+	}
+	s.txLock.Unlock()
+
+	from, _ := types.Sender(s.signer, tx)
+	err := s.applyOrdered(&OrderedTx{Tx: tx, From: from})
+	// The transaction may have been ordered ahead of a lower nonce of its
+	// sender
+	if errors.Is(err, core.ErrNonceTooHigh) && s.futureQueue.enabled() {
+		return s.queueFutureTransaction(tx)
+	}
+	return err
+}
+
+// applySequencerTransaction applies a validated sequencer transaction. It must
+// be called with the txLock held and releases it.
+func (s *SyncService) applySequencerTransaction(tx *types.Transaction) error {
+	wait, err := s.submitSequencerTransaction(tx)
+	if err != nil {
+		return err
+	}
+	return wait()
+}
+
+// submitSequencerTransaction hands a validated sequencer transaction to the
+// miner. It must be called with the txLock held and releases it. The returned
+// function blocks until the transaction is included in the chain.
+func (s *SyncService) submitSequencerTransaction(tx *types.Transaction) (func() error, error) {
+	if s.maxTxsPerBlock <= 1 {
+		defer s.txLock.Unlock()
+		if err := s.applyTransaction(tx); err != nil {
+			return nil, err
+		}
+		return func() error { return nil }, nil
+	}
+	// With multi transaction blocks the lock is only held until the miner
+	// took the transaction, so that the transactions that follow can be
+	// included in the same block
+	req, err := s.submitTransactionToTip(tx)
+	s.txLock.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	return func() error {
+		err := s.waitForCommit(req)
+		s.txLock.Lock()
+		s.commitDone(err)
+		s.txLock.Unlock()
+		return err
+	}, nil
+}
+
+// isOrderingActive returns whether transactions are applied in the order of
+// the ordering policy by the ordering loop
+func (s *SyncService) isOrderingActive() bool {
+	return atomic.LoadInt32(&s.orderingActive) == 1
+}
+
+// applyOrdered hands a transaction to the ordering policy and blocks until
+// the ordering loop applied it
+func (s *SyncService) applyOrdered(otx *OrderedTx) error {
+	otx.Time = time.Now()
+	otx.result = make(chan error, 1)
+
+	s.orderingLock.Lock()
+	otx.Seq = s.orderingSeq
+	s.orderingSeq++
+	s.ordering.Push(otx)
+	orderingPendingGauge.Update(int64(s.ordering.Len()))
+	s.orderingLock.Unlock()
+
+	select {
+	case s.orderingNotify <- struct{}{}:
+	default:
+	}
+	select {
+	case err := <-otx.result:
+		return err
+	case <-s.ctx.Done():
+		return s.ctx.Err()
+	}
+}
+
+// orderingLoop applies the transactions that wait to be applied in the order
+// of the ordering policy
+func (s *SyncService) orderingLoop() {
+	for {
+		select {
+		case <-s.orderingNotify:
+		case <-s.ctx.Done():
+			return
+		}
+		for {
+			s.orderingLock.Lock()
+			otx := s.ordering.Pop(time.Now())
+			orderingPendingGauge.Update(int64(s.ordering.Len()))
+			s.orderingLock.Unlock()
+			if otx == nil {
+				break
+			}
+			orderingWaitTimer.UpdateSince(otx.Time)
+			s.applyOrderedTx(otx)
+		}
+	}
+}
+
+// applyOrderedTx applies a transaction that was popped from the ordering
+// policy and reports the outcome to the caller of applyOrdered. The ordering
+// loop moves on as soon as the miner took a sequencer transaction, so that
+// multi transaction blocks can be filled.
+func (s *SyncService) applyOrderedTx(otx *OrderedTx) {
+	s.txLock.Lock()
+	if otx.Enqueue {
+		err := s.applyTransaction(otx.Tx)
+		s.txLock.Unlock()
+		otx.result <- err
+		return
+	}
+	// The state may have changed while the transaction was waiting
+	if err := s.txpool.ValidateTx(otx.Tx); err != nil {
+		s.txLock.Unlock()
+		otx.result <- fmt.Errorf("invalid transaction: %w", err)
+		return
+	}
+	wait, err := s.submitSequencerTransaction(otx.Tx)
+	if err != nil {
+		otx.result <- err
+		return
+	}
+	go func() {
+		otx.result <- wait()
+	}()
+}
+
+// queueFutureTransaction holds a sequencer transaction whose nonce is ahead of
+// the nonce of its sender until the transactions before it are applied
+func (s *SyncService) queueFutureTransaction(tx *types.Transaction) error {
+	from, err := types.Sender(s.signer, tx)
+	if err != nil {
+		return fmt.Errorf("invalid transaction: %w", core.ErrInvalidSender)
+	}
+	if err := s.futureQueue.add(from, tx, time.Now()); err != nil {
+		return fmt.Errorf("invalid transaction: %w: %v", core.ErrNonceTooHigh, err)
+	}
+	log.Debug("Queued future nonce transaction", "hash", tx.Hash().Hex(), "from", from.Hex(), "nonce", tx.Nonce())
+	return nil
+}
+
+// enqueueDeadlineLoop reports on the force inclusion deadlines of the pending
+// enqueue transactions over time
+func (s *SyncService) enqueueDeadlineLoop() {
+	t := time.NewTicker(enqueueDeadlineCheckInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case now := <-t.C:
+			s.enqueueDeadlines.check(now)
+		case <-s.ctx.Done():
+			return
+		}
+	}
+}
+
+// futureQueueLoop triggers the release of queued future nonce transactions on
+// every new chain head and drops the transactions that expired
+func (s *SyncService) futureQueueLoop() {
+	heads := make(chan core.ChainHeadEvent, 16)
+	sub := s.bc.SubscribeChainHeadEvent(heads)
+	defer sub.Unsubscribe()
+
+	expiry := time.NewTicker(futureQueueExpiryInterval)
+	defer expiry.Stop()
+
+	for {
+		select {
+		case <-heads:
+			// Never block here, releasing the transactions inserts new heads
+			select {
+			case s.futureRelease <- struct{}{}:
+			default:
+			}
+		case <-expiry.C:
+			if dropped := s.futureQueue.expire(time.Now()); dropped > 0 {
+				log.Info("Dropped expired future nonce transactions", "count", dropped)
+			}
+		case <-sub.Err():
+			return
+		case <-s.ctx.Done():
+			return
+		}
+	}
+}
+
+// futureReleaseLoop releases the queued future nonce transactions whenever
+// futureQueueLoop triggers it
+func (s *SyncService) futureReleaseLoop() {
+	for {
+		select {
+		case <-s.futureRelease:
+			s.releaseFutureTransactions()
+		case <-s.ctx.Done():
+			return
+		}
+	}
+}
+
+// releaseFutureTransactions applies the queued transactions of every sender
+// in nonce order for as long as the next nonce of the sender is queued
+func (s *SyncService) releaseFutureTransactions() {
+	for _, from := range s.futureQueue.senders() {
+		for {
+			if s.IsSyncing() || s.enqueueDeadlines.overdue(time.Now()) {
+				return
+			}
+			statedb, err := s.bc.State()
+			if err != nil {
+				log.Error("Cannot release future nonce transactions", "msg", err)
+				return
+			}
+			ftx := s.futureQueue.pop(from, statedb.GetNonce(from))
+			if ftx == nil {
+				break
+			}
+			err = s.releaseFutureTransaction(ftx.tx)
+			if errors.Is(err, core.ErrNonceTooHigh) {
+				// The transaction pool has not caught up with the chain yet,
+				// retry on the next chain head
+				if err := s.futureQueue.put(from, ftx); err != nil {
+					log.Debug("Cannot requeue future nonce transaction", "hash", ftx.tx.Hash().Hex(), "msg", err)
+				}
+				break
+			}
+			if err != nil {
+				log.Warn("Dropping future nonce transaction", "hash", ftx.tx.Hash().Hex(), "from", from.Hex(), "nonce", ftx.tx.Nonce(), "msg", err)
+				break
+			}
+			log.Debug("Released future nonce transaction", "hash", ftx.tx.Hash().Hex(), "from", from.Hex(), "nonce", ftx.tx.Nonce())
+		}
+	}
+}
+
+// releaseFutureTransaction validates a queued transaction again and applies
+// it. Fees are verified again as the L1 gas price may have changed while the
+// transaction was queued.
+func (s *SyncService) releaseFutureTransaction(tx *types.Transaction) error {
+	if err := s.verifyFee(tx); err != nil {
+		return err
+	}
+	if s.isOrderingActive() {
+		from, _ := types.Sender(s.signer, tx)
+		return s.applyOrdered(&OrderedTx{Tx: tx, From: from})
+	}
+	s.txLock.Lock()
+	if err := s.txpool.ValidateTx(tx); err != nil {
+		s.txLock.Unlock()
+		return err
+	}
+	return s.applySequencerTransaction(tx)
+}
+
+// FutureTransactions returns the number of sequencer transactions that are
+// queued until their nonce becomes executable
+func (s *SyncService) FutureTransactions() int {
+	return s.futureQueue.len()
 }
 
 // syncer represents a function that can sync remote items and then returns the
@@ -1040,25 +2090,136 @@ func (s *SyncService) syncBatches() (*uint64, error) {
 }
 
 // syncTransactionBatchRange will sync a range of batched transactions from
-// start to end (inclusive)
+// start to end (inclusive). Upcoming batches are prefetched while the
+// transactions are applied in order.
 func (s *SyncService) syncTransactionBatchRange(start, end uint64) error {
 	log.Info("Syncing transaction batch range", "start", start, "end", end)
-	for i := start; i <= end; i++ {
+	fetch := func(i uint64) (interface{}, error) {
 		log.Debug("Fetching transaction batch", "index", i)
-		_, txs, err := s.client.GetTransactionBatch(i)
+		batch, txs, err := s.client.GetTransactionBatch(i)
 		if err != nil {
-			return fmt.Errorf("Cannot get transaction batch: %w", err)
+			return nil, fmt.Errorf("Cannot get transaction batch: %w", err)
 		}
-		for _, tx := range txs {
-			if err := s.applyBatchedTransaction(tx); err != nil {
+		return &batchResponse{batch: batch, txs: txs}, nil
+	}
+	apply := func(i uint64, element interface{}) error {
+		res := element.(*batchResponse)
+		for _, tx := range res.txs {
+			if err := s.applyBatchedTransaction(tx, res.batch); err != nil {
 				return fmt.Errorf("cannot apply batched transaction: %w", err)
 			}
 		}
+		writeTransactionBatch(s.db, res.batch)
 		s.SetLatestBatchIndex(&i)
+		return nil
+	}
+	return s.prefetcher.run(start, end, fetch, apply)
+}
+
+// writeTransactionBatch stores the batch so that its transactions can be
+// looked up by the batch index
+func writeTransactionBatch(db ethdb.KeyValueWriter, batch *Batch) {
+	rawdb.WriteTransactionBatch(db, &rawdb.TransactionBatch{
+		Index:             batch.Index,
+		Root:              batch.Root,
+		Size:              uint64(batch.Size),
+		PrevTotalElements: uint64(batch.PrevTotalElements),
+		BlockNumber:       batch.BlockNumber,
+		Timestamp:         batch.Timestamp,
+		Submitter:         batch.Submitter,
+	})
+}
+
+// syncStateRootBatches will verify a range of state root batches from the
+// current known tip to the remote tip.
+func (s *SyncService) syncStateRootBatches() (*uint64, error) {
+	index, err := s.sync(s.client.GetLatestStateRootBatchIndex, s.GetNextStateRootBatchIndex, s.syncStateRootBatchRange)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot sync state root batches: %w", err)
+	}
+	return index, nil
+}
+
+// syncStateRootBatchRange will verify the state roots of a range of state
+// root batches from start to end (inclusive) against the local chain. It
+// stops at the first state root whose block has not been synced yet so that
+// the batch is verified again on the next poll.
+func (s *SyncService) syncStateRootBatchRange(start, end uint64) error {
+	log.Info("Verifying state root batch range", "start", start, "end", end)
+	for i := start; i <= end; i++ {
+		log.Debug("Fetching state root batch", "index", i)
+		batch, roots, err := s.client.GetStateRootBatch(i)
+		if err != nil {
+			return fmt.Errorf("Cannot get state root batch: %w", err)
+		}
+		for _, root := range roots {
+			ok, err := s.verifyStateRoot(root)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				log.Debug("Waiting for blocks to verify state root batch", "index", i, "ctc-index", root.Index)
+				return nil
+			}
+		}
+		// The range of the batch is kept so that it is verified again when
+		// its blocks are rewound
+		if batch != nil {
+			rawdb.WriteStateRootBatch(s.db, &rawdb.StateRootBatch{
+				Index:             batch.Index,
+				Root:              batch.Root,
+				Size:              uint64(batch.Size),
+				PrevTotalElements: uint64(batch.PrevTotalElements),
+				BlockNumber:       batch.BlockNumber,
+			})
+		}
+		s.SetLatestStateRootBatchIndex(&i)
 	}
 	return nil
 }
 
+// verifyStateRoot compares a state root that was submitted to L1 with the
+// state root of the local block at the same index. Mismatches are persisted
+// and halt the verifier when configured to. It returns false when the block
+// has not been synced yet.
+func (s *SyncService) verifyStateRoot(root *StateRoot) (bool, error) {
+	block, txIndex := s.blockByIndex(root.Index)
+	if block == nil {
+		return false, nil
+	}
+	// Only the state after the last transaction of a block is known locally
+	if txIndex != len(block.Transactions())-1 {
+		log.Debug("Skipping state root inside of block", "index", root.Index, "block", block.NumberU64())
+		return true, nil
+	}
+	if block.Root() == root.Value {
+		if rawdb.ReadStateRootMismatch(s.db, root.Index) != nil {
+			log.Info("State root mismatch resolved", "index", root.Index, "root", root.Value.Hex())
+			rawdb.DeleteStateRootMismatch(s.db, root.Index)
+		}
+		stateRootIndexGauge.Update(int64(root.Index))
+		return true, nil
+	}
+
+	rawdb.WriteStateRootMismatch(s.db, &rawdb.StateRootMismatch{
+		Index:       root.Index,
+		BatchIndex:  root.BatchIndex,
+		BlockNumber: block.NumberU64(),
+		BlockHash:   block.Hash(),
+		LocalRoot:   block.Root(),
+		RemoteRoot:  root.Value,
+	})
+	stateRootMismatchCounter.Inc(1)
+	log.Error("State root mismatch", "index", root.Index, "batch-index", root.BatchIndex, "block", block.NumberU64(),
+		"local", block.Root().Hex(), "remote", root.Value.Hex())
+
+	if s.haltOnStateRootMismatch {
+		atomic.StoreInt32(&s.halted, 1)
+		return false, fmt.Errorf("Halting at index %d: %w", root.Index, errStateRootMismatch)
+	}
+	return true, nil
+}
+
 // syncQueue will sync from the local tip to the known tip of the remote
 // enqueue transaction feed.
 func (s *SyncService) syncQueue() (*uint64, error) {
@@ -1070,17 +2231,55 @@ func (s *SyncService) syncQueue() (*uint64, error) {
 }
 
 // syncQueueTransactionRange will apply a range of queue transactions from
-// start to end (inclusive)
+// start to end (inclusive). Upcoming queue transactions are prefetched while
+// they are applied in order.
 func (s *SyncService) syncQueueTransactionRange(start, end uint64) error {
 	log.Info("Syncing enqueue transactions range", "start", start, "end", end)
-	for i := start; i <= end; i++ {
+	fetch := func(i uint64) (interface{}, error) {
 		tx, err := s.client.GetEnqueue(i)
 		if err != nil {
-			return fmt.Errorf("Canot get enqueue transaction; %w", err)
-		}
-		if err := s.applyTransaction(tx); err != nil {
-			return fmt.Errorf("Cannot apply transaction: %w", err)
+			return nil, fmt.Errorf("Canot get enqueue transaction; %w", err)
 		}
+		return tx, nil
+	}
+	return s.prefetcher.run(start, end, fetch, s.applyFetchedEnqueue)
+}
+
+// applyFetchedEnqueue applies an enqueue transaction that was fetched by the
+// prefetcher. Once the sequencer accepts transactions they are ordered
+// together with the enqueue transactions.
+func (s *SyncService) applyFetchedEnqueue(i uint64, element interface{}) error {
+	tx := element.(*types.Transaction)
+	// The force inclusion deadline is tracked until the enqueue is applied,
+	// an enqueue that failed to apply is still pending
+	if s.enqueueDeadlines.enabled() {
+		s.enqueueDeadlines.track(i, tx.L1Timestamp())
+	}
+	if err := s.applyFetchedEnqueueTx(i, element); err != nil {
+		return err
+	}
+	if s.enqueueDeadlines.enabled() {
+		s.enqueueDeadlines.done(i)
+	}
+	return nil
+}
+
+// applyFetchedEnqueueTx applies an enqueue transaction directly or through
+// the ordering policy once the sequencer accepts transactions
+func (s *SyncService) applyFetchedEnqueueTx(i uint64, element interface{}) error {
+	if !s.isOrderingActive() {
+		return s.applyFetchedTransaction(i, element)
+	}
+	tx := element.(*types.Transaction)
+	otx := &OrderedTx{Tx: tx, Enqueue: true}
+	if origin := tx.L1MessageSender(); origin != nil {
+		otx.From = *origin
+	}
+	if s.enqueueDeadlines.enabled() {
+		otx.Urgent = s.enqueueDeadlines.urgent(tx.L1Timestamp())
+	}
+	if err := s.applyOrdered(otx); err != nil {
+		return fmt.Errorf("Cannot apply transaction: %w", err)
 	}
 	return nil
 }
@@ -1102,17 +2301,25 @@ func (s *SyncService) syncTransactions(backend Backend) (*uint64, error) {
 }
 
 // syncTransactionRange will sync a range of transactions from
-// start to end (inclusive) from a specific Backend
+// start to end (inclusive) from a specific Backend. Upcoming transactions are
+// prefetched while they are applied in order.
 func (s *SyncService) syncTransactionRange(start, end uint64, backend Backend) error {
 	log.Info("Syncing transaction range", "start", start, "end", end, "backend", backend.String())
-	for i := start; i <= end; i++ {
+	fetch := func(i uint64) (interface{}, error) {
 		tx, err := s.client.GetTransaction(i, backend)
 		if err != nil {
-			return fmt.Errorf("cannot fetch transaction %d: %w", i, err)
-		}
-		if err := s.applyTransaction(tx); err != nil {
-			return fmt.Errorf("Cannot apply transaction: %w", err)
+			return nil, fmt.Errorf("cannot fetch transaction %d: %w", i, err)
 		}
+		return tx, nil
+	}
+	return s.prefetcher.run(start, end, fetch, s.applyFetchedTransaction)
+}
+
+// applyFetchedTransaction applies a transaction that was fetched by the
+// prefetcher
+func (s *SyncService) applyFetchedTransaction(i uint64, element interface{}) error {
+	if err := s.applyTransaction(element.(*types.Transaction)); err != nil {
+		return fmt.Errorf("Cannot apply transaction: %w", err)
 	}
 	return nil
 }
@@ -1125,20 +2332,44 @@ func (s *SyncService) updateEthContext() error {
 	if err != nil {
 		return fmt.Errorf("Cannot get eth context: %w", err)
 	}
+	s.setLatestEthContext(context)
 	current := time.Unix(int64(s.GetLatestL1Timestamp()), 0)
 	next := time.Unix(int64(context.Timestamp), 0)
 	if next.Sub(current) > s.timestampRefreshThreshold {
 		log.Info("Updating Eth Context", "timetamp", context.Timestamp, "blocknumber", context.BlockNumber)
-		s.SetLatestL1BlockNumber(context.BlockNumber)
-		s.SetLatestL1Timestamp(context.Timestamp)
+		s.SetLatestL1Context(context.BlockNumber, context.Timestamp)
 	}
 	return nil
 }
 
-// SubscribeNewTxsEvent registers a subscription of NewTxsEvent and
+// TxCommitRequests returns the channel that the miner receives the
+// transactions to include in the chain from. Each request must be answered.
+func (s *SyncService) TxCommitRequests() <-chan TxCommitRequest {
+	return s.commitCh
+}
+
+// FeeThresholds returns the factors by which a fee may be below or above the
+// expected fee and still be accepted. Nil means that there is no threshold.
+func (s *SyncService) FeeThresholds() (*big.Float, *big.Float) {
+	return s.feeThresholdDown, s.feeThresholdUp
+}
+
+// MaxTxsPerBlock returns the maximum number of queue origin sequencer
+// transactions that the miner may include in a single block
+func (s *SyncService) MaxTxsPerBlock() int {
+	return s.maxTxsPerBlock
+}
+
+// BlockWindow returns the maximum time that the miner waits for more
+// transactions before sealing a multi transaction block
+func (s *SyncService) BlockWindow() time.Duration {
+	return s.blockWindow
+}
+
+// SubscribeReorgEvent registers a subscription of ReorgEvent and
 // starts sending event to the given channel.
-func (s *SyncService) SubscribeNewTxsEvent(ch chan<- core.NewTxsEvent) event.Subscription {
-	return s.scope.Track(s.txFeed.Subscribe(ch))
+func (s *SyncService) SubscribeReorgEvent(ch chan<- ReorgEvent) event.Subscription {
+	return s.scope.Track(s.reorgFeed.Subscribe(ch))
 }
 
 func stringify(i *uint64) string {
@@ -20,13 +20,12 @@ import (
 	"github.com/MetisProtocol/l2geth/crypto"
 	"github.com/MetisProtocol/l2geth/eth/gasprice"
 	"github.com/MetisProtocol/l2geth/ethdb"
-	"github.com/MetisProtocol/l2geth/event"
 	"github.com/MetisProtocol/l2geth/params"
 	"github.com/MetisProtocol/l2geth/rollup/fees"
 )
 
 func setupLatestEthContextTest() (*SyncService, *EthContext) {
-	service, _, _, _ := newTestSyncService(false)
+	service, _, _ := newTestSyncService(false)
 	resp := &EthContext{
 		BlockNumber: uint64(10),
 		BlockHash:   common.Hash{},
@@ -97,7 +96,7 @@ func TestSyncServiceContextUpdated(t *testing.T) {
 // after the transaction enqueued event is emitted. Set `false` as
 // the argument to start as a sequencer
 func TestSyncServiceTransactionEnqueued(t *testing.T) {
-	service, txCh, _, err := newTestSyncService(false)
+	service, txCh, err := newTestSyncService(false)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -144,14 +143,10 @@ func TestSyncServiceTransactionEnqueued(t *testing.T) {
 	}()
 	// Wait for the tx to be confirmed into the chain and then
 	// make sure it is the transactions that was set up with in the mockclient
-	event := <-txCh
+	confirmed := commitTx(<-txCh)
 	if err != nil {
 		t.Fatal("sequencing failed", err)
 	}
-	if len(event.Txs) != 1 {
-		t.Fatal("Unexpected number of transactions")
-	}
-	confirmed := event.Txs[0]
 
 	if !reflect.DeepEqual(tx, confirmed) {
 		t.Fatal("different txs")
@@ -159,7 +154,7 @@ func TestSyncServiceTransactionEnqueued(t *testing.T) {
 }
 
 func TestTransactionToTipNoIndex(t *testing.T) {
-	service, txCh, _, err := newTestSyncService(false)
+	service, txCh, err := newTestSyncService(false)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -190,11 +185,10 @@ func TestTransactionToTipNoIndex(t *testing.T) {
 	go func() {
 		err = service.applyTransactionToTip(tx)
 	}()
-	event := <-txCh
+	confirmed := commitTx(<-txCh)
 	if err != nil {
 		t.Fatal("Cannot apply transaction to the tip")
 	}
-	confirmed := event.Txs[0]
 	// The transaction was applied without an index so the chain gave it the
 	// next index
 	index := confirmed.GetMeta().Index
@@ -210,7 +204,7 @@ func TestTransactionToTipNoIndex(t *testing.T) {
 }
 
 func TestTransactionToTipTimestamps(t *testing.T) {
-	service, txCh, _, err := newTestSyncService(false)
+	service, txCh, err := newTestSyncService(false)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -233,12 +227,11 @@ func TestTransactionToTipTimestamps(t *testing.T) {
 		go func() {
 			err = service.applyTransactionToTip(tx)
 		}()
-		event := <-txCh
+		conf := commitTx(<-txCh)
 		if err != nil {
 			t.Fatal(err)
 		}
 
-		conf := event.Txs[0]
 		// The index should be set to the next
 		if conf.GetMeta().Index == nil {
 			t.Fatal("Index is nil")
@@ -265,16 +258,15 @@ func TestTransactionToTipTimestamps(t *testing.T) {
 	go func() {
 		err = service.applyTransactionToTip(tx3)
 	}()
-	result := <-txCh
-	service.chainHeadCh <- core.ChainHeadEvent{}
+	result := commitTx(<-txCh)
 
-	if result.Txs[0].L1Timestamp() != ts {
+	if result.L1Timestamp() != ts {
 		t.Fatal("Timestamp not updated correctly")
 	}
 }
 
 func TestApplyIndexedTransaction(t *testing.T) {
-	service, txCh, _, err := newTestSyncService(true)
+	service, txCh, err := newTestSyncService(true)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -291,7 +283,7 @@ func TestApplyIndexedTransaction(t *testing.T) {
 	go func() {
 		err = service.applyIndexedTransaction(tx0)
 	}()
-	<-txCh
+	commitTx(<-txCh)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -302,7 +294,7 @@ func TestApplyIndexedTransaction(t *testing.T) {
 	go func() {
 		err = service.applyIndexedTransaction(tx1)
 	}()
-	<-txCh
+	commitTx(<-txCh)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -317,7 +309,7 @@ func TestApplyIndexedTransaction(t *testing.T) {
 }
 
 func TestApplyBatchedTransaction(t *testing.T) {
-	service, txCh, _, err := newTestSyncService(true)
+	service, txCh, err := newTestSyncService(true)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -327,11 +319,15 @@ func TestApplyBatchedTransaction(t *testing.T) {
 
 	// Ingest through applyBatchedTransaction which should set the latest
 	// verified index to the index of the transaction
+	batch := &Batch{
+		Index:       3,
+		BlockNumber: 100,
+		Submitter:   common.HexToAddress("0x42"),
+	}
 	go func() {
-		err = service.applyBatchedTransaction(tx0)
+		err = service.applyBatchedTransaction(tx0, batch)
 	}()
-	service.chainHeadCh <- core.ChainHeadEvent{}
-	<-txCh
+	commitTx(<-txCh)
 
 	// Catch race conditions with the database write
 	wg := new(sync.WaitGroup)
@@ -351,10 +347,369 @@ func TestApplyBatchedTransaction(t *testing.T) {
 	if *tx0.GetMeta().Index != *service.GetLatestVerifiedIndex() {
 		t.Fatal("Latest verified index mismatch")
 	}
+	// The batch membership of the transaction is recorded
+	entry := rawdb.ReadTxBatchEntry(service.db, 0)
+	if entry == nil {
+		t.Fatal("No batch entry for the transaction")
+	}
+	if entry.BatchIndex != batch.Index || entry.L1BlockNumber != batch.BlockNumber || entry.Submitter != batch.Submitter {
+		t.Fatalf("Unexpected batch entry: %v", entry)
+	}
+}
+
+func TestApplyHistoricalTransactionReorg(t *testing.T) {
+	service, txCh, blocks, err := newTestSyncServiceWithChain(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reorgCh := make(chan ReorgEvent, 1)
+	sub := service.SubscribeReorgEvent(reorgCh)
+	defer sub.Unsubscribe()
+
+	// A transaction that matches the local chain does not cause a reorg
+	local := blocks[0].Transactions()[0]
+	if err := service.applyHistoricalTransaction(local); err != nil {
+		t.Fatal(err)
+	}
+	if head := service.bc.CurrentBlock().NumberU64(); head != 4 {
+		t.Fatalf("Unexpected head: got %d, expected 4", head)
+	}
+
+	// A transaction at index 2 that does not match the local chain causes
+	// the chain to be rewound to block 2
+	tx := setMockTxL1Timestamp(setMockTxIndex(mockTx(), 2), 10)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- service.applyHistoricalTransaction(tx)
+	}()
+	confirmed := commitTx(<-txCh)
+	if err := <-errCh; err != nil {
+		t.Fatal(err)
+	}
+	if confirmed.Hash() != tx.Hash() {
+		t.Fatal("Mismatched transaction was not applied to the tip")
+	}
+
+	reorg := <-reorgCh
+	if reorg.Index != 2 || reorg.OldHead != 4 || reorg.NewHead != 2 {
+		t.Fatalf("Unexpected reorg event: %#v", reorg)
+	}
+	// The sequencer transaction after the mismatched one is reported
+	if len(reorg.Orphaned) != 1 || reorg.Orphaned[0].Hash() != blocks[3].Transactions()[0].Hash() {
+		t.Fatalf("Unexpected orphaned transactions: %v", reorg.Orphaned)
+	}
+	if head := service.bc.CurrentBlock().NumberU64(); head != 2 {
+		t.Fatalf("Unexpected head: got %d, expected 2", head)
+	}
+	// The mismatched transaction was applied to the tip so the latest index
+	// is its index
+	if index := service.GetLatestIndex(); index == nil || *index != 2 {
+		t.Fatalf("Unexpected latest index: %s", stringify(index))
+	}
+	if index := service.GetLatestVerifiedIndex(); index == nil || *index != 1 {
+		t.Fatalf("Unexpected latest verified index: %s", stringify(index))
+	}
+	if index := service.GetLatestEnqueueIndex(); index == nil || *index != 0 {
+		t.Fatalf("Unexpected latest queue index: %s", stringify(index))
+	}
+}
+
+// A mismatch in a multi transaction block that crosses a batch boundary
+// rewinds the batches so that the removed transactions are synced again
+func TestApplyHistoricalTransactionReorgBatchBoundary(t *testing.T) {
+	// The blocks hold the indices 0-1, 2-3 and 4-5
+	service, _, _, err := newTestSyncServiceWithBlocks(3, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Block 2 holds the last transaction of the first batch and the first
+	// transaction of the second one
+	rawdb.WriteTransactionBatch(service.db, &rawdb.TransactionBatch{Index: 0, Size: 3})
+	rawdb.WriteTransactionBatch(service.db, &rawdb.TransactionBatch{Index: 1, Size: 3, PrevTotalElements: 3})
+	service.SetLatestBatchIndex(newUint64(0))
+	rawdb.WriteStateRootBatch(service.db, &rawdb.StateRootBatch{Index: 0, Size: 2})
+	rawdb.WriteStateRootBatch(service.db, &rawdb.StateRootBatch{Index: 1, Size: 4, PrevTotalElements: 2})
+	service.SetLatestStateRootBatchIndex(newUint64(1))
+
+	// The mismatch at index 3 removes block 2 along with index 2
+	tx := setMockTxL1Timestamp(setMockTxIndex(mockTx(), 3), 10)
+	if err := service.applyHistoricalTransaction(tx); err == nil {
+		t.Fatal("Expected the rewound index 2 to be synced first")
+	}
+	if index := service.GetLatestIndex(); index == nil || *index != 1 {
+		t.Fatalf("Unexpected latest index: %s", stringify(index))
+	}
+	// Both batches that hold index 2 are synced and verified again
+	if index := service.GetLatestBatchIndex(); index != nil {
+		t.Fatalf("Unexpected latest batch index: %s", stringify(index))
+	}
+	if index := service.GetLatestStateRootBatchIndex(); index == nil || *index != 0 {
+		t.Fatalf("Unexpected latest state root batch index: %s", stringify(index))
+	}
+}
+
+// A remote that is behind the local chain, such as a lagging endpoint after
+// a failover, has nothing to sync
+func TestSyncTransactionsLaggingRemote(t *testing.T) {
+	service, _, _, err := newTestSyncServiceWithChain(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	service.prefetcher = newPrefetcher(4, 8)
+	lagging := setMockTxIndex(mockTx(), 1)
+	setupMockClient(service, map[string]interface{}{
+		"GetTransaction": []*types.Transaction{lagging},
+	})
+	index, err := service.syncTransactions(BackendL2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if index == nil || *index != 1 {
+		t.Fatalf("Unexpected remote index: %s", stringify(index))
+	}
+	if mock := service.client.(*mockClient); mock.getTransactionCallCount != 0 {
+		t.Fatalf("Fetched %d transactions from a lagging remote", mock.getTransactionCallCount)
+	}
+	if head := service.bc.CurrentBlock().NumberU64(); head != 4 {
+		t.Fatalf("Unexpected head: got %d, expected 4", head)
+	}
+}
+
+func TestApplyTransactionToTipCommitError(t *testing.T) {
+	service, txCh, _, err := newTestSyncServiceWithChain(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The miner fails to add the transaction to the chain
+	tx := setMockTxL1Timestamp(mockTx(), 10)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- service.applyTransactionToTip(tx)
+	}()
+	req := <-txCh
+	req.Result <- TxCommitResult{Err: fmt.Errorf("%w: bad transaction", ErrTxCommitFailed)}
+	if err := <-errCh; !errors.Is(err, ErrTxCommitFailed) {
+		t.Fatalf("Expected commit failure, got %v", err)
+	}
+	// The indices are reset to the tip of the chain
+	if index := service.GetLatestIndex(); index == nil || *index != 3 {
+		t.Fatalf("Unexpected latest index: %s", stringify(index))
+	}
+	if ts := service.GetLatestL1Timestamp(); ts != 3 {
+		t.Fatalf("Unexpected latest L1 timestamp: %d", ts)
+	}
+
+	// Nothing answers the request so the commit times out
+	service.txCommitTimeout = 10 * time.Millisecond
+	tx = setMockTxL1Timestamp(mockTx(), 10)
+	if err := service.applyTransactionToTip(tx); !errors.Is(err, ErrTxCommitTimeout) {
+		t.Fatalf("Expected commit timeout, got %v", err)
+	}
+	if index := service.GetLatestIndex(); index == nil || *index != 3 {
+		t.Fatalf("Unexpected latest index: %s", stringify(index))
+	}
+}
+
+// A failed transaction of a multi transaction block fails the transactions in
+// flight with it, the indices are only reset once none of them is in flight
+func TestSubmitSequencerTransactionPendingFailure(t *testing.T) {
+	service, txCh, _, err := newTestSyncServiceWithChain(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	service.maxTxsPerBlock = 2
+
+	submit := func() (func() error, error) {
+		service.txLock.Lock()
+		return service.submitSequencerTransaction(setMockTxL1Timestamp(mockTx(), 10))
+	}
+	waitCh := make(chan func() error, 2)
+	go func() {
+		for i := 0; i < 2; i++ {
+			wait, err := submit()
+			if err != nil {
+				t.Error(err)
+			}
+			waitCh <- wait
+		}
+	}()
+	reqs := []TxCommitRequest{<-txCh, <-txCh}
+	waits := []func() error{<-waitCh, <-waitCh}
+
+	// The first transaction fails while the second one is in flight
+	reqs[0].Result <- TxCommitResult{Err: fmt.Errorf("%w: bad block", ErrTxCommitFailed)}
+	if err := waits[0](); !errors.Is(err, ErrTxCommitFailed) {
+		t.Fatalf("Expected commit failure, got %v", err)
+	}
+	if index := service.GetLatestIndex(); index == nil || *index != 5 {
+		t.Fatalf("Unexpected latest index: %s", stringify(index))
+	}
+	if _, err := submit(); !errors.Is(err, ErrTxCommitFailed) {
+		t.Fatalf("Expected pending block failure, got %v", err)
+	}
+
+	// The indices are reset once the last transaction in flight is answered
+	reqs[1].Result <- TxCommitResult{Err: fmt.Errorf("%w: bad block", ErrTxCommitFailed)}
+	if err := waits[1](); !errors.Is(err, ErrTxCommitFailed) {
+		t.Fatalf("Expected commit failure, got %v", err)
+	}
+	if index := service.GetLatestIndex(); index == nil || *index != 3 {
+		t.Fatalf("Unexpected latest index: %s", stringify(index))
+	}
+	go submit()
+	if req := <-txCh; req.Tx.GetMeta().Index == nil || *req.Tx.GetMeta().Index != 4 {
+		t.Fatalf("Unexpected index: %s", stringify(req.Tx.GetMeta().Index))
+	}
+}
+
+func TestMultiTransactionBlocks(t *testing.T) {
+	service, _, blocks, err := newTestSyncServiceWithBlocks(3, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The CTC indices are mapped to the position of the transactions
+	for index := uint64(0); index < 6; index++ {
+		block, txIndex := service.blockByIndex(index)
+		if block == nil || block.NumberU64() != index/2+1 || txIndex != int(index%2) {
+			t.Fatalf("Unexpected position of index %d", index)
+		}
+	}
+	if block, _ := service.blockByIndex(6); block != nil {
+		t.Fatalf("Unexpected block %d for index 6", block.NumberU64())
+	}
+
+	// A transaction that matches the second transaction of a block does not
+	// cause a reorg
+	local := blocks[2].Transactions()[1]
+	if err := service.applyHistoricalTransaction(local); err != nil {
+		t.Fatal(err)
+	}
+	if head := service.bc.CurrentBlock().NumberU64(); head != 3 {
+		t.Fatalf("Unexpected head: got %d, expected 3", head)
+	}
+
+	for index := uint64(0); index < 6; index++ {
+		rawdb.WriteTxBatchEntry(service.db, index, &rawdb.TxBatchEntry{BatchIndex: index})
+	}
+	// A mismatch in the second transaction of a block removes the whole block
+	// so the first transaction of the block must be synced again
+	tx := setMockTxL1Timestamp(setMockTxIndex(mockTx(), 5), 10)
+	if err := service.applyHistoricalTransaction(tx); err == nil {
+		t.Fatal("Expected the transaction at index 4 to be missing")
+	}
+	if head := service.bc.CurrentBlock().NumberU64(); head != 2 {
+		t.Fatalf("Unexpected head: got %d, expected 2", head)
+	}
+	if index := service.GetLatestIndex(); index == nil || *index != 3 {
+		t.Fatalf("Unexpected latest index: %s", stringify(index))
+	}
+	if index := service.GetLatestEnqueueIndex(); index == nil || *index != 0 {
+		t.Fatalf("Unexpected latest queue index: %s", stringify(index))
+	}
+	// The lookup entries of the removed block are deleted
+	if entry := rawdb.ReadCTCLookupEntry(service.db, 4); entry != nil {
+		t.Fatalf("Unexpected lookup entry for index 4: %v", entry)
+	}
+	if block, _ := service.blockByIndex(4); block != nil {
+		t.Fatalf("Unexpected block %d for index 4", block.NumberU64())
+	}
+	if entry := rawdb.ReadCTCLookupEntry(service.db, 3); entry == nil || entry.BlockHash != blocks[1].Hash() {
+		t.Fatalf("Unexpected lookup entry for index 3: %v", entry)
+	}
+	if entry := rawdb.ReadQueueLookupEntry(service.db, 0); entry == nil || entry.BlockHash != blocks[0].Hash() {
+		t.Fatalf("Unexpected lookup entry for queue index 0: %v", entry)
+	}
+	// The batch membership and the metadata of the removed transactions are
+	// deleted as well
+	for index := uint64(0); index < 6; index++ {
+		if entry := rawdb.ReadTxBatchEntry(service.db, index); (entry != nil) != (index < 4) {
+			t.Fatalf("Unexpected batch entry for index %d: %v", index, entry)
+		}
+	}
+	for txIndex := uint64(0); txIndex < 2; txIndex++ {
+		if rawdb.ReadTransactionMetaRawAt(service.db, 2, txIndex) == nil {
+			t.Fatalf("Missing metadata of transaction %d in block 2", txIndex)
+		}
+		if rawdb.ReadTransactionMetaRawAt(service.db, 3, txIndex) != nil {
+			t.Fatalf("Unexpected metadata of transaction %d in block 3", txIndex)
+		}
+	}
+}
+
+func TestVerifyStateRoots(t *testing.T) {
+	service, _, blocks, err := newTestSyncServiceWithChain(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := newElementStore(0)
+	root := func(index, batchIndex uint64, value common.Hash) *StateRoot {
+		return &StateRoot{Index: index, BatchIndex: batchIndex, Value: value}
+	}
+	store.addStateRootBatch(&Batch{Index: 0}, []*StateRoot{
+		root(0, 0, blocks[0].Root()),
+		root(1, 0, blocks[1].Root()),
+	})
+	store.addStateRootBatch(&Batch{Index: 1}, []*StateRoot{
+		root(2, 1, blocks[2].Root()),
+		root(3, 1, common.Hash{0x01}),
+	})
+	// The block for the state root at index 4 has not been synced yet
+	store.addStateRootBatch(&Batch{Index: 2}, []*StateRoot{
+		root(4, 2, common.Hash{0x02}),
+	})
+	service.client = &ReplayClient{store: store}
+
+	if err := service.syncStateRootBatchesToTip(); err != nil {
+		t.Fatal(err)
+	}
+	if index := service.GetLatestStateRootBatchIndex(); index == nil || *index != 1 {
+		t.Fatalf("Unexpected latest state root batch index: %s", stringify(index))
+	}
+	mismatches := rawdb.ReadStateRootMismatches(service.db, 0, 10)
+	if len(mismatches) != 1 {
+		t.Fatalf("Expected 1 state root mismatch, got %d", len(mismatches))
+	}
+	mismatch := mismatches[0]
+	if mismatch.Index != 3 || mismatch.BlockNumber != 4 || mismatch.LocalRoot != blocks[3].Root() || mismatch.RemoteRoot != (common.Hash{0x01}) {
+		t.Fatalf("Unexpected state root mismatch: %#v", mismatch)
+	}
+	if service.IsHalted() {
+		t.Fatal("Verifier halted without halt on mismatch")
+	}
+
+	// The verifier stops syncing at the mismatch when configured to halt
+	service.haltOnStateRootMismatch = true
+	service.SetLatestStateRootBatchIndex(newUint64(0))
+	if err := service.syncStateRootBatchesToTip(); !errors.Is(err, errStateRootMismatch) {
+		t.Fatalf("Expected state root mismatch, got %v", err)
+	}
+	if !service.IsHalted() {
+		t.Fatal("Verifier did not halt")
+	}
+	if index := service.GetLatestStateRootBatchIndex(); index == nil || *index != 0 {
+		t.Fatalf("Unexpected latest state root batch index: %s", stringify(index))
+	}
+	if err := service.verify(); !errors.Is(err, errStateRootMismatch) {
+		t.Fatalf("Expected halted verifier, got %v", err)
+	}
+
+	// A matching state root resolves the mismatch
+	store.addStateRootBatch(&Batch{Index: 1}, []*StateRoot{
+		root(2, 1, blocks[2].Root()),
+		root(3, 1, blocks[3].Root()),
+	})
+	service.halted = 0
+	if err := service.syncStateRootBatchesToTip(); err != nil {
+		t.Fatal(err)
+	}
+	if mismatches := rawdb.ReadStateRootMismatches(service.db, 0, 10); len(mismatches) != 0 {
+		t.Fatalf("Expected no state root mismatches, got %d", len(mismatches))
+	}
 }
 
 func TestIsAtTip(t *testing.T) {
-	service, _, _, err := newTestSyncService(true)
+	service, _, err := newTestSyncService(true)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -427,7 +782,7 @@ func TestIsAtTip(t *testing.T) {
 }
 
 func TestSyncQueue(t *testing.T) {
-	service, txCh, _, err := newTestSyncService(true)
+	service, txCh, err := newTestSyncService(true)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -447,9 +802,7 @@ func TestSyncQueue(t *testing.T) {
 	}()
 
 	for i := 0; i < 4; i++ {
-		service.chainHeadCh <- core.ChainHeadEvent{}
-		event := <-txCh
-		tx := event.Txs[0]
+		tx := commitTx(<-txCh)
 		if *tx.GetMeta().QueueIndex != uint64(i) {
 			t.Fatal("queue index mismatch")
 		}
@@ -484,7 +837,7 @@ func TestSyncQueue(t *testing.T) {
 }
 
 func TestSyncServiceL1GasPrice(t *testing.T) {
-	service, _, _, err := newTestSyncService(true)
+	service, _, err := newTestSyncService(true)
 	setupMockClient(service, map[string]interface{}{})
 
 	if err != nil {
@@ -514,8 +867,88 @@ func TestSyncServiceL1GasPrice(t *testing.T) {
 	}
 }
 
+func TestSyncServiceL1GasPriceSmoothing(t *testing.T) {
+	cfg, txPool, chain, db, err := newTestSyncServiceDeps(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The floor applies to the L1 gas price of 1 returned by the mock client
+	cfg.L1GasPriceFloor = big.NewInt(10)
+	service, err := NewSyncService(context.Background(), cfg, txPool, chain, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	setupMockClient(service, map[string]interface{}{})
+
+	if err := service.updateL1GasPrice(); err != nil {
+		t.Fatal(err)
+	}
+	gasPrice, err := service.RollupGpo.SuggestL1GasPrice(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gasPrice.Cmp(cfg.L1GasPriceFloor) != 0 {
+		t.Fatalf("expected smoothed gas price %d, got %d", cfg.L1GasPriceFloor, gasPrice)
+	}
+
+	// A floor above the ceiling is rejected
+	cfg.L1GasPriceCeiling = big.NewInt(5)
+	if _, err := NewSyncService(context.Background(), cfg, txPool, chain, db); !errors.Is(err, errBadConfig) {
+		t.Fatalf("expected bad config error, got %v", err)
+	}
+}
+
+func TestWriteTransactionFee(t *testing.T) {
+	service, txCh, blocks, err := newTestSyncServiceWithChain(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	service.verifier = false
+	service.RollupGpo = gasprice.NewRollupOracle(nil)
+	service.RollupGpo.SetL1GasPrice(big.NewInt(10))
+	service.RollupGpo.SetL2GasPrice(big.NewInt(2))
+
+	// The gas prices are captured when the transactions are submitted
+	go func() {
+		<-txCh
+		<-txCh
+	}()
+	enqueue := blocks[0].Transactions()[0]
+	enqueueReq, err := service.sendCommitRequest(enqueue, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := blocks[1].Transactions()[0]
+	req, err := service.sendCommitRequest(tx, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	service.RollupGpo.SetL1GasPrice(big.NewInt(20))
+	service.RollupGpo.SetL2GasPrice(big.NewInt(4))
+
+	// L1 to L2 transactions are not charged fees
+	service.writeTransactionFee(enqueueReq)
+	if rawdb.ReadTransactionFee(service.db, enqueue.Hash()) != nil {
+		t.Fatal("Unexpected fee for L1 to L2 transaction")
+	}
+
+	service.writeTransactionFee(req)
+	fee := rawdb.ReadTransactionFee(service.db, tx.Hash())
+	if fee == nil {
+		t.Fatal("No fee recorded for sequencer transaction")
+	}
+	l2GasLimit := fees.DecodeL2GasLimit(new(big.Int).SetUint64(tx.Gas()))
+	want := fees.CalculateFeeBreakdown(tx.Data(), big.NewInt(10), l2GasLimit, big.NewInt(2))
+	if fee.L1GasUsed != want.L1GasLimit.Uint64() || fee.L1Fee.Cmp(want.L1Fee) != 0 || fee.L1GasPrice.Uint64() != 10 {
+		t.Fatalf("Unexpected L1 fee: %v", fee)
+	}
+	if fee.L2GasPrice.Uint64() != 2 || fee.L2Fee.Cmp(want.L2Fee) != 0 {
+		t.Fatalf("Unexpected L2 fee: %v", fee)
+	}
+}
+
 func TestSyncServiceL2GasPrice(t *testing.T) {
-	service, _, _, err := newTestSyncService(true)
+	service, _, err := newTestSyncService(true)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -550,7 +983,7 @@ func TestSyncServiceL2GasPrice(t *testing.T) {
 }
 
 func TestSyncServiceMinL2GasPrice(t *testing.T) {
-	service, _, _, err := newTestSyncService(true)
+	service, _, err := newTestSyncService(true)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -582,7 +1015,7 @@ func TestSyncServiceMinL2GasPrice(t *testing.T) {
 }
 
 func TestSyncServiceGasPriceOracleOwnerAddress(t *testing.T) {
-	service, _, _, err := newTestSyncService(true)
+	service, _, err := newTestSyncService(true)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -616,7 +1049,7 @@ func TestSyncServiceGasPriceOracleOwnerAddress(t *testing.T) {
 // Only the gas price oracle owner can send 0 gas price txs
 // when fees are enforced
 func TestFeeGasPriceOracleOwnerTransactions(t *testing.T) {
-	service, _, _, err := newTestSyncService(true)
+	service, _, err := newTestSyncService(true)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -672,8 +1105,7 @@ func TestFeeGasPriceOracleOwnerTransactions(t *testing.T) {
 
 // Pass true to set as a verifier
 func TestSyncServiceSync(t *testing.T) {
-	service, txCh, sub, err := newTestSyncService(true)
-	defer sub.Unsubscribe()
+	service, txCh, err := newTestSyncService(true)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -708,23 +1140,18 @@ func TestSyncServiceSync(t *testing.T) {
 	go func() {
 		err = service.syncTransactionsToTip()
 	}()
-	event := <-txCh
+	confirmed := commitTx(<-txCh)
 	if err != nil {
 		t.Fatal("verification failed", err)
 	}
 
-	if len(event.Txs) != 1 {
-		t.Fatal("Unexpected number of transactions")
-	}
-	confirmed := event.Txs[0]
-
 	if !reflect.DeepEqual(tx, confirmed) {
 		t.Fatal("different txs")
 	}
 }
 
 func TestInitializeL1ContextPostGenesis(t *testing.T) {
-	service, _, _, err := newTestSyncService(true)
+	service, _, err := newTestSyncService(true)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -861,21 +1288,95 @@ func newTestSyncServiceDeps(isVerifier bool) (Config, *core.TxPool, *core.BlockC
 	return cfg, txPool, chain, db, nil
 }
 
-func newTestSyncService(isVerifier bool) (*SyncService, chan core.NewTxsEvent, event.Subscription, error) {
+func newTestSyncService(isVerifier bool) (*SyncService, <-chan TxCommitRequest, error) {
 	cfg, txPool, chain, db, err := newTestSyncServiceDeps(isVerifier)
 	if err != nil {
-		return nil, nil, nil, fmt.Errorf("Cannot initialize syncservice: %w", err)
+		return nil, nil, fmt.Errorf("Cannot initialize syncservice: %w", err)
 	}
 	service, err := NewSyncService(context.Background(), cfg, txPool, chain, db)
 	if err != nil {
-		return nil, nil, nil, fmt.Errorf("Cannot initialize syncservice: %w", err)
+		return nil, nil, fmt.Errorf("Cannot initialize syncservice: %w", err)
 	}
 
-	service.RollupGpo = gasprice.NewRollupOracle()
-	txCh := make(chan core.NewTxsEvent, 1)
-	sub := service.SubscribeNewTxsEvent(txCh)
+	service.RollupGpo = gasprice.NewRollupOracle(nil)
+	return service, service.TxCommitRequests(), nil
+}
 
-	return service, txCh, sub, nil
+// newTestSyncServiceWithChain creates a SyncService backed by a chain of `n`
+// blocks that each hold a single transaction. The transaction in the first
+// block is an L1 to L2 transaction and the rest are sequencer transactions.
+// The rollup indices are set to the tip of the chain.
+func newTestSyncServiceWithChain(n int) (*SyncService, <-chan TxCommitRequest, []*types.Block, error) {
+	return newTestSyncServiceWithBlocks(n, 1)
+}
+
+// newTestSyncServiceWithBlocks creates a SyncService backed by a chain of `n`
+// blocks that each hold `txsPerBlock` transactions
+func newTestSyncServiceWithBlocks(n, txsPerBlock int) (*SyncService, <-chan TxCommitRequest, []*types.Block, error) {
+	key, _ := crypto.GenerateKey()
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+
+	chainCfg := params.AllEthashProtocolChanges
+	chainCfg.ChainID = big.NewInt(420)
+	signer := types.NewEIP155Signer(chainCfg.ChainID)
+
+	engine := ethash.NewFaker()
+	db := rawdb.NewMemoryDatabase()
+	gspec := &core.Genesis{
+		Config: chainCfg,
+		Alloc:  core.GenesisAlloc{addr: {Balance: big.NewInt(params.Ether)}},
+	}
+	genesis := gspec.MustCommit(db)
+
+	blocks, _ := core.GenerateChain(chainCfg, genesis, engine, db, n, func(i int, gen *core.BlockGen) {
+		for j := 0; j < txsPerBlock; j++ {
+			tx := types.NewTransaction(gen.TxNonce(addr), common.Address{0x01}, big.NewInt(1), params.TxGas, big.NewInt(0), nil)
+			tx, _ = types.SignTx(tx, signer, key)
+			index := uint64(i*txsPerBlock + j)
+			queueOrigin := types.QueueOriginSequencer
+			var queueIndex *uint64
+			if index == 0 {
+				queueOrigin = types.QueueOriginL1ToL2
+				queueIndex = newUint64(0)
+			}
+			meta := types.NewTransactionMeta(big.NewInt(int64(i)), uint64(i), &addr, queueOrigin, &index, queueIndex, nil)
+			tx.SetTransactionMeta(meta)
+			gen.AddTx(tx)
+		}
+	})
+
+	chain, err := core.NewBlockChain(db, nil, chainCfg, engine, vm.Config{}, nil)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("Cannot initialize blockchain: %w", err)
+	}
+	if _, err := chain.InsertChain(blocks); err != nil {
+		return nil, nil, nil, fmt.Errorf("Cannot insert chain: %w", err)
+	}
+	txPool := core.NewTxPool(core.TxPoolConfig{PriceLimit: 0}, chainCfg, chain)
+	cfg := Config{
+		CanonicalTransactionChainDeployHeight: big.NewInt(0),
+		IsVerifier:                            true,
+		RollupClientHttp:                      "",
+		Backend:                               BackendL1,
+	}
+	service, err := NewSyncService(context.Background(), cfg, txPool, chain, db)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("Cannot initialize syncservice: %w", err)
+	}
+	service.RollupGpo = gasprice.NewRollupOracle(nil)
+	tip := uint64(n*txsPerBlock - 1)
+	service.SetLatestIndex(&tip)
+	service.SetLatestVerifiedIndex(&tip)
+	service.SetLatestEnqueueIndex(newUint64(0))
+	return service, service.TxCommitRequests(), blocks, nil
+}
+
+// commitTx answers a commit request like the miner does once the transaction
+// is added to the chain and returns the committed transaction
+func commitTx(req TxCommitRequest) *types.Transaction {
+	block := types.NewBlockWithHeader(&types.Header{}).WithBody(types.Transactions{req.Tx}, nil)
+	req.Result <- TxCommitResult{Block: block}
+	return req.Tx
 }
 
 type mockClient struct {
@@ -893,7 +1394,7 @@ type mockClient struct {
 func setupMockClient(service *SyncService, responses map[string]interface{}) {
 	client := newMockClient(responses)
 	service.client = client
-	service.RollupGpo = gasprice.NewRollupOracle()
+	service.RollupGpo = gasprice.NewRollupOracle(nil)
 }
 
 func newMockClient(responses map[string]interface{}) *mockClient {
@@ -1017,6 +1518,14 @@ func (m *mockClient) GetLatestTransactionBatchIndex() (*uint64, error) {
 	return nil, nil
 }
 
+func (m *mockClient) GetLatestStateRootBatchIndex() (*uint64, error) {
+	return nil, errElementNotFound
+}
+
+func (m *mockClient) GetStateRootBatch(index uint64) (*Batch, []*StateRoot, error) {
+	return nil, nil, errElementNotFound
+}
+
 func (m *mockClient) GetLatestTransactionIndex(backend Backend) (*uint64, error) {
 	tx, err := m.GetLatestTransaction(backend)
 	if err != nil {
@@ -2,7 +2,10 @@ package rollup
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"math/big"
+	"time"
 
 	"github.com/MetisProtocol/l2geth/common"
 	"github.com/MetisProtocol/l2geth/core/types"
@@ -15,6 +18,56 @@ type OVMContext struct {
 	timestamp   uint64
 }
 
+var (
+	// ErrTxCommitFailed is returned when the miner cannot include a
+	// transaction in a block
+	ErrTxCommitFailed = errors.New("transaction commit failed")
+	// ErrTxCommitTimeout is returned when a transaction is not included in a
+	// block in time
+	ErrTxCommitTimeout = errors.New("transaction commit timed out")
+)
+
+// TxCommitRequest is sent by the SyncService to the miner for each
+// transaction that must be included in the chain. The miner answers exactly
+// once on Result, with either the block that includes the transaction or an
+// error. Result is buffered so that answering never blocks.
+type TxCommitRequest struct {
+	Tx     *types.Transaction
+	Result chan TxCommitResult
+	// Confirmed is set when the transaction was read from the canonical
+	// transaction chain, its L1 context is final
+	Confirmed bool
+	// L1GasPrice and L2GasPrice are the prices of the gas price oracle when
+	// a sequencer transaction was submitted, its fee is recorded with them
+	L1GasPrice *big.Int
+	L2GasPrice *big.Int
+	// Deadline is the time by which the SyncService stops waiting for the
+	// result, the miner must not wait for the block any longer than that
+	Deadline time.Time
+}
+
+// TxCommitResult is the answer of the miner to a TxCommitRequest
+type TxCommitResult struct {
+	Block *types.Block
+	Err   error
+}
+
+// ReorgEvent is emitted by the SyncService after it rewinds the local chain
+// because a transaction does not match the transaction at the same index in
+// the Canonical Transaction Chain.
+type ReorgEvent struct {
+	// Index is the CTC index of the mismatched transaction
+	Index uint64
+	// OldHead is the block number of the tip before the reorg
+	OldHead uint64
+	// NewHead is the block number of the tip after the reorg
+	NewHead uint64
+	// Orphaned are the sequencer transactions after Index that were removed.
+	// The ones that are part of a later batch are applied again when the
+	// batch is synced, the others are dropped.
+	Orphaned []*types.Transaction
+}
+
 // Backend represents the type of transactions that are being synced.
 // The different types have different security models.
 type Backend uint
@@ -56,6 +109,49 @@ const (
 	BackendL2
 )
 
+// ClientType represents the transport that the RollupClient uses to fetch
+// data from the data transport layer
+type ClientType uint
+
+// String implements the Stringer interface
+func (c ClientType) String() string {
+	switch c {
+	case ClientHTTP:
+		return "http"
+	case ClientWebsocket:
+		return "ws"
+	case ClientReplay:
+		return "replay"
+	default:
+		return ""
+	}
+}
+
+// NewClientType creates a ClientType from a human readable string
+func NewClientType(typ string) (ClientType, error) {
+	switch typ {
+	case "http":
+		return ClientHTTP, nil
+	case "ws":
+		return ClientWebsocket, nil
+	case "replay":
+		return ClientReplay, nil
+	default:
+		return 0, fmt.Errorf("Unknown ClientType: %s", typ)
+	}
+}
+
+const (
+	// ClientHTTP polls the data transport layer over HTTP
+	ClientHTTP ClientType = iota
+	// ClientWebsocket subscribes to the data transport layer over a
+	// websocket and uses HTTP for everything that is not pushed
+	ClientWebsocket
+	// ClientReplay replays an archive of enqueues, transactions and batches
+	// from disk without connecting to a data transport layer
+	ClientReplay
+)
+
 func isCtcTxEqual(a, b *types.Transaction) bool {
 	if a.To() == nil && b.To() != nil {
 		if !bytes.Equal(b.To().Bytes(), common.Address{}.Bytes()) {
@@ -135,7 +135,7 @@ func StartClefAccountManager(ksLocation string, nousb, lightKDF bool, scpath str
 	}
 	// support password based accounts
 	if len(ksLocation) > 0 {
-		backends = append(backends, keystore.NewKeyStore(ksLocation, n, p))
+		backends = append(backends, keystore.NewKeyStore(ksLocation, n, p, false))
 	}
 	if !nousb {
 		// Start a USB hub for Ledger hardware wallets